@@ -0,0 +1,194 @@
+package core
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// AESModes lists the mode names accepted by ParseAESMode: BlockModes and the
+// AES only GCM, XTS and GCM-SIV.
+func AESModes() []string {
+	return append([]string(nil), blockModeNames...)
+}
+
+// ParseAESMode is ParseBlockMode for AES, which also takes GCM, XTS and GCM-SIV.
+func ParseAESMode(s string) (BlockMode, error) {
+	for i, name := range blockModeNames {
+		if name == s {
+			return BlockMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: AES mode %q", ErrUnknownAlgorithm, s)
+}
+
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+// EncryptAES encrypts data with a 16, 24 or 32 byte key. The random IV or
+// nonce is prepended to the result, except in ECB, which has none, and XTS,
+// which takes a 32 or 64 byte key and starts at sector 0 (see EncryptAESXTS).
+// CBC and ECB use PKCS#7 padding.
+func EncryptAES(mode BlockMode, key, data []byte) ([]byte, error) {
+	return EncryptAESWithPadding(mode, PaddingPKCS7, key, data)
+}
+
+// DecryptAES reverses EncryptAES.
+func DecryptAES(mode BlockMode, key, data []byte) ([]byte, error) {
+	return DecryptAESWithPadding(mode, PaddingPKCS7, key, data)
+}
+
+// EncryptAESWithPadding is EncryptAES with the given padding for CBC and ECB.
+// The other modes ignore it.
+func EncryptAESWithPadding(mode BlockMode, padding Padding, key, data []byte) ([]byte, error) {
+	if mode == BlockModeXTS {
+		return EncryptAESXTS(key, XTSParams{}, data)
+	}
+	if mode == BlockModeGCMSIV {
+		return encryptGCMSIV(key, data)
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == BlockModeGCM {
+		return encryptGCM(c, data)
+	}
+	return EncryptBlockMode(c, mode, padding, data)
+}
+
+// DecryptAESWithPadding reverses EncryptAESWithPadding.
+func DecryptAESWithPadding(mode BlockMode, padding Padding, key, data []byte) ([]byte, error) {
+	if mode == BlockModeXTS {
+		return DecryptAESXTS(key, XTSParams{}, data)
+	}
+	if mode == BlockModeGCMSIV {
+		return decryptGCMSIV(key, data)
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == BlockModeGCM {
+		return decryptGCM(c, data)
+	}
+	return DecryptBlockMode(c, mode, padding, data)
+}
+
+func AESOperation() *Operation {
+	return &Operation{
+		Name:     "aes",
+		Category: "crypto",
+		Params: append([]Param{
+			{Name: "mode", Kind: KindString, Choices: AESModes(), Default: "CBC", Usage: "block cipher mode"},
+			{Name: "key", Kind: KindBytes, Usage: "16, 24 or 32 byte key"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decrypt instead of encrypt"},
+			{Name: "aad", Kind: KindBytes, Usage: "GCM associated data"},
+			{Name: "nonce", Kind: KindBytes, Usage: "GCM nonce sent separately, random and prepended if empty"},
+			{Name: "nonce-size", Kind: KindInt, Default: 12, Usage: "GCM nonce size in bytes"},
+			{Name: "tag-size", Kind: KindInt, Default: 16, Usage: "GCM tag size in bytes (12 to 16)"},
+			{Name: "sector", Kind: KindInt, Default: 0, Usage: "XTS number of the first sector"},
+			{Name: "sector-size", Kind: KindInt, Default: 512, Usage: "XTS sector size in bytes"},
+			{Name: "padding", Kind: KindString, Choices: Paddings(), Default: "PKCS7", Usage: "CBC and ECB padding"},
+		}, passphraseParams()...),
+		run: func(input []byte, opts Options) ([]byte, error) {
+			mode, err := ParseAESMode(opts.String("mode"))
+			if err != nil {
+				return nil, err
+			}
+			padding, err := ParsePadding(opts.String("padding"))
+			if err != nil {
+				return nil, err
+			}
+			gcm := GCMParams{
+				AAD:       opts.Bytes("aad"),
+				Nonce:     opts.Bytes("nonce"),
+				NonceSize: opts.Int("nonce-size"),
+				TagSize:   opts.Int("tag-size"),
+			}
+			sector := opts.Int("sector")
+			if mode == BlockModeXTS && sector < 0 {
+				return nil, fmt.Errorf("invalid XTS sector %d, must not be negative", sector)
+			}
+			xts := XTSParams{
+				Sector:     uint64(sector),
+				SectorSize: opts.Int("sector-size"),
+			}
+
+			encrypt := func(key []byte) ([]byte, error) {
+				switch mode {
+				case BlockModeGCM:
+					return EncryptAESGCM(key, gcm, input)
+				case BlockModeXTS:
+					return EncryptAESXTS(key, xts, input)
+				}
+				return EncryptAESWithPadding(mode, padding, key, input)
+			}
+			decrypt := func(key, data []byte) ([]byte, error) {
+				switch mode {
+				case BlockModeGCM:
+					return DecryptAESGCM(key, gcm, data)
+				case BlockModeXTS:
+					return DecryptAESXTS(key, xts, data)
+				}
+				return DecryptAESWithPadding(mode, padding, key, data)
+			}
+
+			if passphrase := opts.Bytes("passphrase"); len(passphrase) > 0 {
+				if opts.Bool(Reverse) {
+					return OpenWithPassphrase(passphrase, input, decrypt)
+				}
+				params, err := passphraseKDF(opts)
+				if err != nil {
+					return nil, err
+				}
+				return SealWithPassphrase(passphrase, params, encrypt)
+			}
+			if opts.Bool(Reverse) {
+				return decrypt(opts.Bytes("key"), input)
+			}
+			return encrypt(opts.Bytes("key"))
+		},
+	}
+}
+
+func encryptGCM(c cipher.Block, data []byte) ([]byte, error) {
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	result := gcm.Seal(nonce, nonce, data, nil)
+	return result, nil
+}
+
+func decryptGCM(c cipher.Block, data []byte) ([]byte, error) {
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// NIST SP 800-38A appendix F, AES-128
+var (
+	sp80038aKey       = fromHex("2b7e151628aed2a6abf7158809cf4f3c")
+	sp80038aPlaintext = fromHex(`6bc1bee22e409f96e93d7e117393172a ae2d8a571e03ac9c9eb76fac45af8e51
+		30c81c46a35ce411e5fbc1191a0a52ef f69f2445df4f9b17ad2b417be66c3710`)
+)
+
+func TestAESKnownAnswer(t *testing.T) {
+	iv := fromHex("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		mode       BlockMode
+		iv         []byte
+		ciphertext string
+	}{
+		{BlockModeECB, nil, `3ad77bb40d7a3660a89ecaf32466ef97 f5d3d58503b9699de785895a96fdbaaf
+			43b1cd7f598ece23881b00e3ed030688 7b0c785e27e8ad3f8223207104725dd4`},
+		{BlockModeCBC, iv, `7649abac8119b246cee98e9b12e9197d 5086cb9b507219ee95db113a917678b2
+			73bed6b8e3c1743b7116e69e22229516 3ff1caa1681fac09120eca307586e1a7`},
+		{BlockModeCFB, iv, `3b3fd92eb72dad20333449f8e83cfb4a c8a64537a0b3a93fcde3cdad9f1ce58b
+			26751f67a3cbb140b1808cf187a4f4df c04b05357c5d1c0eeac4c66f9ff7f2e6`},
+		{BlockModeOFB, iv, `3b3fd92eb72dad20333449f8e83cfb4a 7789508d16918f03f53c52dac54ed825
+			9740051e9c5fecf64344f7a82260edcc 304c6528f659c77866a510d9c1d6ae5e`},
+		{BlockModeCTR, fromHex("f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff"), `874d6191b620e3261bef6864990db6ce 9806f66b7970fdff8617187bb9fffdff
+			5ae4df3edbd5d35e5b4f09020db03eab 1e031dda2fbe03d1792170a0f3009cee`},
+	}
+
+	for _, tt := range tests {
+		data := append(append([]byte(nil), tt.iv...), fromHex(tt.ciphertext)...)
+		got, err := DecryptAESWithPadding(tt.mode, PaddingNone, sp80038aKey, data)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.mode, err)
+		}
+		if !bytes.Equal(got, sp80038aPlaintext) {
+			t.Errorf("%v: decrypted %x, want %x", tt.mode, got, sp80038aPlaintext)
+		}
+	}
+
+	// ECB has no IV, so encryption is deterministic too
+	got, err := EncryptAESWithPadding(BlockModeECB, PaddingNone, sp80038aKey, sp80038aPlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fromHex(tests[0].ciphertext); !bytes.Equal(got, want) {
+		t.Errorf("ECB encrypted %x, want %x", got, want)
+	}
+}
+
+func TestAESGCMKnownAnswer(t *testing.T) {
+	// test case 4 of the GCM specification (McGrew and Viega)
+	key := fromHex("feffe9928665731c6d6a8f9467308308")
+	p := GCMParams{
+		AAD:   fromHex("feedfacedeadbeeffeedfacedeadbeefabaddad2"),
+		Nonce: fromHex("cafebabefacedbaddecaf888"),
+	}
+	plaintext := fromHex(`d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a72
+		1c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39`)
+	ciphertext := fromHex(`42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e
+		21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091`)
+	tag := fromHex("5bc94fbc3221a5db94fae95ae7121a47")
+
+	_, gotCiphertext, gotTag, err := SealAESGCM(key, p, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotCiphertext, ciphertext) || !bytes.Equal(gotTag, tag) {
+		t.Fatalf("SealAESGCM = %x %x, want %x %x", gotCiphertext, gotTag, ciphertext, tag)
+	}
+
+	// a truncated tag is the prefix of the full one
+	for _, tagSize := range []int{12, 16} {
+		p.TagSize = tagSize
+		got, err := OpenAESGCM(key, p, ciphertext, tag[:tagSize])
+		if err != nil {
+			t.Fatalf("tag size %d: %v", tagSize, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("tag size %d: decrypted %x", tagSize, got)
+		}
+
+		changed := append([]byte(nil), tag[:tagSize]...)
+		changed[0] ^= 1
+		if _, err := OpenAESGCM(key, p, ciphertext, changed); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("tag size %d: changed tag error = %v, want ErrAuthentication", tagSize, err)
+		}
+	}
+}
+
+func TestAESRoundTrip(t *testing.T) {
+	data := []byte("The quick brown fox jumps over the lazy dog, twice over.")
+
+	for _, name := range AESModes() {
+		mode, err := ParseAESMode(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, keySizes := data, []int{16, 24, 32}
+		switch mode {
+		case BlockModeXTS:
+			// XTS here works on whole blocks
+			data, keySizes = data[:48], []int{32, 64}
+		case BlockModeGCMSIV:
+			keySizes = []int{16, 32}
+		}
+
+		for _, size := range keySizes {
+			key := bytes.Repeat([]byte{byte(size)}, size)
+			encrypted, err := EncryptAES(mode, key, data)
+			if err != nil {
+				t.Fatalf("%v/%d: %v", mode, size, err)
+			}
+			decrypted, err := DecryptAES(mode, key, encrypted)
+			if err != nil {
+				t.Fatalf("%v/%d decrypt: %v", mode, size, err)
+			}
+			if !bytes.Equal(decrypted, data) {
+				t.Errorf("%v/%d: round trip gave %q", mode, size, decrypted)
+			}
+		}
+	}
+}
+
+func TestAESOperation(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	data := []byte("attack at dawn")
+	op := AESOperation()
+
+	for _, mode := range []string{"CBC", "GCM", "CTR", "GCM-SIV"} {
+		encrypted, err := op.Run(data, Options{"mode": mode, "key": key})
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		decrypted, err := op.Run(encrypted, Options{"mode": mode, "key": key, Reverse: true})
+		if err != nil {
+			t.Fatalf("%s decrypt: %v", mode, err)
+		}
+		if !bytes.Equal(decrypted, data) {
+			t.Errorf("%s: round trip gave %q", mode, decrypted)
+		}
+	}
+
+	if _, err := op.Run(data, Options{"mode": "CCM", "key": key}); err == nil {
+		t.Error("unknown mode was accepted")
+	}
+	if _, err := op.Run(data, Options{"key": key[:15]}); err == nil {
+		t.Error("15 byte key was accepted")
+	}
+}
+
+func TestAESWrongKey(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	other := bytes.Repeat([]byte{2}, 16)
+
+	for _, mode := range []BlockMode{BlockModeGCM, BlockModeGCMSIV} {
+		encrypted, err := EncryptAES(mode, key, []byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecryptAES(mode, other, encrypted); err == nil {
+			t.Errorf("%v: decrypted with the wrong key", mode)
+		}
+	}
+}
@@ -0,0 +1,635 @@
+package core
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The age v1 format (https://age-encryption.org/v1): a text header with one
+// stanza per recipient wrapping a random file key, a header MAC, then the
+// payload in 64 KiB ChaCha20-Poly1305 chunks.
+
+const (
+	ageIntro        = "age-encryption.org/v1\n"
+	ageArmorHeader  = "-----BEGIN AGE ENCRYPTED FILE-----"
+	ageArmorFooter  = "-----END AGE ENCRYPTED FILE-----"
+	ageRecipientHRP = "age"
+	ageIdentityHRP  = "AGE-SECRET-KEY-"
+	ageX25519Label  = "age-encryption.org/v1/X25519"
+	ageScryptLabel  = "age-encryption.org/v1/scrypt"
+	ageFileKeySize  = 16
+	ageChunkSize    = 64 * 1024
+	ageColumns      = 64
+
+	// AgeWorkFactor is the default log2 of the scrypt cost of passphrase files.
+	AgeWorkFactor = 18
+	// ageMaxWorkFactor bounds the scrypt cost a file may ask for when decrypting
+	ageMaxWorkFactor = 22
+)
+
+var ageBase64 = base64.RawStdEncoding.Strict()
+
+var (
+	ErrAgeHeader     = errors.New("invalid age header")
+	ErrAgeHeaderMAC  = fmt.Errorf("%w: bad header MAC", ErrAgeHeader)
+	ErrAgeNoMatch    = errors.New("no identity matched any of the file's recipients")
+	ErrAgePassphrase = errors.New("wrong age passphrase")
+	ErrAgePayload    = errors.New("invalid age payload")
+	errAgeNotMine    = errors.New("stanza is not for this identity")
+	ErrAgeTooCostly  = fmt.Errorf("age scrypt work factor above %d", ageMaxWorkFactor)
+)
+
+// AgeParams say who can decrypt a new file: the owners of X25519 recipients
+// (age1...), or whoever knows Passphrase. age allows no other recipient
+// next to a passphrase.
+type AgeParams struct {
+	Recipients []string
+	Passphrase []byte
+	WorkFactor int // log2 of the scrypt cost, AgeWorkFactor if 0
+	Armor      bool
+}
+
+type ageStanza struct {
+	typ  string
+	args []string
+	body []byte
+}
+
+// GenerateAgeIdentity returns a new X25519 identity (AGE-SECRET-KEY-1...)
+// and its recipient (age1...).
+func GenerateAgeIdentity() (identity, recipient string, err error) {
+	private, public, err := GenerateX25519Key()
+	if err != nil {
+		return "", "", err
+	}
+	identity, err = bech32Encode(strings.ToLower(ageIdentityHRP), private)
+	if err != nil {
+		return "", "", err
+	}
+	recipient, err = bech32Encode(ageRecipientHRP, public)
+	return strings.ToUpper(identity), recipient, err
+}
+
+// AgeRecipient returns the recipient of an identity.
+func AgeRecipient(identity string) (string, error) {
+	private, err := parseAgeIdentity(identity)
+	if err != nil {
+		return "", err
+	}
+	public, err := X25519PublicKey(private)
+	if err != nil {
+		return "", err
+	}
+	return bech32Encode(ageRecipientHRP, public)
+}
+
+func parseAgeRecipient(s string) ([]byte, error) {
+	hrp, key, err := bech32Decode(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("age recipient %q: %w", s, err)
+	}
+	if hrp != ageRecipientHRP || len(key) != curve25519.PointSize {
+		return nil, fmt.Errorf("%q is not an age X25519 recipient", s)
+	}
+	return key, nil
+}
+
+func parseAgeIdentity(s string) ([]byte, error) {
+	hrp, key, err := bech32Decode(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("age identity: %w", err)
+	}
+	if hrp != strings.ToLower(ageIdentityHRP) || len(key) != curve25519.ScalarSize {
+		return nil, errors.New("not an age X25519 identity")
+	}
+	return key, nil
+}
+
+// ParseAgeKeys reads recipients or identities one per line, skipping
+// blank lines and # comments, as in age key files.
+func ParseAgeKeys(text string) []string {
+	var keys []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			keys = append(keys, line)
+		}
+	}
+	return keys
+}
+
+func ageWrap(key, fileKey []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil), nil
+}
+
+func ageUnwrap(key, body []byte) ([]byte, error) {
+	if len(body) != ageFileKeySize+chacha20poly1305.Overhead {
+		return nil, fmt.Errorf("%w: wrapped file key of %d bytes", ErrAgeHeader, len(body))
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), body, nil)
+	if err != nil {
+		return nil, errAgeNotMine
+	}
+	return fileKey, nil
+}
+
+func ageX25519Stanza(recipient, fileKey []byte) (*ageStanza, error) {
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeral); err != nil {
+		return nil, err
+	}
+	share, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(ephemeral, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapKey, err := hkdf.Key(sha256.New, shared, append(share[:len(share):len(share)], recipient...), ageX25519Label, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ageWrap(wrapKey, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ageStanza{typ: "X25519", args: []string{ageBase64.EncodeToString(share)}, body: body}, nil
+}
+
+func ageX25519Unwrap(identity []byte, s *ageStanza) ([]byte, error) {
+	if len(s.args) != 1 {
+		return nil, fmt.Errorf("%w: X25519 stanza with %d arguments", ErrAgeHeader, len(s.args))
+	}
+	share, err := ageBase64.DecodeString(s.args[0])
+	if err != nil || len(share) != curve25519.PointSize {
+		return nil, fmt.Errorf("%w: X25519 share", ErrAgeHeader)
+	}
+
+	// X25519 fails on low order shares, which would give an all zero secret
+	shared, err := curve25519.X25519(identity, share)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAgeHeader, err)
+	}
+	public, err := X25519PublicKey(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapKey, err := hkdf.Key(sha256.New, shared, append(share, public...), ageX25519Label, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return ageUnwrap(wrapKey, s.body)
+}
+
+func ageScryptKey(passphrase, salt []byte, workFactor int) ([]byte, error) {
+	return scrypt.Key(passphrase, append([]byte(ageScryptLabel), salt...), 1<<workFactor, 8, 1, chacha20poly1305.KeySize)
+}
+
+func ageScryptStanza(passphrase []byte, workFactor int, fileKey []byte) (*ageStanza, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	wrapKey, err := ageScryptKey(passphrase, salt, workFactor)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ageWrap(wrapKey, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ageStanza{typ: "scrypt", args: []string{ageBase64.EncodeToString(salt), strconv.Itoa(workFactor)}, body: body}, nil
+}
+
+func ageScryptUnwrap(passphrase []byte, s *ageStanza) ([]byte, error) {
+	if len(s.args) != 2 {
+		return nil, fmt.Errorf("%w: scrypt stanza with %d arguments", ErrAgeHeader, len(s.args))
+	}
+	salt, err := ageBase64.DecodeString(s.args[0])
+	if err != nil || len(salt) != 16 {
+		return nil, fmt.Errorf("%w: scrypt salt", ErrAgeHeader)
+	}
+	// decimal without leading zeros
+	workFactor, err := strconv.Atoi(s.args[1])
+	if err != nil || workFactor <= 0 || strconv.Itoa(workFactor) != s.args[1] {
+		return nil, fmt.Errorf("%w: scrypt work factor %q", ErrAgeHeader, s.args[1])
+	}
+	if workFactor > ageMaxWorkFactor {
+		return nil, ErrAgeTooCostly
+	}
+
+	wrapKey, err := ageScryptKey(passphrase, salt, workFactor)
+	if err != nil {
+		return nil, err
+	}
+	return ageUnwrap(wrapKey, s.body)
+}
+
+func (s *ageStanza) marshal(b *bytes.Buffer) {
+	b.WriteString("-> " + s.typ)
+	for _, arg := range s.args {
+		b.WriteString(" " + arg)
+	}
+	b.WriteByte('\n')
+
+	// the last line is always shorter than a full one, even if empty
+	body := ageBase64.EncodeToString(s.body)
+	for len(body) >= ageColumns {
+		b.WriteString(body[:ageColumns] + "\n")
+		body = body[ageColumns:]
+	}
+	b.WriteString(body + "\n")
+}
+
+func ageHeaderMAC(fileKey, header []byte) ([]byte, error) {
+	key, err := hkdf.Key(sha256.New, fileKey, nil, "header", 32)
+	if err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(header)
+	return h.Sum(nil), nil
+}
+
+// EncryptAge writes an age file for p.Recipients or p.Passphrase.
+func EncryptAge(p AgeParams, data []byte) ([]byte, error) {
+	if len(p.Passphrase) > 0 && len(p.Recipients) > 0 {
+		return nil, errors.New("an age passphrase cannot be combined with recipients")
+	}
+	if len(p.Passphrase) == 0 && len(p.Recipients) == 0 {
+		return nil, errors.New("age needs at least one recipient or a passphrase")
+	}
+	if p.WorkFactor == 0 {
+		p.WorkFactor = AgeWorkFactor
+	}
+	if p.WorkFactor < 1 || p.WorkFactor > ageMaxWorkFactor {
+		return nil, fmt.Errorf("age scrypt work factor must be 1 to %d", ageMaxWorkFactor)
+	}
+
+	fileKey := make([]byte, ageFileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	var stanzas []*ageStanza
+	if len(p.Passphrase) > 0 {
+		s, err := ageScryptStanza(p.Passphrase, p.WorkFactor, fileKey)
+		if err != nil {
+			return nil, err
+		}
+		stanzas = append(stanzas, s)
+	}
+	for _, r := range p.Recipients {
+		public, err := parseAgeRecipient(r)
+		if err != nil {
+			return nil, err
+		}
+		s, err := ageX25519Stanza(public, fileKey)
+		if err != nil {
+			return nil, err
+		}
+		stanzas = append(stanzas, s)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(ageIntro)
+	for _, s := range stanzas {
+		s.marshal(&b)
+	}
+	b.WriteString("---")
+	mac, err := ageHeaderMAC(fileKey, b.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	b.WriteString(" " + ageBase64.EncodeToString(mac) + "\n")
+
+	payload, err := ageSealPayload(fileKey, data)
+	if err != nil {
+		return nil, err
+	}
+	b.Write(payload)
+
+	if p.Armor {
+		return ageArmor(b.Bytes()), nil
+	}
+	return b.Bytes(), nil
+}
+
+// DecryptAge opens an age file, armored or not, with any of the identities
+// (AGE-SECRET-KEY-1...) or, for passphrase files, with passphrase.
+func DecryptAge(identities []string, passphrase, data []byte) ([]byte, error) {
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte(ageArmorHeader)) {
+		var err error
+		if data, err = ageDearmor(trimmed); err != nil {
+			return nil, err
+		}
+	}
+
+	stanzas, header, mac, payload, err := parseAgeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys [][]byte
+	for _, identity := range identities {
+		key, err := parseAgeIdentity(identity)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	var fileKey []byte
+	for _, s := range stanzas {
+		switch {
+		case s.typ == "scrypt":
+			if len(stanzas) != 1 {
+				return nil, fmt.Errorf("%w: scrypt stanza next to other recipients", ErrAgeHeader)
+			}
+			if len(passphrase) == 0 {
+				return nil, errors.New("this age file is encrypted with a passphrase")
+			}
+			fileKey, err = ageScryptUnwrap(passphrase, s)
+		case s.typ == "X25519":
+			for _, key := range keys {
+				if fileKey, err = ageX25519Unwrap(key, s); !errors.Is(err, errAgeNotMine) {
+					break
+				}
+			}
+		default:
+			// other recipient types, and random grease stanzas
+			continue
+		}
+		if errors.Is(err, errAgeNotMine) {
+			err, fileKey = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if fileKey != nil {
+			break
+		}
+	}
+	if fileKey == nil {
+		if len(stanzas) == 1 && stanzas[0].typ == "scrypt" {
+			return nil, ErrAgePassphrase
+		}
+		return nil, ErrAgeNoMatch
+	}
+
+	expected, err := ageHeaderMAC(fileKey, header)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, expected) {
+		return nil, ErrAgeHeaderMAC
+	}
+
+	return ageOpenPayload(fileKey, payload)
+}
+
+// parseAgeHeader splits an age file into its stanzas, the header bytes
+// covered by the MAC, the MAC and the payload.
+func parseAgeHeader(data []byte) (stanzas []*ageStanza, header, mac, payload []byte, err error) {
+	if !bytes.HasPrefix(data, []byte(ageIntro)) {
+		return nil, nil, nil, nil, fmt.Errorf("%w: not an age v1 file", ErrAgeHeader)
+	}
+	rest := data[len(ageIntro):]
+
+	nextLine := func() (string, bool) {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			return "", false
+		}
+		line := string(rest[:i])
+		rest = rest[i+1:]
+		return line, true
+	}
+
+	for {
+		line, ok := nextLine()
+		if !ok {
+			return nil, nil, nil, nil, fmt.Errorf("%w: truncated", ErrAgeHeader)
+		}
+
+		if macText, ok := strings.CutPrefix(line, "--- "); ok {
+			header = data[:len(data)-len(rest)-len(line)-1+len("---")]
+			mac, err = ageBase64.DecodeString(macText)
+			if err != nil || len(mac) != sha256.Size {
+				return nil, nil, nil, nil, fmt.Errorf("%w: header MAC", ErrAgeHeader)
+			}
+			if len(stanzas) == 0 {
+				return nil, nil, nil, nil, fmt.Errorf("%w: no recipients", ErrAgeHeader)
+			}
+			return stanzas, header, mac, rest, nil
+		}
+
+		fields, ok := strings.CutPrefix(line, "-> ")
+		if !ok {
+			return nil, nil, nil, nil, fmt.Errorf("%w: unexpected line %q", ErrAgeHeader, line)
+		}
+		args := strings.Split(fields, " ")
+		for _, arg := range args {
+			if !isAgeArg(arg) {
+				return nil, nil, nil, nil, fmt.Errorf("%w: stanza argument %q", ErrAgeHeader, arg)
+			}
+		}
+		s := &ageStanza{typ: args[0], args: args[1:]}
+
+		for {
+			line, ok := nextLine()
+			if !ok {
+				return nil, nil, nil, nil, fmt.Errorf("%w: truncated stanza", ErrAgeHeader)
+			}
+			chunk, err := ageBase64.DecodeString(line)
+			if err != nil || len(line) > ageColumns {
+				return nil, nil, nil, nil, fmt.Errorf("%w: stanza body", ErrAgeHeader)
+			}
+			s.body = append(s.body, chunk...)
+			if len(line) < ageColumns {
+				break
+			}
+		}
+		stanzas = append(stanzas, s)
+	}
+}
+
+// isAgeArg reports whether s is a non-empty string of visible ASCII.
+func isAgeArg(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := range len(s) {
+		if s[i] < 33 || s[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+func agePayloadAEAD(fileKey, nonce []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, fileKey, nonce, "payload", chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+// ageChunkNonce is an 11 byte big endian counter and a last chunk flag.
+func ageChunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	for i := 10; i >= 3; i-- {
+		nonce[i] = byte(counter)
+		counter >>= 8
+	}
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func ageSealPayload(fileKey, data []byte) ([]byte, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := agePayloadAEAD(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	out := nonce
+	for counter := uint64(0); ; counter++ {
+		chunk := data[:min(len(data), ageChunkSize)]
+		data = data[len(chunk):]
+		last := len(data) == 0
+		out = aead.Seal(out, ageChunkNonce(counter, last), chunk, nil)
+		if last {
+			return out, nil
+		}
+	}
+}
+
+func ageOpenPayload(fileKey, payload []byte) ([]byte, error) {
+	if len(payload) < 16 {
+		// the nonce belongs to the header in the age spec
+		return nil, fmt.Errorf("%w: missing payload nonce", ErrAgeHeader)
+	}
+	aead, err := agePayloadAEAD(fileKey, payload[:16])
+	if err != nil {
+		return nil, err
+	}
+	payload = payload[16:]
+
+	var out []byte
+	const sealedSize = ageChunkSize + chacha20poly1305.Overhead
+	for counter := uint64(0); ; counter++ {
+		chunk := payload[:min(len(payload), sealedSize)]
+		payload = payload[len(chunk):]
+		last := len(payload) == 0
+
+		if len(chunk) < chacha20poly1305.Overhead {
+			return nil, fmt.Errorf("%w: truncated chunk", ErrAgePayload)
+		}
+		// only an empty file may end in an empty chunk
+		if last && counter > 0 && len(chunk) == chacha20poly1305.Overhead {
+			return nil, fmt.Errorf("%w: empty last chunk", ErrAgePayload)
+		}
+
+		plaintext, err := aead.Open(out, ageChunkNonce(counter, last), chunk, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: chunk %d: %v", ErrAgePayload, counter, ErrAuthentication)
+		}
+		out = plaintext
+		if last {
+			return out, nil
+		}
+	}
+}
+
+// ageArmor wraps an age file in PEM style armor, base64 at 64 columns.
+func ageArmor(data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(ageArmorHeader + "\n")
+	text := base64.StdEncoding.EncodeToString(data)
+	for len(text) > ageColumns {
+		b.WriteString(text[:ageColumns] + "\n")
+		text = text[ageColumns:]
+	}
+	b.WriteString(text + "\n")
+	b.WriteString(ageArmorFooter + "\n")
+	return b.Bytes()
+}
+
+func ageDearmor(data []byte) ([]byte, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) < 3 || lines[0] != ageArmorHeader || lines[len(lines)-1] != ageArmorFooter {
+		return nil, fmt.Errorf("%w: bad armor", ErrAgeHeader)
+	}
+
+	lines = lines[1 : len(lines)-1]
+	for i, line := range lines {
+		// every line but the last is full, and the last one is not empty
+		if len(line) == 0 || len(line) > ageColumns || (i < len(lines)-1 && len(line) != ageColumns) {
+			return nil, fmt.Errorf("%w: bad armor line length", ErrAgeHeader)
+		}
+	}
+	decoded, err := base64.StdEncoding.Strict().DecodeString(strings.Join(lines, ""))
+	if err != nil {
+		return nil, fmt.Errorf("%w: armor: %v", ErrAgeHeader, err)
+	}
+	return decoded, nil
+}
+
+func AgeOperation() *Operation {
+	return &Operation{
+		Name:     "age",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "recipients", Kind: KindString, Default: "", Usage: "comma separated age1... recipients to encrypt to"},
+			{Name: "identities", Kind: KindString, Default: "", Usage: "comma separated AGE-SECRET-KEY-1... identities to decrypt with"},
+			{Name: "passphrase", Kind: KindBytes, Usage: "passphrase instead of recipients or identities"},
+			{Name: "work-factor", Kind: KindInt, Default: AgeWorkFactor, Usage: "log2 of the scrypt cost of a new passphrase file"},
+			{Name: "armor", Kind: KindBool, Default: false, Usage: "write ASCII armor"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decrypt instead of encrypt"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			split := func(s string) []string {
+				return ParseAgeKeys(strings.ReplaceAll(s, ",", "\n"))
+			}
+			if opts.Bool(Reverse) {
+				return DecryptAge(split(opts.String("identities")), opts.Bytes("passphrase"), input)
+			}
+			return EncryptAge(AgeParams{
+				Recipients: split(opts.String("recipients")),
+				Passphrase: opts.Bytes("passphrase"),
+				WorkFactor: opts.Int("work-factor"),
+				Armor:      opts.Bool("armor"),
+			}, input)
+		},
+	}
+}
@@ -0,0 +1,273 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/age holds the age testkit (c2sp.org/CCTV/age), without the hybrid
+// ML-KEM vectors chify does not implement. Every file is a text header, an
+// empty line and the age file.
+type ageVector struct {
+	expect     string
+	payload    string
+	identities []string
+	passphrase string
+	file       []byte
+}
+
+func readAgeVector(t *testing.T, path string) ageVector {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v ageVector
+	compressed := false
+	r := bufio.NewReader(bytes.NewReader(data))
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("vector header: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			break
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "expect":
+			v.expect = value
+		case "payload":
+			v.payload = value
+		case "identity":
+			v.identities = append(v.identities, value)
+		case "passphrase":
+			v.passphrase = value
+		case "compressed":
+			compressed = value == "zlib"
+		}
+	}
+
+	var body io.Reader = r
+	if compressed {
+		if body, err = zlib.NewReader(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v.file, err = io.ReadAll(body); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// ageExpectErrors maps the testkit outcomes to our errors. Armor is decoded
+// as part of the header, and a wrong passphrase is the scrypt "no match".
+var ageExpectErrors = map[string][]error{
+	"no match":        {ErrAgeNoMatch, ErrAgePassphrase},
+	"HMAC failure":    {ErrAgeHeaderMAC},
+	"header failure":  {ErrAgeHeader, ErrAgeTooCostly},
+	"armor failure":   {ErrAgeHeader},
+	"payload failure": {ErrAgePayload},
+}
+
+func TestAgeTestkit(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "age", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		if filepath.Base(path) == "LICENSE" {
+			continue
+		}
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			v := readAgeVector(t, path)
+			got, err := DecryptAge(v.identities, []byte(v.passphrase), v.file)
+
+			if v.expect == "success" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sum := sha256.Sum256(got); hex.EncodeToString(sum[:]) != v.payload {
+					t.Errorf("payload hash %x, want %s", sum, v.payload)
+				}
+				return
+			}
+
+			want, ok := ageExpectErrors[v.expect]
+			if !ok {
+				t.Fatalf("unknown expect %q", v.expect)
+			}
+			for _, target := range want {
+				if errors.Is(err, target) {
+					return
+				}
+			}
+			t.Errorf("error = %v, want %s (%v)", err, v.expect, want)
+		})
+	}
+}
+
+func TestAgeRoundTrip(t *testing.T) {
+	identity, recipient, err := GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{0, 1, ageChunkSize, ageChunkSize + 1} {
+		data := bytes.Repeat([]byte{'a'}, size)
+		for _, armor := range []bool{false, true} {
+			encrypted, err := EncryptAge(AgeParams{Recipients: []string{recipient}, Armor: armor}, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decrypted, err := DecryptAge([]string{other, identity}, nil, encrypted)
+			if err != nil {
+				t.Fatalf("%d bytes, armor %v: %v", size, armor, err)
+			}
+			if !bytes.Equal(decrypted, data) {
+				t.Errorf("%d bytes, armor %v: round trip gave %d bytes", size, armor, len(decrypted))
+			}
+		}
+	}
+
+	encrypted, err := EncryptAge(AgeParams{Passphrase: []byte("hunter2"), WorkFactor: 10}, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptAge(nil, []byte("hunter3"), encrypted); !errors.Is(err, ErrAgePassphrase) {
+		t.Errorf("wrong passphrase error = %v, want ErrAgePassphrase", err)
+	}
+	if got, err := DecryptAge(nil, []byte("hunter2"), encrypted); err != nil || string(got) != "secret" {
+		t.Errorf("passphrase decrypt = %q, %v", got, err)
+	}
+}
+
+func TestAgeTruncated(t *testing.T) {
+	identity, recipient, err := GenerateAgeIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("chify"), ageChunkSize/5+100)
+	encrypted, err := EncryptAge(AgeParams{Recipients: []string{recipient}}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, header, _, payload, err := parseAgeHeader(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// header and MAC line
+	payloadStart := len(encrypted) - len(payload)
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"inside the header", encrypted[:len(header)/2], ErrAgeHeader},
+		{"no payload", encrypted[:payloadStart], ErrAgeHeader},
+		{"inside the nonce", encrypted[:payloadStart+8], ErrAgeHeader},
+		{"nonce only", encrypted[:payloadStart+16], ErrAgePayload},
+		{"last chunk dropped", encrypted[:payloadStart+16+ageChunkSize+16], ErrAgePayload},
+		{"inside the last tag", encrypted[:len(encrypted)-1], ErrAgePayload},
+		{"byte appended", append(bytes.Clone(encrypted), 0), ErrAgePayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecryptAge([]string{identity}, nil, tt.data); !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	mac := bytes.Clone(encrypted)
+	mac[len(header)+4] ^= 1 // first character of the base64 MAC
+	if _, err := DecryptAge([]string{identity}, nil, mac); !errors.Is(err, ErrAgeHeaderMAC) {
+		t.Errorf("changed MAC error = %v, want ErrAgeHeaderMAC", err)
+	}
+}
+
+func TestBech32(t *testing.T) {
+	// BIP 173 valid checksums
+	for _, s := range []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j",
+		"?1ezyfcl",
+	} {
+		hrp, data, err := bech32Decode(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if got, err := bech32Encode(hrp, data); err != nil || got != strings.ToLower(s) {
+			t.Errorf("%s: encoded back to %s, %v", s, got, err)
+		}
+	}
+
+	tests := []struct {
+		name, s, reason string
+	}{
+		// BIP 173 invalid strings
+		{"no separator", "pzry9x0s0muk", ""},
+		{"empty hrp", "1pzry9x0s0muk", ""},
+		{"invalid data character", "x1b4n0q5v", ""},
+		{"short checksum", "li1dgmt3", ""},
+		{"checksum of the upper case hrp", "A1G7SGD8", "bad checksum"},
+		{"hrp character out of range", "\x201nwldj5", ""},
+		{"mixed case", "A12uEL5L", "mixed case"},
+		{"changed character", "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxx", "bad checksum"},
+		{"mixed case age key", "AGE-SECRET-KEY-1EGTZVFFV20835NWYV6270LXYVK2VKNX2MMDKWYKLMGR48UAWX40q2P2LM0", "mixed case"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := bech32Decode(tt.s)
+			if !errors.Is(err, errBech32) {
+				t.Fatalf("error = %v, want errBech32", err)
+			}
+			if !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("error = %v, want %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestAgeKeys(t *testing.T) {
+	// identity of the testkit's x25519 vector
+	const identity = "AGE-SECRET-KEY-1EGTZVFFV20835NWYV6270LXYVK2VKNX2MMDKWYKLMGR48UAWX40Q2P2LM0"
+	recipient, err := AgeRecipient(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(recipient, "age1") {
+		t.Errorf("recipient %s", recipient)
+	}
+	if _, err := parseAgeRecipient(recipient); err != nil {
+		t.Error(err)
+	}
+	if _, err := AgeRecipient(strings.ToLower(identity[:len(identity)-1]) + "q"); err == nil {
+		t.Error("identity with a bad checksum was accepted")
+	}
+	if _, err := parseAgeRecipient(identity); err == nil {
+		t.Error("identity accepted as a recipient")
+	}
+}
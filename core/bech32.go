@@ -0,0 +1,125 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Bech32 (BIP 173) without the 90 character limit, as age uses it for keys.
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var errBech32 = errors.New("invalid bech32 string")
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i, g := range generator {
+			if (top>>i)&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	var out []byte
+	for i := range len(hrp) {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := range len(hrp) {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// convertBits regroups data from groups of from bits to groups of to bits.
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	var acc, bits uint
+	var out []byte
+	maxValue := uint(1)<<to - 1
+	for _, b := range data {
+		if uint(b)>>from != 0 {
+			return nil, errBech32
+		}
+		acc = acc<<from | uint(b)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxValue))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxValue != 0 {
+		return nil, errBech32
+	}
+	return out, nil
+}
+
+// bech32Encode encodes data under a lower case hrp.
+func bech32Encode(hrp string, data []byte) (string, error) {
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	expanded := append(bech32HRPExpand(hrp), values...)
+	polymod := bech32Polymod(append(expanded, 0, 0, 0, 0, 0, 0)) ^ 1
+
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, v := range values {
+		b.WriteByte(bech32Charset[v])
+	}
+	for i := range 6 {
+		b.WriteByte(bech32Charset[polymod>>(5*(5-i))&31])
+	}
+	return b.String(), nil
+}
+
+// bech32Decode returns the lower case hrp and the data of s.
+func bech32Decode(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, fmt.Errorf("%w: mixed case", errBech32)
+	}
+	s = strings.ToLower(s)
+
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, errBech32
+	}
+	hrp := s[:pos]
+	for i := range len(hrp) {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, errBech32
+		}
+	}
+
+	var values []byte
+	for i := pos + 1; i < len(s); i++ {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v < 0 {
+			return "", nil, errBech32
+		}
+		values = append(values, byte(v))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != 1 {
+		return "", nil, fmt.Errorf("%w: bad checksum", errBech32)
+	}
+
+	data, err := convertBits(values[:len(values)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// BlockMode is a block cipher mode of operation. GCM, XTS and GCM-SIV are
+// for AES only, EncryptBlockMode takes the rest with any cipher.Block.
+type BlockMode int
+
+const (
+	BlockModeCBC BlockMode = iota
+	BlockModeGCM
+	BlockModeCTR
+	BlockModeCFB
+	BlockModeOFB
+	BlockModeECB
+	BlockModeXTS
+	BlockModeGCMSIV
+)
+
+var blockModeNames = []string{"CBC", "GCM", "CTR", "CFB", "OFB", "ECB", "XTS", "GCM-SIV"}
+
+func (m BlockMode) String() string {
+	return blockModeNames[m]
+}
+
+// The modes below work with any cipher.Block, whatever its block size. The
+// IV, when the mode has one, is random and prepended to the result.
+
+var blockModes = []BlockMode{BlockModeCBC, BlockModeCTR, BlockModeCFB, BlockModeOFB, BlockModeECB}
+
+// BlockModes lists the names of the modes EncryptBlockMode supports.
+func BlockModes() []string {
+	var names []string
+	for _, mode := range blockModes {
+		names = append(names, mode.String())
+	}
+	return names
+}
+
+// ParseBlockMode returns the mode named s if it is one of BlockModes.
+func ParseBlockMode(s string) (BlockMode, error) {
+	for _, mode := range blockModes {
+		if mode.String() == s {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: block mode %q", ErrUnknownAlgorithm, s)
+}
+
+// EncryptBlockMode encrypts data with c in mode. padding applies to CBC and ECB.
+func EncryptBlockMode(c cipher.Block, mode BlockMode, padding Padding, data []byte) ([]byte, error) {
+	switch mode {
+	case BlockModeCBC:
+		return encryptCBC(c, padding, data)
+	case BlockModeCTR:
+		return encryptCTR(c, data)
+	case BlockModeCFB:
+		return encryptStream(c, cipher.NewCFBEncrypter, data)
+	case BlockModeOFB:
+		return encryptStream(c, cipher.NewOFB, data)
+	case BlockModeECB:
+		return encryptECB(c, padding, data)
+	}
+
+	return nil, fmt.Errorf("%w: %v mode for a generic block cipher", ErrUnknownAlgorithm, mode)
+}
+
+// DecryptBlockMode reverses EncryptBlockMode.
+func DecryptBlockMode(c cipher.Block, mode BlockMode, padding Padding, data []byte) ([]byte, error) {
+	switch mode {
+	case BlockModeCBC:
+		return decryptCBC(c, padding, data)
+	case BlockModeCTR:
+		return decryptCTR(c, data)
+	case BlockModeCFB:
+		return decryptStream(c, cipher.NewCFBDecrypter, data)
+	case BlockModeOFB:
+		return decryptStream(c, cipher.NewOFB, data)
+	case BlockModeECB:
+		return decryptECB(c, padding, data)
+	}
+
+	return nil, fmt.Errorf("%w: %v mode for a generic block cipher", ErrUnknownAlgorithm, mode)
+}
+
+func encryptCBC(c cipher.Block, padding Padding, data []byte) ([]byte, error) {
+	data, err := padding.Pad(data, c.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, c.BlockSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	mode := cipher.NewCBCEncrypter(c, iv)
+	result := make([]byte, len(data))
+	mode.CryptBlocks(result, data)
+
+	result = append(iv, result...)
+
+	return result, nil
+}
+
+func decryptCBC(c cipher.Block, padding Padding, data []byte) ([]byte, error) {
+	blockSize := c.BlockSize()
+
+	if len(data) < blockSize {
+		return nil, ErrCiphertextTooShort
+	}
+
+	iv := data[:blockSize]
+	data = data[blockSize:]
+	if len(data)%blockSize != 0 {
+		return nil, ErrBlockLength
+	}
+
+	mode := cipher.NewCBCDecrypter(c, iv)
+	result := make([]byte, len(data))
+	mode.CryptBlocks(result, data)
+
+	return padding.Unpad(result, blockSize)
+}
+
+func encryptCTR(c cipher.Block, data []byte) ([]byte, error) {
+	iv := make([]byte, c.BlockSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	ctr := cipher.NewCTR(c, iv)
+
+	ciphertext := make([]byte, len(data))
+	ctr.XORKeyStream(ciphertext, data)
+
+	result := append(iv, ciphertext...)
+
+	return result, nil
+}
+
+func decryptCTR(c cipher.Block, data []byte) ([]byte, error) {
+	blockSize := c.BlockSize()
+
+	if len(data) < blockSize {
+		return nil, ErrCiphertextTooShort
+	}
+
+	iv := data[:blockSize]
+	ciphertext := data[blockSize:]
+
+	ctr := cipher.NewCTR(c, iv)
+
+	plaintext := make([]byte, len(ciphertext))
+	ctr.XORKeyStream(plaintext, ciphertext)
+
+	return plaintext, nil
+}
+
+// encryptStream covers the IV based stream modes, CFB and OFB.
+func encryptStream(c cipher.Block, newStream func(cipher.Block, []byte) cipher.Stream, data []byte) ([]byte, error) {
+	iv := make([]byte, c.BlockSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	ciphertext := make([]byte, len(data))
+	newStream(c, iv).XORKeyStream(ciphertext, data)
+
+	return append(iv, ciphertext...), nil
+}
+
+func decryptStream(c cipher.Block, newStream func(cipher.Block, []byte) cipher.Stream, data []byte) ([]byte, error) {
+	blockSize := c.BlockSize()
+
+	if len(data) < blockSize {
+		return nil, ErrCiphertextTooShort
+	}
+
+	plaintext := make([]byte, len(data)-blockSize)
+	newStream(c, data[:blockSize]).XORKeyStream(plaintext, data[blockSize:])
+
+	return plaintext, nil
+}
+
+// ECB encrypts every block on its own, so equal blocks stay equal. It is only
+// here to read legacy data.
+func encryptECB(c cipher.Block, padding Padding, data []byte) ([]byte, error) {
+	blockSize := c.BlockSize()
+	data, err := padding.Pad(data, blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, len(data))
+	for i := 0; i < len(data); i += blockSize {
+		c.Encrypt(result[i:i+blockSize], data[i:i+blockSize])
+	}
+
+	return result, nil
+}
+
+func decryptECB(c cipher.Block, padding Padding, data []byte) ([]byte, error) {
+	blockSize := c.BlockSize()
+
+	if len(data)%blockSize != 0 {
+		return nil, ErrBlockLength
+	}
+
+	result := make([]byte, len(data))
+	for i := 0; i < len(data); i += blockSize {
+		c.Decrypt(result[i:i+blockSize], data[i:i+blockSize])
+	}
+
+	return padding.Unpad(result, blockSize)
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestParseBlockMode(t *testing.T) {
+	for _, name := range BlockModes() {
+		mode, err := ParseBlockMode(name)
+		if err != nil || mode.String() != name {
+			t.Errorf("%s parsed to %v, %v", name, mode, err)
+		}
+		if aesMode, err := ParseAESMode(name); err != nil || aesMode != mode {
+			t.Errorf("%s: AES mode %v, %v", name, aesMode, err)
+		}
+	}
+
+	for _, name := range []string{"GCM", "XTS", "GCM-SIV", "cbc", ""} {
+		if _, err := ParseBlockMode(name); !errors.Is(err, ErrUnknownAlgorithm) {
+			t.Errorf("%q error = %v, want ErrUnknownAlgorithm", name, err)
+		}
+	}
+	for _, name := range []string{"GCM", "XTS", "GCM-SIV"} {
+		if _, err := ParseAESMode(name); err != nil {
+			t.Errorf("AES mode %s: %v", name, err)
+		}
+	}
+}
+
+func TestBlockCipherOperation(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 24)
+	for _, mode := range BlockModes() {
+		opts := Options{"cipher": "3DES", "mode": mode, "key": key}
+		ciphertext, err := BlockCipherOperation().Run(sunscreen, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		opts[Reverse] = true
+		if got, err := BlockCipherOperation().Run(ciphertext, opts); err != nil || !bytes.Equal(got, sunscreen) {
+			t.Errorf("%s: round trip gave %q, %v", mode, got, err)
+		}
+	}
+
+	if _, err := BlockCipherOperation().Run(sunscreen, Options{"cipher": "3DES", "mode": "GCM", "key": key}); err == nil {
+		t.Error("3DES in GCM mode was accepted")
+	}
+}
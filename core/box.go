@@ -0,0 +1,169 @@
+package core
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// NaCl box encrypts to an X25519 public key, compatible with libsodium's
+// crypto_box_easy (with the nonce prepended) and crypto_box_seal.
+
+type BoxMode int
+
+const (
+	BoxModeBox    BoxMode = iota // authenticated sender, both sides have a key pair
+	BoxModeSealed                // anonymous sender, crypto_box_seal
+)
+
+var boxModes = []string{"box", "sealed"}
+
+func (m BoxMode) String() string {
+	return boxModes[m]
+}
+
+func BoxModes() []string {
+	return append([]string(nil), boxModes...)
+}
+
+func ParseBoxMode(s string) (BoxMode, error) {
+	for i, name := range boxModes {
+		if name == s {
+			return BoxMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: box mode %q", ErrUnknownAlgorithm, s)
+}
+
+// GenerateX25519Key returns a new 32 byte private and public key.
+func GenerateX25519Key() (privateKey, publicKey []byte, err error) {
+	public, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return private[:], public[:], nil
+}
+
+// X25519PublicKey computes the public key of a 32 byte private key.
+func X25519PublicKey(privateKey []byte) ([]byte, error) {
+	if len(privateKey) != curve25519.ScalarSize {
+		return nil, fmt.Errorf("invalid X25519 private key size %d, must be 32", len(privateKey))
+	}
+	return curve25519.X25519(privateKey, curve25519.Basepoint)
+}
+
+func boxKey(name string, key []byte) (*[32]byte, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid X25519 %s size %d, must be 32", name, len(key))
+	}
+	return (*[32]byte)(key), nil
+}
+
+// SealBox encrypts data from the sender to the recipient. The result is a
+// random 24 byte nonce followed by the output of crypto_box_easy, the MAC
+// and the ciphertext.
+func SealBox(recipientPublic, senderPrivate, data []byte) ([]byte, error) {
+	public, err := boxKey("public key", recipientPublic)
+	if err != nil {
+		return nil, err
+	}
+	private, err := boxKey("private key", senderPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	return box.Seal(nonce[:], data, &nonce, public, private), nil
+}
+
+// OpenBox reverses SealBox. It returns ErrAuthentication unless data was
+// sealed by the owner of senderPublic for the owner of recipientPrivate.
+func OpenBox(senderPublic, recipientPrivate, data []byte) ([]byte, error) {
+	public, err := boxKey("public key", senderPublic)
+	if err != nil {
+		return nil, err
+	}
+	private, err := boxKey("private key", recipientPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 24+box.Overhead {
+		return nil, ErrCiphertextTooShort
+	}
+
+	plaintext, ok := box.Open(nil, data[24:], (*[24]byte)(data[:24]), public, private)
+	if !ok {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
+
+// SealAnonymous encrypts data to the recipient with a throwaway sender key,
+// like crypto_box_seal: ephemeral public key | MAC | ciphertext.
+func SealAnonymous(recipientPublic, data []byte) ([]byte, error) {
+	public, err := boxKey("public key", recipientPublic)
+	if err != nil {
+		return nil, err
+	}
+	return box.SealAnonymous(nil, data, public, rand.Reader)
+}
+
+// OpenAnonymous reverses SealAnonymous with the recipient's private key.
+func OpenAnonymous(recipientPrivate, data []byte) ([]byte, error) {
+	private, err := boxKey("private key", recipientPrivate)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := X25519PublicKey(recipientPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < box.AnonymousOverhead {
+		return nil, ErrCiphertextTooShort
+	}
+
+	plaintext, ok := box.OpenAnonymous(nil, data, (*[32]byte)(publicKey), private)
+	if !ok {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
+
+func BoxOperation() *Operation {
+	return &Operation{
+		Name:     "box",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "mode", Kind: KindString, Choices: BoxModes(), Default: "sealed", Usage: "box with a sender key, or anonymous sealed box"},
+			{Name: "private-key", Kind: KindBytes, Usage: "own X25519 private key (sender for box, recipient when decrypting)"},
+			{Name: "peer-key", Kind: KindBytes, Usage: "X25519 public key of the other side"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decrypt instead of encrypt"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			mode, err := ParseBoxMode(opts.String("mode"))
+			if err != nil {
+				return nil, err
+			}
+
+			private, peer := opts.Bytes("private-key"), opts.Bytes("peer-key")
+			switch {
+			case mode == BoxModeSealed && opts.Bool(Reverse):
+				return OpenAnonymous(private, input)
+			case mode == BoxModeSealed:
+				return SealAnonymous(peer, input)
+			case opts.Bool(Reverse):
+				return OpenBox(peer, private, input)
+			default:
+				return SealBox(peer, private, input)
+			}
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// the crypto_box vector of NaCl and libsodium (test/default/box.c): Alice
+// sends to Bob with the secretbox vector's nonce and message, the shared key
+// is the secretbox key, so the output is secretboxCiphertext
+var (
+	aliceBoxPrivate = fromHex("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	aliceBoxPublic  = fromHex("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")
+	bobBoxPrivate   = fromHex("5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+	bobBoxPublic    = fromHex("de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f")
+)
+
+func TestBox(t *testing.T) {
+	for _, pair := range [][2][]byte{{aliceBoxPrivate, aliceBoxPublic}, {bobBoxPrivate, bobBoxPublic}} {
+		if public, err := X25519PublicKey(pair[0]); err != nil || !bytes.Equal(public, pair[1]) {
+			t.Errorf("public key %x, %v, want %x", public, err, pair[1])
+		}
+	}
+
+	// crypto_box_easy output with the nonce in front
+	sealed := append(bytes.Clone(secretboxNonce), secretboxCiphertext...)
+	opened, err := OpenBox(aliceBoxPublic, bobBoxPrivate, sealed)
+	if err != nil || !bytes.Equal(opened, secretboxPlaintext) {
+		t.Fatalf("opened %x, %v", opened, err)
+	}
+
+	sealed, err = SealBox(bobBoxPublic, aliceBoxPrivate, sunscreen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 24 + box.Overhead + len(sunscreen); len(sealed) != want {
+		t.Errorf("sealed %d bytes, want %d", len(sealed), want)
+	}
+	if opened, err := OpenBox(aliceBoxPublic, bobBoxPrivate, sealed); err != nil || !bytes.Equal(opened, sunscreen) {
+		t.Errorf("round trip gave %q, %v", opened, err)
+	}
+	// the shared key is symmetric, Alice can open what she sent to Bob
+	if opened, err := OpenBox(bobBoxPublic, aliceBoxPrivate, sealed); err != nil || !bytes.Equal(opened, sunscreen) {
+		t.Errorf("sender opened %q, %v", opened, err)
+	}
+
+	eve, evePublic, err := GenerateX25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name            string
+		sender, private []byte
+		data            []byte
+	}{
+		{"wrong recipient key", aliceBoxPublic, eve, sealed},
+		{"wrong sender key", evePublic, bobBoxPrivate, sealed},
+		{"nonce changed", aliceBoxPublic, bobBoxPrivate, flipByte(sealed, 3)},
+		{"MAC changed", aliceBoxPublic, bobBoxPrivate, flipByte(sealed, 24+5)},
+		{"ciphertext changed", aliceBoxPublic, bobBoxPrivate, flipByte(sealed, len(sealed)-1)},
+	} {
+		if _, err := OpenBox(tt.sender, tt.private, tt.data); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: error = %v, want ErrAuthentication", tt.name, err)
+		}
+	}
+	if _, err := OpenBox(aliceBoxPublic, bobBoxPrivate, sealed[:24+box.Overhead-1]); !errors.Is(err, ErrCiphertextTooShort) {
+		t.Errorf("short box error = %v, want ErrCiphertextTooShort", err)
+	}
+}
+
+func TestSealedBox(t *testing.T) {
+	// crypto_box_seal: ephemeral public key | crypto_box_easy with the nonce
+	// BLAKE2b-192(ephemeral public key | recipient public key)
+	ephemeral := sequence(32)
+	ephemeralPublic, err := X25519PublicKey(ephemeral)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ := blake2b.New(24, nil)
+	h.Write(ephemeralPublic)
+	h.Write(bobBoxPublic)
+	nonce := (*[24]byte)(h.Sum(nil))
+	libsodium := box.Seal(bytes.Clone(ephemeralPublic), sunscreen, nonce, (*[32]byte)(bobBoxPublic), (*[32]byte)(ephemeral))
+
+	opened, err := OpenAnonymous(bobBoxPrivate, libsodium)
+	if err != nil || !bytes.Equal(opened, sunscreen) {
+		t.Fatalf("opened %q, %v", opened, err)
+	}
+
+	sealed, err := SealAnonymous(bobBoxPublic, sunscreen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sealed) != box.AnonymousOverhead+len(sunscreen) {
+		t.Errorf("sealed %d bytes", len(sealed))
+	}
+	if opened, err := OpenAnonymous(bobBoxPrivate, sealed); err != nil || !bytes.Equal(opened, sunscreen) {
+		t.Errorf("round trip gave %q, %v", opened, err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		private []byte
+		data    []byte
+	}{
+		{"wrong recipient key", aliceBoxPrivate, sealed},
+		{"ephemeral key changed", bobBoxPrivate, flipByte(sealed, 0)},
+		{"MAC changed", bobBoxPrivate, flipByte(sealed, 32)},
+		{"ciphertext changed", bobBoxPrivate, flipByte(sealed, len(sealed)-1)},
+	} {
+		if _, err := OpenAnonymous(tt.private, tt.data); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: error = %v, want ErrAuthentication", tt.name, err)
+		}
+	}
+	if _, err := OpenAnonymous(bobBoxPrivate, sealed[:box.AnonymousOverhead-1]); !errors.Is(err, ErrCiphertextTooShort) {
+		t.Errorf("short box error = %v, want ErrCiphertextTooShort", err)
+	}
+}
+
+func TestBoxOperation(t *testing.T) {
+	for _, mode := range BoxModes() {
+		sealed, err := BoxOperation().Run(sunscreen, Options{"mode": mode, "private-key": aliceBoxPrivate, "peer-key": bobBoxPublic})
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		opened, err := BoxOperation().Run(sealed, Options{"mode": mode, "private-key": bobBoxPrivate, "peer-key": aliceBoxPublic, Reverse: true})
+		if err != nil || !bytes.Equal(opened, sunscreen) {
+			t.Errorf("%s: round trip gave %q, %v", mode, opened, err)
+		}
+	}
+}
+
+// flipByte returns a copy of data with one bit of byte i changed.
+func flipByte(data []byte, i int) []byte {
+	data = bytes.Clone(data)
+	data[i] ^= 0x10
+	return data
+}
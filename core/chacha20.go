@@ -0,0 +1,149 @@
+package core
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"math"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+type ChaChaMode int
+
+const (
+	ChaChaModeRaw ChaChaMode = iota // unauthenticated stream cipher
+	ChaChaModePoly1305
+	ChaChaModeXPoly1305
+)
+
+var chaChaModes = []string{"ChaCha20", "ChaCha20-Poly1305", "XChaCha20-Poly1305"}
+
+func (m ChaChaMode) String() string {
+	return chaChaModes[m]
+}
+
+func ChaChaModes() []string {
+	return append([]string(nil), chaChaModes...)
+}
+
+func ParseChaChaMode(s string) (ChaChaMode, error) {
+	for i, name := range chaChaModes {
+		if name == s {
+			return ChaChaMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: ChaCha20 mode %q", ErrUnknownAlgorithm, s)
+}
+
+// XORChaCha20 encrypts or decrypts data with the ChaCha20 stream starting at
+// the given block counter. It needs a 32 byte key and a 12 or 24 byte nonce.
+func XORChaCha20(key, nonce []byte, counter uint32, data []byte) ([]byte, error) {
+	c, err := chacha20.NewUnauthenticatedCipher(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetCounter(counter)
+
+	result := make([]byte, len(data))
+	c.XORKeyStream(result, data)
+
+	return result, nil
+}
+
+func newChaChaAEAD(mode ChaChaMode, key []byte) (cipher.AEAD, error) {
+	switch mode {
+	case ChaChaModePoly1305:
+		return chacha20poly1305.New(key)
+	case ChaChaModeXPoly1305:
+		return chacha20poly1305.NewX(key)
+	}
+	return nil, fmt.Errorf("%w: %v is not an AEAD mode", ErrUnknownAlgorithm, mode)
+}
+
+// SealChaCha20 encrypts and authenticates data with ChaCha20-Poly1305 (12
+// byte nonce) or XChaCha20-Poly1305 (24 byte nonce) under a 32 byte key. The
+// random nonce is prepended to the result; aad is authenticated but not
+// included.
+func SealChaCha20(mode ChaChaMode, key, aad, data []byte) ([]byte, error) {
+	aead, err := newChaChaAEAD(mode, key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, data, aad), nil
+}
+
+// OpenChaCha20 reverses SealChaCha20. Any change to the ciphertext, nonce or
+// aad, or a wrong key, gives ErrAuthentication.
+func OpenChaCha20(mode ChaChaMode, key, aad, data []byte) ([]byte, error) {
+	aead, err := newChaChaAEAD(mode, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+
+	return plaintext, nil
+}
+
+func ChaCha20Operation() *Operation {
+	return &Operation{
+		Name:     "chacha20",
+		Category: "crypto",
+		Params: append([]Param{
+			{Name: "mode", Kind: KindString, Choices: ChaChaModes(), Default: "ChaCha20", Usage: "plain stream cipher or AEAD"},
+			{Name: "key", Kind: KindBytes, Usage: "32 byte key"},
+			{Name: "nonce", Kind: KindBytes, Usage: "12 byte nonce (ChaCha20 mode)"},
+			{Name: "counter", Kind: KindInt, Default: 1, Usage: "initial block counter (ChaCha20 mode)"},
+			{Name: "aad", Kind: KindBytes, Usage: "associated data (AEAD modes)"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decrypt instead of encrypt"},
+		}, passphraseParams()...),
+		run: func(input []byte, opts Options) ([]byte, error) {
+			mode, err := ParseChaChaMode(opts.String("mode"))
+			if err != nil {
+				return nil, err
+			}
+			if c := opts.Int("counter"); c < 0 || uint64(c) > math.MaxUint32 {
+				return nil, fmt.Errorf("ChaCha20 counter %d out of range", c)
+			}
+			counter, aad := uint32(opts.Int("counter")), opts.Bytes("aad")
+
+			// with a passphrase the nonce is random and stored with the ciphertext
+			if passphrase := opts.Bytes("passphrase"); len(passphrase) > 0 {
+				if opts.Bool(Reverse) {
+					return DecryptChaCha20Passphrase(mode, passphrase, counter, aad, input)
+				}
+				params, err := passphraseKDF(opts)
+				if err != nil {
+					return nil, err
+				}
+				return EncryptChaCha20Passphrase(mode, passphrase, params, counter, aad, input)
+			}
+
+			switch {
+			case mode == ChaChaModeRaw:
+				return XORChaCha20(opts.Bytes("key"), opts.Bytes("nonce"), counter, input)
+			case opts.Bool(Reverse):
+				return OpenChaCha20(mode, opts.Bytes("key"), aad, input)
+			default:
+				return SealChaCha20(mode, opts.Bytes("key"), aad, input)
+			}
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+var sunscreen = []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+
+func TestXORChaCha20(t *testing.T) {
+	// RFC 8439 section 2.4.2
+	key := fromHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	nonce := fromHex("000000000000004a00000000")
+	want := fromHex(`6e2e359a2568f98041ba0728dd0d6981e97e7aec1d4360c20a27afccfd9fae0b
+		f91b65c5524733ab8f593dabcd62b3571639d624e65152ab8f530c359f0861d8
+		07ca0dbf500d6a6156a38e088a22b65e52bc514d16ccf806818ce91ab7793736
+		5af90bbf74a35be6b40b8eedf2785e42874d`)
+
+	got, err := XORChaCha20(key, nonce, 1, sunscreen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("XORChaCha20 = %x, want %x", got, want)
+	}
+
+	got, err = ChaCha20Operation().Run(want, Options{"key": key, "nonce": nonce})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, sunscreen) {
+		t.Errorf("operation decrypted %q", got)
+	}
+
+	for _, counter := range []int{-1, 1<<32 + 1} {
+		if _, err := ChaCha20Operation().Run(want, Options{"key": key, "nonce": nonce, "counter": counter}); err == nil {
+			t.Errorf("counter %d was accepted", counter)
+		}
+	}
+}
+
+func TestOpenChaCha20(t *testing.T) {
+	key := fromHex("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	aad := fromHex("50515253c0c1c2c3c4c5c6c7")
+	tests := []struct {
+		mode                   ChaChaMode
+		nonce, ciphertext, tag string
+	}{
+		{
+			// RFC 8439 section 2.8.2
+			ChaChaModePoly1305,
+			"070000004041424344454647",
+			`d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d6
+			3dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b36
+			92ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc
+			3ff4def08e4b7a9de576d26586cec64b6116`,
+			"1ae10b594f09e26a7e902ecbd0600691",
+		},
+		{
+			// draft-irtf-cfrg-xchacha-03 appendix A.3.1
+			ChaChaModeXPoly1305,
+			"404142434445464748494a4b4c4d4e4f5051525354555657",
+			`bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb
+			731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b452
+			2f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff9
+			21f9664c97637da9768812f615c68b13b52e`,
+			"c0875924c1c7987947deafd8780acf49",
+		},
+	}
+
+	for _, tt := range tests {
+		data := fromHex(tt.nonce + tt.ciphertext + tt.tag)
+		got, err := OpenChaCha20(tt.mode, key, aad, data)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.mode, err)
+		}
+		if !bytes.Equal(got, sunscreen) {
+			t.Errorf("%v: decrypted %q", tt.mode, got)
+		}
+
+		if _, err := OpenChaCha20(tt.mode, key, aad[1:], data); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%v: changed aad error = %v, want ErrAuthentication", tt.mode, err)
+		}
+		data[len(data)-1] ^= 1
+		if _, err := OpenChaCha20(tt.mode, key, aad, data); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%v: changed tag error = %v, want ErrAuthentication", tt.mode, err)
+		}
+		if _, err := OpenChaCha20(tt.mode, key, aad, data[:len(tt.nonce)/2+15]); !errors.Is(err, ErrCiphertextTooShort) {
+			t.Errorf("%v: short input error = %v, want ErrCiphertextTooShort", tt.mode, err)
+		}
+	}
+}
+
+func TestChaCha20RoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{9}, 32)
+	op := ChaCha20Operation()
+
+	for _, mode := range []string{"ChaCha20-Poly1305", "XChaCha20-Poly1305"} {
+		encrypted, err := op.Run(sunscreen, Options{"mode": mode, "key": key, "aad": []byte("header")})
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		decrypted, err := op.Run(encrypted, Options{"mode": mode, "key": key, "aad": []byte("header"), Reverse: true})
+		if err != nil {
+			t.Fatalf("%s decrypt: %v", mode, err)
+		}
+		if !bytes.Equal(decrypted, sunscreen) {
+			t.Errorf("%s: round trip gave %q", mode, decrypted)
+		}
+	}
+
+	if _, err := SealChaCha20(ChaChaModeRaw, key, nil, sunscreen); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("SealChaCha20 without Poly1305 error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+var Compressions = []string{"gzip", "zlib", "deflate"}
+
+func Compress(name string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+
+	switch name {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "deflate":
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress reverses Compress.
+func Decompress(name string, data []byte) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+
+	switch name {
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case "zlib":
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	case "deflate":
+		r = flate.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+func CompressOperation(name string) *Operation {
+	return &Operation{
+		Name:     name,
+		Category: "compress",
+		Params: []Param{
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decompress instead of compress"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			if opts.Bool(Reverse) {
+				return Decompress(name, input)
+			}
+			return Compress(name, input)
+		},
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	for _, name := range Compressions {
+		for _, data := range [][]byte{nil, sunscreen, bytes.Repeat([]byte("chify "), 10000)} {
+			compressed, err := Compress(name, data)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			got, err := Decompress(name, compressed)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("%s: round trip of %d bytes gave %d bytes", name, len(data), len(got))
+			}
+
+			op := CompressOperation(name)
+			viaOp, err := op.Run(compressed, Options{Reverse: true})
+			if err != nil || !bytes.Equal(viaOp, data) {
+				t.Errorf("%s operation: decompressed %d bytes, %v", name, len(viaOp), err)
+			}
+		}
+	}
+
+	if _, err := Compress("brotli", sunscreen); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("unknown compression error = %v, want ErrUnknownAlgorithm", err)
+	}
+	if _, err := Decompress("brotli", sunscreen); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("unknown decompression error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
+
+func TestDecompressCorrupt(t *testing.T) {
+	for _, name := range Compressions {
+		compressed, err := Compress(name, sunscreen)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		flipped := bytes.Clone(compressed)
+		flipped[len(flipped)/2] ^= 0x40
+		corrupt := map[string][]byte{
+			"truncated": compressed[:len(compressed)/2],
+			"garbage":   []byte("not compressed at all"),
+		}
+		if name != "deflate" {
+			// raw deflate has no checksum to catch a changed byte
+			corrupt["flipped"] = flipped
+		}
+
+		for kind, data := range corrupt {
+			if _, err := Decompress(name, data); err == nil {
+				t.Errorf("%s %s: no error", name, kind)
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ECDH key agreement over crypto/ecdh. Public keys are raw as in
+// ecdh.PublicKey.Bytes: 32 bytes for X25519, an uncompressed point for the
+// NIST curves.
+
+var ECDHCurves = []string{"X25519", "P-256", "P-384", "P-521"}
+
+// ECDHPublicKeyFormats are the encodings a public key can be exported in.
+var ECDHPublicKeyFormats = []string{"raw", "SPKI", "JWK"}
+
+var ErrECDHKey = errors.New("invalid ECDH key")
+
+var ecdhCurves = map[string]ecdh.Curve{
+	"X25519": ecdh.X25519(),
+	"P-256":  ecdh.P256(),
+	"P-384":  ecdh.P384(),
+	"P-521":  ecdh.P521(),
+}
+
+// ellipticCurves hold the domain parameters to decompress points, which
+// crypto/ecdh does not accept, and the curves of crypto/ecdsa.
+var ellipticCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func ecdhCurve(name string) (ecdh.Curve, error) {
+	curve, ok := ecdhCurves[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: curve %q", ErrUnknownAlgorithm, name)
+	}
+	return curve, nil
+}
+
+func ecdhCurveName(curve ecdh.Curve) string {
+	for name, c := range ecdhCurves {
+		if c == curve {
+			return name
+		}
+	}
+	return fmt.Sprint(curve)
+}
+
+func GenerateECDHKey(curveName string) (privateKey, publicKey []byte, err error) {
+	curve, err := ecdhCurve(curveName)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key.Bytes(), key.PublicKey().Bytes(), nil
+}
+
+func ECDHPublicKey(curveName string, privateKey []byte) ([]byte, error) {
+	curve, err := ecdhCurve(curveName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := curve.NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrECDHKey, err)
+	}
+	return key.PublicKey().Bytes(), nil
+}
+
+// ECDH returns the shared secret of privateKey and the raw peer public key:
+// the u-coordinate for X25519, the x-coordinate for the NIST curves.
+func ECDH(curveName string, privateKey, peerPublicKey []byte) ([]byte, error) {
+	curve, err := ecdhCurve(curveName)
+	if err != nil {
+		return nil, err
+	}
+	private, err := curve.NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: private key: %v", ErrECDHKey, err)
+	}
+	public, err := curve.NewPublicKey(peerPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: peer public key: %v", ErrECDHKey, err)
+	}
+	return private.ECDH(public)
+}
+
+// jwk is the subset of RFC 7517 used for EC ("EC") and X25519 ("OKP") keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+}
+
+// EncodeECDHPublicKey exports a raw public key as raw bytes, SPKI PEM or a JWK.
+func EncodeECDHPublicKey(curveName string, publicKey []byte, format string) ([]byte, error) {
+	curve, err := ecdhCurve(curveName)
+	if err != nil {
+		return nil, err
+	}
+	public, err := curve.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrECDHKey, err)
+	}
+
+	switch format {
+	case "raw":
+		return public.Bytes(), nil
+	case "SPKI":
+		der, err := x509.MarshalPKIXPublicKey(public)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+	case "JWK":
+		key := jwk{Kty: "EC", Crv: curveName}
+		if curveName == "X25519" {
+			key.Kty = "OKP"
+			key.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		} else {
+			// uncompressed point 0x04 || x || y
+			size := (len(publicKey) - 1) / 2
+			key.X = base64.RawURLEncoding.EncodeToString(publicKey[1 : 1+size])
+			key.Y = base64.RawURLEncoding.EncodeToString(publicKey[1+size:])
+		}
+		return json.Marshal(key)
+	}
+	return nil, fmt.Errorf("%w: public key format %q", ErrUnknownAlgorithm, format)
+}
+
+// ParseECDHPublicKey reads a public key for curveName given as SPKI (PEM or
+// DER), a JWK or raw bytes, compressed NIST points included, and returns
+// it raw. Keys on another curve are rejected.
+func ParseECDHPublicKey(curveName string, data []byte) ([]byte, error) {
+	curve, err := ecdhCurve(curveName)
+	if err != nil {
+		return nil, err
+	}
+
+	var public *ecdh.PublicKey
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		public, err = parseECDHJWK(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN")):
+		block, _ := pem.Decode(trimmed)
+		if block == nil {
+			return nil, fmt.Errorf("%w: bad PEM", ErrECDHKey)
+		}
+		public, err = parseECDHSPKI(block.Bytes)
+	default:
+		public, err = parseECDHRaw(curveName, curve, data)
+		if err != nil {
+			if spki, spkiErr := parseECDHSPKI(data); spkiErr == nil {
+				public, err = spki, nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if public.Curve() != curve {
+		return nil, fmt.Errorf("%w: %s key, expected %s", ErrECDHKey, ecdhCurveName(public.Curve()), curveName)
+	}
+	return public.Bytes(), nil
+}
+
+func parseECDHRaw(curveName string, curve ecdh.Curve, data []byte) (*ecdh.PublicKey, error) {
+	if ec, ok := ellipticCurves[curveName]; ok && len(data) > 0 && (data[0] == 2 || data[0] == 3) {
+		var err error
+		if data, err = decompressPoint(ec.Params(), data); err != nil {
+			return nil, err
+		}
+	}
+	public, err := curve.NewPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrECDHKey, err)
+	}
+	return public, nil
+}
+
+// decompressPoint returns the uncompressed form 04 || x || y of a compressed
+// point 02 or 03 || x, solving y² = x³ - 3x + b mod p for the y whose parity
+// the prefix gives. crypto/ecdh checks the result is on the curve.
+func decompressPoint(params *elliptic.CurveParams, data []byte) ([]byte, error) {
+	size := (params.BitSize + 7) / 8
+	if len(data) != 1+size {
+		return nil, fmt.Errorf("%w: compressed point of %d bytes, expected %d", ErrECDHKey, len(data), 1+size)
+	}
+	p := params.P
+	x := new(big.Int).SetBytes(data[1:])
+	if x.Cmp(p) >= 0 {
+		return nil, fmt.Errorf("%w: bad compressed point", ErrECDHKey)
+	}
+
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	threeX := new(big.Int).Lsh(x, 1)
+	threeX.Add(threeX, x)
+	y2.Sub(y2, threeX)
+	y2.Add(y2, params.B)
+	y2.Mod(y2, p)
+	y := new(big.Int).ModSqrt(y2, p)
+	if y == nil {
+		return nil, fmt.Errorf("%w: x is not on the curve", ErrECDHKey)
+	}
+	if y.Bit(0) != uint(data[0]&1) {
+		y.Sub(p, y)
+	}
+
+	point := make([]byte, 1+2*size)
+	point[0] = 4
+	x.FillBytes(point[1 : 1+size])
+	y.FillBytes(point[1+size:])
+	return point, nil
+}
+
+func parseECDHSPKI(der []byte) (*ecdh.PublicKey, error) {
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrECDHKey, err)
+	}
+	switch key := parsed.(type) {
+	case *ecdh.PublicKey:
+		return key, nil
+	case *ecdsa.PublicKey:
+		return key.ECDH()
+	}
+	return nil, fmt.Errorf("%w: SPKI key is %T", ErrECDHKey, parsed)
+}
+
+func parseECDHJWK(data []byte) (*ecdh.PublicKey, error) {
+	var key jwk
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("%w: JWK: %v", ErrECDHKey, err)
+	}
+	curve, ok := ecdhCurves[key.Crv]
+	if !ok {
+		return nil, fmt.Errorf("%w: JWK curve %q", ErrECDHKey, key.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(key.X)
+	if err != nil {
+		return nil, fmt.Errorf("%w: JWK x: %v", ErrECDHKey, err)
+	}
+
+	raw := x
+	switch {
+	case key.Kty == "OKP" && key.Crv == "X25519":
+	case key.Kty == "EC" && key.Crv != "X25519":
+		y, err := base64.RawURLEncoding.DecodeString(key.Y)
+		if err != nil {
+			return nil, fmt.Errorf("%w: JWK y: %v", ErrECDHKey, err)
+		}
+		raw = append(append([]byte{4}, x...), y...)
+	default:
+		return nil, fmt.Errorf("%w: JWK kty %q with curve %q", ErrECDHKey, key.Kty, key.Crv)
+	}
+
+	public, err := curve.NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrECDHKey, err)
+	}
+	return public, nil
+}
+
+// ECDHOperation reads the peer public key from the input and writes the
+// shared secret, or a key derived from it with HKDF when length is set.
+func ECDHOperation() *Operation {
+	return &Operation{
+		Name:     "ecdh",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "curve", Kind: KindString, Choices: ECDHCurves, Default: "X25519", Usage: "key agreement curve"},
+			{Name: "private-key", Kind: KindBytes, Usage: "own raw private key"},
+			{Name: "hash", Kind: KindString, Choices: ShaAlgorithms, Default: "sha256", Usage: "HKDF digest"},
+			{Name: "salt", Kind: KindBytes, Usage: "HKDF salt"},
+			{Name: "info", Kind: KindBytes, Usage: "HKDF info"},
+			{Name: "length", Kind: KindInt, Default: 0, Usage: "HKDF key length in bytes, 0 for the raw shared secret"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			curve := opts.String("curve")
+			peer, err := ParseECDHPublicKey(curve, input)
+			if err != nil {
+				return nil, err
+			}
+			shared, err := ECDH(curve, opts.Bytes("private-key"), peer)
+			if err != nil {
+				return nil, err
+			}
+			length := opts.Int("length")
+			if length < 0 {
+				return nil, fmt.Errorf("invalid HKDF length %d, must not be negative", length)
+			}
+			if length == 0 {
+				return shared, nil
+			}
+			return HKDF(opts.String("hash"), shared, opts.Bytes("salt"), opts.Bytes("info"), length)
+		},
+	}
+}
@@ -0,0 +1,209 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+// compressPoint is the 02 or 03 || x form of an uncompressed point.
+func compressPoint(point []byte) []byte {
+	size := (len(point) - 1) / 2
+	return append([]byte{2 | point[len(point)-1]&1}, point[1:1+size]...)
+}
+
+func TestParseECDHPublicKeyCompressed(t *testing.T) {
+	// the P-256 base point
+	g := fromHex(`04 6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296
+		4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5`)
+	got, err := ParseECDHPublicKey("P-256", fromHex("036b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, g) {
+		t.Errorf("decompressed G = %x, want %x", got, g)
+	}
+
+	for _, curve := range []string{"P-256", "P-384", "P-521"} {
+		for range 8 {
+			_, public, err := GenerateECDHKey(curve)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseECDHPublicKey(curve, compressPoint(public))
+			if err != nil {
+				t.Fatalf("%s: %v", curve, err)
+			}
+			if !bytes.Equal(got, public) {
+				t.Errorf("%s: decompressed %x, want %x", curve, got, public)
+			}
+		}
+	}
+}
+
+func TestParseECDHPublicKeyBadPoints(t *testing.T) {
+	params := ellipticCurves["P-256"].Params()
+	compressed := fromHex("036b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296")
+
+	// the first x > 0 with no point on the curve
+	notOnCurve := make([]byte, 33)
+	notOnCurve[0] = 2
+	for x := int64(1); ; x++ {
+		big.NewInt(x).FillBytes(notOnCurve[1:])
+		if _, err := decompressPoint(params, notOnCurve); err != nil {
+			break
+		}
+	}
+
+	tests := []struct {
+		name, curve string
+		data        []byte
+	}{
+		{"short", "P-256", compressed[:32]},
+		{"long", "P-256", append(bytes.Clone(compressed), 0)},
+		{"x = p", "P-256", append([]byte{2}, params.P.Bytes()...)},
+		{"x not on the curve", "P-256", notOnCurve},
+		{"P-256 point on P-384", "P-384", compressed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseECDHPublicKey(tt.curve, tt.data); !errors.Is(err, ErrECDHKey) {
+				t.Errorf("error = %v, want ErrECDHKey", err)
+			}
+		})
+	}
+}
+
+func TestECDHVectors(t *testing.T) {
+	tests := []struct {
+		name, curve, private, peer, shared string
+	}{
+		// RFC 7748 section 5.2, the two X25519 scalar multiplications
+		{
+			"RFC 7748 5.2 #1", "X25519",
+			"a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4",
+			"e6db6867583030db3594c1a424b15f7c726624ec26b3353b10a903a6d0ab1c4c",
+			"c3da55379de9c6908e94ea4df28d084f32eccf03491c71f754b4075577a28552",
+		},
+		{
+			"RFC 7748 5.2 #2", "X25519",
+			"4b66e9d4d1b4673c5ad22691957d6af5c11b6421e0ea01d42ca4169e7918ba0d",
+			"e5210f12786811d3f4b7959d0538ae2c31dbe7106fc03c3efc4cd549c715a493",
+			"95cbde9476e8907d7aade45cb4b873f88b595a68799fa152e6f8f7647aac7957",
+		},
+		// RFC 7748 section 6.1, both directions
+		{
+			"RFC 7748 6.1 Alice", "X25519",
+			"77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a",
+			"de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f",
+			"4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742",
+		},
+		{
+			"RFC 7748 6.1 Bob", "X25519",
+			"5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb",
+			"8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a",
+			"4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742",
+		},
+		// NIST CAVS 14.1 ECC CDH primitive, P-256 COUNT = 0
+		{
+			"CAVS P-256 #0", "P-256",
+			"7d7dc5f71eb29ddaf80d6214632eeae03d9058af1fb6d22ed80badb62bc1a534",
+			`04 700c48f77f56584c5cc632ca65640db91b6bacce3a4df6b42ce7cc838833d287
+			db71e509e3fd9b060ddb20ba5c51dcc5948d46fbf640dfe0441782cab85fa4ac`,
+			"46fc62106420ff012e54a434fbdd2d25ccc5852060561e68040dd7778997bd7b",
+		},
+	}
+
+	for _, tt := range tests {
+		private, peer, want := fromHex(tt.private), fromHex(tt.peer), fromHex(tt.shared)
+		got, err := ECDH(tt.curve, private, peer)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: shared secret %x, want %x", tt.name, got, want)
+		}
+
+		// the operation takes the peer key in any format
+		opts := Options{"curve": tt.curve, "private-key": private}
+		for _, format := range ECDHPublicKeyFormats {
+			encoded, err := EncodeECDHPublicKey(tt.curve, peer, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ECDHOperation().Run(encoded, opts); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%s %s: operation gave %x, %v", tt.name, format, got, err)
+			}
+		}
+	}
+
+	// X25519 public keys of RFC 7748 section 6.1
+	public, err := ECDHPublicKey("X25519", fromHex("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a"))
+	if err != nil || !bytes.Equal(public, fromHex("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")) {
+		t.Errorf("Alice's public key %x, %v", public, err)
+	}
+	// and the IUT public key of the CAVS vector
+	public, err = ECDHPublicKey("P-256", fromHex("7d7dc5f71eb29ddaf80d6214632eeae03d9058af1fb6d22ed80badb62bc1a534"))
+	want := fromHex(`04 ead218590119e8876b29146ff89ca61770c4edbbf97d38ce385ed281d8a6b230
+		28af61281fd35e2fa7002523acc85a429cb06ee6648325389f59edfce1405141`)
+	if err != nil || !bytes.Equal(public, want) {
+		t.Errorf("P-256 public key %x, %v", public, err)
+	}
+}
+
+func TestECDHPublicKeyFormats(t *testing.T) {
+	for _, curve := range ECDHCurves {
+		_, public, err := GenerateECDHKey(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, format := range ECDHPublicKeyFormats {
+			encoded, err := EncodeECDHPublicKey(curve, public, format)
+			if err != nil {
+				t.Fatalf("%s %s: %v", curve, format, err)
+			}
+			got, err := ParseECDHPublicKey(curve, encoded)
+			if err != nil || !bytes.Equal(got, public) {
+				t.Errorf("%s %s: parsed to %x, %v", curve, format, got, err)
+			}
+
+			// a key is only read for its own curve
+			other := ECDHCurves[(slices.Index(ECDHCurves, curve)+1)%len(ECDHCurves)]
+			if format != "raw" {
+				if _, err := ParseECDHPublicKey(other, encoded); !errors.Is(err, ErrECDHKey) {
+					t.Errorf("%s %s read as %s: error = %v, want ErrECDHKey", curve, format, other, err)
+				}
+			}
+		}
+	}
+
+	if _, err := EncodeECDHPublicKey("X25519", make([]byte, 32), "JWS"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("unknown format error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
+
+func TestECDHOperationHKDF(t *testing.T) {
+	opts := Options{
+		"curve":       "X25519",
+		"private-key": fromHex("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a"),
+		"hash":        "sha256",
+		"salt":        []byte("salt"),
+		"info":        []byte("chify"),
+		"length":      42,
+	}
+	peer := fromHex("de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f")
+
+	// HKDF-SHA256 of the RFC 7748 section 6.1 shared secret, computed with
+	// Python's hmac module
+	want := fromHex("46381fe0cedb242fea29ba83b365af8aded6c39e7488ef49ec916fa894c29044c733ce9782f9ab4089b3")
+	if got, err := ECDHOperation().Run(peer, opts); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("derived key %x, %v, want %x", got, err, want)
+	}
+
+	opts["length"] = -1
+	if _, err := ECDHOperation().Run(peer, opts); err == nil {
+		t.Error("negative length was accepted")
+	}
+}
@@ -0,0 +1,277 @@
+package core
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"slices"
+)
+
+// ECDSA signatures over the NIST curves. A signature is either DER, the
+// ASN.1 sequence of r and s used by X.509 and OpenSSL, or raw, r ‖ s at the
+// curve size as in JWS, WebCrypto and PKCS #11.
+
+var ECDSACurves = []string{"P-256", "P-384", "P-521"}
+
+// ECDSAHashes are the SHA-2 and SHA-3 digests accepted for signing.
+var ECDSAHashes = slices.DeleteFunc(slices.Clone(ShaAlgorithms), func(name string) bool {
+	return name == "sha1"
+})
+
+var SignatureEncodings = []string{"DER", "raw"}
+
+// ECDSADefaultHash returns the digest matching the strength of curve.
+func ECDSADefaultHash(curve string) string {
+	switch curve {
+	case "P-384":
+		return "sha384"
+	case "P-521":
+		return "sha512"
+	}
+	return "sha256"
+}
+
+func ecdsaCurve(name string) (elliptic.Curve, error) {
+	curve, ok := ellipticCurves[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: curve %q", ErrUnknownAlgorithm, name)
+	}
+	return curve, nil
+}
+
+// ECDSACurveName returns the ECDSACurves name of the key's curve.
+func ECDSACurveName(key *ecdsa.PublicKey) string {
+	return key.Curve.Params().Name
+}
+
+func GenerateECDSAKey(curveName string) (*ecdsa.PrivateKey, error) {
+	curve, err := ecdsaCurve(curveName)
+	if err != nil {
+		return nil, err
+	}
+	return ecdsa.GenerateKey(curve, rand.Reader)
+}
+
+// EncodeECDSAPrivateKey exports key as PKCS #8 PEM or the raw scalar.
+func EncodeECDSAPrivateKey(key *ecdsa.PrivateKey, format string) ([]byte, error) {
+	switch format {
+	case "raw":
+		private, err := key.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		return private.Bytes(), nil
+	case "PEM":
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+	return nil, fmt.Errorf("%w: key format %q", ErrUnknownAlgorithm, format)
+}
+
+// EncodeECDSAPublicKey exports key as SPKI PEM or the uncompressed point.
+func EncodeECDSAPublicKey(key *ecdsa.PublicKey, format string) ([]byte, error) {
+	switch format {
+	case "raw":
+		public, err := key.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		return public.Bytes(), nil
+	case "PEM":
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+	}
+	return nil, fmt.Errorf("%w: key format %q", ErrUnknownAlgorithm, format)
+}
+
+// ParseECDSAPrivateKey reads a PKCS #8 or SEC 1 key, PEM or DER, or a raw
+// scalar on curveName.
+func ParseECDSAPrivateKey(curveName string, data []byte) (*ecdsa.PrivateKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	if key, err := x509.ParseECPrivateKey(data); err == nil {
+		return key, nil
+	}
+	if parsed, err := x509.ParsePKCS8PrivateKey(data); err == nil {
+		key, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: PKCS #8 key is %T, not ECDSA", ErrSignKey, parsed)
+		}
+		return key, nil
+	}
+	return rawECDSAPrivateKey(curveName, data)
+}
+
+func rawECDSAPrivateKey(curveName string, scalar []byte) (*ecdsa.PrivateKey, error) {
+	curve, err := ecdsaCurve(curveName)
+	if err != nil {
+		return nil, err
+	}
+	// crypto/ecdh checks the scalar range and computes the public point
+	private, err := ecdhCurves[curveName].NewPrivateKey(scalar)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignKey, err)
+	}
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsaPublicKey(curve, private.PublicKey()),
+		D:         new(big.Int).SetBytes(scalar),
+	}, nil
+}
+
+// ecdsaPublicKey splits the uncompressed point 04 || x || y of a key that
+// crypto/ecdh already checked is on the curve.
+func ecdsaPublicKey(curve elliptic.Curve, public *ecdh.PublicKey) ecdsa.PublicKey {
+	point := public.Bytes()[1:]
+	size := len(point) / 2
+	return ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(point[:size]),
+		Y:     new(big.Int).SetBytes(point[size:]),
+	}
+}
+
+// ParseECDSAPublicKey reads an SPKI key (PEM or DER), the public half of a
+// PEM private key or a raw point on curveName, compressed or not.
+func ParseECDSAPublicKey(curveName string, data []byte) (*ecdsa.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type == "PRIVATE KEY" || block.Type == "EC PRIVATE KEY" {
+			key, err := ParseECDSAPrivateKey(curveName, block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return &key.PublicKey, nil
+		}
+		data = block.Bytes
+	}
+
+	if parsed, err := x509.ParsePKIXPublicKey(data); err == nil {
+		key, ok := parsed.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: public key is %T, not ECDSA", ErrSignKey, parsed)
+		}
+		return key, nil
+	}
+
+	curve, err := ecdsaCurve(curveName)
+	if err != nil {
+		return nil, err
+	}
+	public, err := parseECDHRaw(curveName, ecdhCurves[curveName], data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignKey, err)
+	}
+	key := ecdsaPublicKey(curve, public)
+	return &key, nil
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// SignECDSA signs the digest of data and returns the signature in encoding.
+func SignECDSA(key *ecdsa.PrivateKey, hash string, data []byte, encoding string) ([]byte, error) {
+	if !slices.Contains(ECDSAHashes, hash) {
+		return nil, fmt.Errorf("%w: ECDSA digest %q", ErrUnknownAlgorithm, hash)
+	}
+	digest, err := Hash(hash, data)
+	if err != nil {
+		return nil, err
+	}
+	der, err := ecdsa.SignASN1(rand.Reader, key, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	switch encoding {
+	case "DER":
+		return der, nil
+	case "raw":
+		var sig ecdsaSignature
+		if _, err := asn1.Unmarshal(der, &sig); err != nil {
+			return nil, err
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		raw := make([]byte, 2*size)
+		sig.R.FillBytes(raw[:size])
+		sig.S.FillBytes(raw[size:])
+		return raw, nil
+	}
+	return nil, fmt.Errorf("%w: signature encoding %q", ErrUnknownAlgorithm, encoding)
+}
+
+// VerifyECDSA reports whether signature, in encoding, is valid for data.
+func VerifyECDSA(key *ecdsa.PublicKey, hash string, data, signature []byte, encoding string) (bool, error) {
+	if !slices.Contains(ECDSAHashes, hash) {
+		return false, fmt.Errorf("%w: ECDSA digest %q", ErrUnknownAlgorithm, hash)
+	}
+	digest, err := Hash(hash, data)
+	if err != nil {
+		return false, err
+	}
+
+	switch encoding {
+	case "DER":
+		return ecdsa.VerifyASN1(key, digest, signature), nil
+	case "raw":
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false, nil
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s), nil
+	}
+	return false, fmt.Errorf("%w: signature encoding %q", ErrUnknownAlgorithm, encoding)
+}
+
+// ECDSAOperation signs the input, or verifies it when a signature is given,
+// in which case the output is the input and an invalid signature is an error.
+func ECDSAOperation() *Operation {
+	return &Operation{
+		Name:     "ecdsa",
+		Category: "sign",
+		Params: []Param{
+			{Name: "curve", Kind: KindString, Choices: ECDSACurves, Default: "P-256", Usage: "curve of raw keys, PEM keys name their own"},
+			{Name: "hash", Kind: KindString, Choices: ECDSAHashes, Default: "sha256", Usage: "message digest"},
+			{Name: "encoding", Kind: KindString, Choices: SignatureEncodings, Default: "DER", Usage: "signature encoding, DER or raw r||s"},
+			{Name: "key", Kind: KindBytes, Usage: "PEM or raw key, private to sign, public to verify"},
+			{Name: "signature", Kind: KindBytes, Usage: "detached signature to verify instead of signing"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			curve, hash, encoding := opts.String("curve"), opts.String("hash"), opts.String("encoding")
+			if signature := opts.Bytes("signature"); signature != nil {
+				key, err := ParseECDSAPublicKey(curve, opts.Bytes("key"))
+				if err != nil {
+					return nil, err
+				}
+				valid, err := VerifyECDSA(key, hash, input, signature, encoding)
+				if err != nil {
+					return nil, err
+				}
+				if !valid {
+					return nil, ErrInvalidSignature
+				}
+				return input, nil
+			}
+			key, err := ParseECDSAPrivateKey(curve, opts.Bytes("key"))
+			if err != nil {
+				return nil, err
+			}
+			return SignECDSA(key, hash, input, encoding)
+		},
+	}
+}
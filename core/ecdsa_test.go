@@ -0,0 +1,62 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseECDSARawKeys(t *testing.T) {
+	for _, curve := range ECDSACurves {
+		key, err := GenerateECDSAKey(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scalar, err := EncodeECDSAPrivateKey(key, "raw")
+		if err != nil {
+			t.Fatal(err)
+		}
+		point, err := EncodeECDSAPublicKey(&key.PublicKey, "raw")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		private, err := ParseECDSAPrivateKey(curve, scalar)
+		if err != nil {
+			t.Fatalf("%s: %v", curve, err)
+		}
+		if !private.Equal(key) {
+			t.Errorf("%s: raw private key parsed to another key", curve)
+		}
+
+		for _, data := range [][]byte{point, compressPoint(point)} {
+			public, err := ParseECDSAPublicKey(curve, data)
+			if err != nil {
+				t.Fatalf("%s: %v", curve, err)
+			}
+			if !public.Equal(&key.PublicKey) {
+				t.Errorf("%s: public key %x parsed to another key", curve, data)
+			}
+		}
+
+		for _, encoding := range SignatureEncodings {
+			signature, err := SignECDSA(private, ECDSADefaultHash(curve), sunscreen, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := VerifyECDSA(&key.PublicKey, ECDSADefaultHash(curve), sunscreen, signature, encoding); err != nil || !ok {
+				t.Errorf("%s %s: signature did not verify: %v", curve, encoding, err)
+			}
+			if ok, _ := VerifyECDSA(&key.PublicKey, ECDSADefaultHash(curve), sunscreen[1:], signature, encoding); ok {
+				t.Errorf("%s %s: signature verified for other data", curve, encoding)
+			}
+		}
+	}
+
+	n := ellipticCurves["P-256"].Params().N
+	if _, err := ParseECDSAPrivateKey("P-256", n.Bytes()); !errors.Is(err, ErrSignKey) {
+		t.Errorf("scalar n error = %v, want ErrSignKey", err)
+	}
+	if _, err := ParseECDSAPublicKey("P-256", make([]byte, 65)); !errors.Is(err, ErrSignKey) {
+		t.Errorf("zero point error = %v, want ErrSignKey", err)
+	}
+}
@@ -0,0 +1,263 @@
+package core
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Ed25519 signatures as in RFC 8032. Ed25519ph signs the SHA-512 digest of
+// the message, Ed25519ctx binds the signature to a context string.
+
+type Ed25519Variant int
+
+const (
+	Ed25519Pure Ed25519Variant = iota
+	Ed25519ph
+	Ed25519ctx
+)
+
+var ed25519Variants = []string{"Ed25519", "Ed25519ph", "Ed25519ctx"}
+
+func (v Ed25519Variant) String() string {
+	if v < 0 || int(v) >= len(ed25519Variants) {
+		return fmt.Sprintf("Ed25519Variant(%d)", v)
+	}
+	return ed25519Variants[v]
+}
+
+func Ed25519Variants() []string {
+	return append([]string(nil), ed25519Variants...)
+}
+
+func ParseEd25519Variant(s string) (Ed25519Variant, error) {
+	for i, name := range ed25519Variants {
+		if name == s {
+			return Ed25519Variant(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: Ed25519 variant %q", ErrUnknownAlgorithm, s)
+}
+
+// SignKeyFormats are the encodings signing keys are exported in: PEM is
+// PKCS #8 or SPKI, raw is the bare key (Ed25519 seed, EC scalar or point).
+var SignKeyFormats = []string{"PEM", "raw"}
+
+var (
+	ErrSignKey          = errors.New("invalid signing key")
+	ErrInvalidSignature = errors.New("signature is not valid")
+)
+
+// GenerateEd25519Key returns a 32 byte private key (the RFC 8032 seed) and
+// its public key.
+func GenerateEd25519Key() (privateKey, publicKey []byte, err error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return private.Seed(), public, nil
+}
+
+func ed25519PrivateKey(privateKey []byte) (ed25519.PrivateKey, error) {
+	switch len(privateKey) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(privateKey), nil
+	case ed25519.PrivateKeySize: // seed followed by the public key, as in libsodium
+		key := ed25519.NewKeyFromSeed(privateKey[:ed25519.SeedSize])
+		if !key.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(privateKey[ed25519.SeedSize:])) {
+			return nil, fmt.Errorf("%w: public half does not match the seed", ErrSignKey)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: Ed25519 private key size %d, must be 32 or 64", ErrSignKey, len(privateKey))
+}
+
+func Ed25519PublicKey(privateKey []byte) ([]byte, error) {
+	key, err := ed25519PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return key.Public().(ed25519.PublicKey), nil
+}
+
+// EncodeEd25519Key exports a raw private or public key as PEM or raw bytes.
+func EncodeEd25519Key(key []byte, private bool, format string) ([]byte, error) {
+	var (
+		der []byte
+		err error
+	)
+	switch {
+	case format == "raw" && private:
+		priv, err := ed25519PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return priv.Seed(), nil
+	case format == "raw":
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: Ed25519 public key size %d, must be 32", ErrSignKey, len(key))
+		}
+		return key, nil
+	case format != "PEM":
+		return nil, fmt.Errorf("%w: key format %q", ErrUnknownAlgorithm, format)
+	case private:
+		priv, err := ed25519PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if der, err = x509.MarshalPKCS8PrivateKey(priv); err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: Ed25519 public key size %d, must be 32", ErrSignKey, len(key))
+	}
+	if der, err = x509.MarshalPKIXPublicKey(ed25519.PublicKey(key)); err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// ParseEd25519PrivateKey reads a PKCS #8 key (PEM or DER), a 32 byte seed or
+// a 64 byte seed and public key, and returns the seed.
+func ParseEd25519PrivateKey(data []byte) ([]byte, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	} else if len(data) == ed25519.SeedSize || len(data) == ed25519.PrivateKeySize {
+		key, err := ed25519PrivateKey(data)
+		if err != nil {
+			return nil, err
+		}
+		return key.Seed(), nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignKey, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: PKCS #8 key is %T, not Ed25519", ErrSignKey, parsed)
+	}
+	return key.Seed(), nil
+}
+
+// ParseEd25519PublicKey reads an SPKI key (PEM or DER) or 32 raw bytes. The
+// public half of a PEM private key is accepted as well.
+func ParseEd25519PublicKey(data []byte) ([]byte, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type == "PRIVATE KEY" {
+			seed, err := ParseEd25519PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return Ed25519PublicKey(seed)
+		}
+		data = block.Bytes
+	} else if len(data) == ed25519.PublicKeySize {
+		return data, nil
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignKey, err)
+	}
+	key, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: public key is %T, not Ed25519", ErrSignKey, parsed)
+	}
+	return key, nil
+}
+
+// ed25519Options returns the message to sign and the options of variant.
+// Context may be up to 255 bytes; Ed25519 takes none.
+func ed25519Options(variant Ed25519Variant, context, data []byte) ([]byte, *ed25519.Options, error) {
+	switch variant {
+	case Ed25519Pure:
+		if len(context) > 0 {
+			return nil, nil, errors.New("Ed25519 takes no context, use Ed25519ctx or Ed25519ph")
+		}
+		return data, &ed25519.Options{}, nil
+	case Ed25519ph:
+		digest := sha512.Sum512(data)
+		return digest[:], &ed25519.Options{Hash: crypto.SHA512, Context: string(context)}, nil
+	case Ed25519ctx:
+		if len(context) == 0 {
+			return nil, nil, errors.New("Ed25519ctx needs a context")
+		}
+		return data, &ed25519.Options{Context: string(context)}, nil
+	}
+	return nil, nil, fmt.Errorf("%w: Ed25519 variant %d", ErrUnknownAlgorithm, variant)
+}
+
+// SignEd25519 returns the 64 byte signature of data.
+func SignEd25519(variant Ed25519Variant, privateKey, context, data []byte) ([]byte, error) {
+	key, err := ed25519PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	message, opts, err := ed25519Options(variant, context, data)
+	if err != nil {
+		return nil, err
+	}
+	return key.Sign(nil, message, opts)
+}
+
+// VerifyEd25519 reports whether signature is valid for data.
+func VerifyEd25519(variant Ed25519Variant, publicKey, context, data, signature []byte) (bool, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false, fmt.Errorf("%w: Ed25519 public key size %d, must be 32", ErrSignKey, len(publicKey))
+	}
+	message, opts, err := ed25519Options(variant, context, data)
+	if err != nil {
+		return false, err
+	}
+	return ed25519.VerifyWithOptions(publicKey, message, signature, opts) == nil, nil
+}
+
+// Ed25519Operation signs the input, or verifies it when a signature is
+// given, in which case the output is the input and an invalid signature is
+// an error.
+func Ed25519Operation() *Operation {
+	return &Operation{
+		Name:     "ed25519",
+		Category: "sign",
+		Params: []Param{
+			{Name: "variant", Kind: KindString, Choices: ed25519Variants, Default: "Ed25519", Usage: "RFC 8032 variant"},
+			{Name: "context", Kind: KindBytes, Usage: "Ed25519ctx or Ed25519ph context"},
+			{Name: "key", Kind: KindBytes, Usage: "PEM or raw key, private to sign, public to verify"},
+			{Name: "signature", Kind: KindBytes, Usage: "detached signature to verify instead of signing"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			variant, err := ParseEd25519Variant(opts.String("variant"))
+			if err != nil {
+				return nil, err
+			}
+			if signature := opts.Bytes("signature"); signature != nil {
+				public, err := ParseEd25519PublicKey(opts.Bytes("key"))
+				if err != nil {
+					return nil, err
+				}
+				valid, err := VerifyEd25519(variant, public, opts.Bytes("context"), input, signature)
+				if err != nil {
+					return nil, err
+				}
+				if !valid {
+					return nil, ErrInvalidSignature
+				}
+				return input, nil
+			}
+			private, err := ParseEd25519PrivateKey(opts.Bytes("key"))
+			if err != nil {
+				return nil, err
+			}
+			return SignEd25519(variant, private, opts.Bytes("context"), input)
+		},
+	}
+}
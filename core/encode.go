@@ -0,0 +1,66 @@
+package core
+
+import (
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+var Encodings = []string{"base32", "base64", "ascii85", "hex"}
+
+// Encode converts data to the named text encoding.
+func Encode(name string, data []byte) ([]byte, error) {
+	switch name {
+	case "base32":
+		return []byte(base32.StdEncoding.EncodeToString(data)), nil
+	case "base64":
+		return []byte(base64.StdEncoding.EncodeToString(data)), nil
+	case "ascii85":
+		dst := make([]byte, ascii85.MaxEncodedLen(len(data)))
+		n := ascii85.Encode(dst, data)
+		return dst[:n], nil
+	case "hex":
+		return []byte(hex.EncodeToString(data)), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+}
+
+// Decode reverses Encode.
+func Decode(name string, data []byte) ([]byte, error) {
+	switch name {
+	case "base32":
+		return base32.StdEncoding.DecodeString(string(data))
+	case "base64":
+		return base64.StdEncoding.DecodeString(string(data))
+	case "ascii85":
+		dst := make([]byte, len(data)*4)
+		n, _, err := ascii85.Decode(dst, data, true)
+		if err != nil {
+			return nil, err
+		}
+		return dst[:n], nil
+	case "hex":
+		return hex.DecodeString(string(data))
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+}
+
+func EncodeOperation(name string) *Operation {
+	return &Operation{
+		Name:     name,
+		Category: "encode",
+		Params: []Param{
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decode instead of encode"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			if opts.Bool(Reverse) {
+				return Decode(name, input)
+			}
+			return Encode(name, input)
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	// RFC 4648 section 10 and the ascii85 example of encoding/ascii85
+	tests := []struct {
+		name, data, want string
+	}{
+		{"base64", "", ""},
+		{"base64", "f", "Zg=="},
+		{"base64", "fo", "Zm8="},
+		{"base64", "foobar", "Zm9vYmFy"},
+		{"base32", "f", "MY======"},
+		{"base32", "foob", "MZXW6YQ="},
+		{"base32", "foobar", "MZXW6YTBOI======"},
+		{"hex", "foobar", "666f6f626172"},
+		{"ascii85", "\x00\x00\x00\x00", "z"},
+		{"ascii85", "sure.", "F*2M7/c"},
+	}
+
+	for _, tt := range tests {
+		got, err := Encode(tt.name, []byte(tt.data))
+		if err != nil {
+			t.Fatalf("Encode(%s, %q): %v", tt.name, tt.data, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Encode(%s, %q) = %q, want %q", tt.name, tt.data, got, tt.want)
+		}
+		back, err := Decode(tt.name, got)
+		if err != nil {
+			t.Fatalf("Decode(%s, %q): %v", tt.name, got, err)
+		}
+		if string(back) != tt.data {
+			t.Errorf("Decode(%s, %q) = %q, want %q", tt.name, got, back, tt.data)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	for _, name := range Encodings {
+		op := EncodeOperation(name)
+		encoded, err := op.Run(data, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		decoded, err := op.Run(encoded, Options{Reverse: true})
+		if err != nil {
+			t.Fatalf("%s decode: %v", name, err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("%s: round trip changed the data", name)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, tt := range []struct{ name, data string }{
+		{"base64", "Zm9v!"},
+		{"base32", "MZXW6YQ"},
+		{"hex", "abc"},
+		{"ascii85", "~~~"},
+	} {
+		if _, err := Decode(tt.name, []byte(tt.data)); err == nil {
+			t.Errorf("Decode(%s, %q) succeeded", tt.name, tt.data)
+		}
+	}
+
+	if _, err := Encode("base58", nil); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("Encode(base58) error = %v, want ErrUnknownAlgorithm", err)
+	}
+	if _, err := Decode("base58", nil); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("Decode(base58) error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"cmp"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+)
+
+// GCMParams controls AES-GCM beyond the defaults of EncryptAES, for messages
+// whose nonce travels separately or whose headers are bound as AAD.
+type GCMParams struct {
+	AAD       []byte
+	Nonce     []byte // random when sealing if empty
+	NonceSize int    // 12 if 0
+	TagSize   int    // 16 if 0, at least 12
+}
+
+func (p GCMParams) sizes() (nonceSize, tagSize int, err error) {
+	nonceSize, tagSize = cmp.Or(p.NonceSize, 12), cmp.Or(p.TagSize, 16)
+	if len(p.Nonce) > 0 {
+		nonceSize = len(p.Nonce)
+	}
+	if nonceSize < 1 || nonceSize > 1024 {
+		return 0, 0, fmt.Errorf("invalid GCM nonce size %d", nonceSize)
+	}
+	if tagSize < 12 || tagSize > 16 {
+		return 0, 0, fmt.Errorf("invalid GCM tag size %d, must be 12 to 16", tagSize)
+	}
+	return nonceSize, tagSize, nil
+}
+
+// SealAESGCM encrypts data and returns the nonce, the ciphertext and the
+// tag separately. A truncated tag is the leading bytes of the full one.
+func SealAESGCM(key []byte, p GCMParams, data []byte) (nonce, ciphertext, tag []byte, err error) {
+	nonceSize, tagSize, err := p.sizes()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	gcm, err := newAESGCM(key, nonceSize)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	nonce = p.Nonce
+	if len(nonce) == 0 {
+		nonce = make([]byte, nonceSize)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	sealed := gcm.Seal(nil, nonce, data, p.AAD)
+	ciphertext, tag = sealed[:len(data)], sealed[len(data):len(data)+tagSize]
+	return nonce, ciphertext, tag, nil
+}
+
+// OpenAESGCM decrypts a ciphertext whose nonce (p.Nonce) and tag are given
+// separately, and returns ErrAuthentication if anything does not match.
+func OpenAESGCM(key []byte, p GCMParams, ciphertext, tag []byte) ([]byte, error) {
+	nonceSize, tagSize, err := p.sizes()
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Nonce) == 0 {
+		return nil, fmt.Errorf("GCM nonce is required to decrypt")
+	}
+	if len(tag) != tagSize {
+		return nil, fmt.Errorf("GCM tag is %d bytes, expected %d", len(tag), tagSize)
+	}
+	gcm, err := newAESGCM(key, nonceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if tagSize == gcm.Overhead() {
+		plaintext, err := gcm.Open(nil, p.Nonce, append(ciphertext[:len(ciphertext):len(ciphertext)], tag...), p.AAD)
+		if err != nil {
+			return nil, ErrAuthentication
+		}
+		return plaintext, nil
+	}
+
+	// cipher.NewGCMWithTagSize only takes 12 byte nonces. GCM encrypts with a
+	// plain keystream, so recover the plaintext by sealing zeros, then seal
+	// it again and compare the truncated tags.
+	keystream := gcm.Seal(nil, p.Nonce, make([]byte, len(ciphertext)), nil)
+	plaintext := make([]byte, len(ciphertext))
+	subtle.XORBytes(plaintext, ciphertext, keystream[:len(ciphertext)])
+
+	resealed := gcm.Seal(nil, p.Nonce, plaintext, p.AAD)
+	if subtle.ConstantTimeCompare(resealed[len(plaintext):len(plaintext)+tagSize], tag) != 1 {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
+
+// EncryptAESGCM returns nonce | ciphertext | tag, or ciphertext | tag when
+// the nonce was supplied in p and is sent separately.
+func EncryptAESGCM(key []byte, p GCMParams, data []byte) ([]byte, error) {
+	nonce, ciphertext, tag, err := SealAESGCM(key, p, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []byte
+	if len(p.Nonce) == 0 {
+		result = append(result, nonce...)
+	}
+	result = append(result, ciphertext...)
+	return append(result, tag...), nil
+}
+
+// DecryptAESGCM reverses EncryptAESGCM with the same params.
+func DecryptAESGCM(key []byte, p GCMParams, data []byte) ([]byte, error) {
+	nonceSize, tagSize, err := p.sizes()
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Nonce) == 0 {
+		if len(data) < nonceSize {
+			return nil, ErrCiphertextTooShort
+		}
+		p.Nonce, data = data[:nonceSize], data[nonceSize:]
+	}
+	if len(data) < tagSize {
+		return nil, ErrCiphertextTooShort
+	}
+
+	return OpenAESGCM(key, p, data[:len(data)-tagSize], data[len(data)-tagSize:])
+}
+
+func newAESGCM(key []byte, nonceSize int) (cipher.AEAD, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(c, nonceSize)
+}
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"slices"
+)
+
+// AES-GCM-SIV (RFC 8452) derives a fresh key pair from every nonce and uses
+// the tag as the CTR IV, so a repeated nonce only reveals that the same
+// message was sent twice.
+
+const (
+	gcmSIVNonceSize = 12
+	gcmSIVTagSize   = 16
+	gcmSIVMaxSize   = 1 << 36
+)
+
+type gcmSIV struct {
+	key cipher.Block
+	// size of the derived encryption key, the same as the key
+	keySize int
+}
+
+// NewGCMSIV returns AES-GCM-SIV for a 16 or 32 byte key.
+func NewGCMSIV(key []byte) (cipher.AEAD, error) {
+	if len(key) != 16 && len(key) != 32 {
+		return nil, aes.KeySizeError(len(key))
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmSIV{key: c, keySize: len(key)}, nil
+}
+
+func (g *gcmSIV) NonceSize() int { return gcmSIVNonceSize }
+
+func (g *gcmSIV) Overhead() int { return gcmSIVTagSize }
+
+func (g *gcmSIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("chify: incorrect nonce length given to GCM-SIV")
+	}
+	if len(plaintext) > gcmSIVMaxSize || len(additionalData) > gcmSIVMaxSize {
+		panic("chify: message too large for GCM-SIV")
+	}
+
+	authKey, block := g.deriveKeys(nonce)
+	tag := gcmSIVTag(authKey, block, nonce, plaintext, additionalData)
+
+	ret := slices.Grow(dst, len(plaintext)+gcmSIVTagSize)[:len(dst)+len(plaintext)+gcmSIVTagSize]
+	out := ret[len(dst):]
+	gcmSIVCTR(block, tag, out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (g *gcmSIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("chify: incorrect nonce length given to GCM-SIV")
+	}
+	if len(ciphertext) < gcmSIVTagSize || len(ciphertext) > gcmSIVMaxSize+gcmSIVTagSize || len(additionalData) > gcmSIVMaxSize {
+		return nil, ErrAuthentication
+	}
+
+	authKey, block := g.deriveKeys(nonce)
+	tag := [gcmSIVTagSize]byte(ciphertext[len(ciphertext)-gcmSIVTagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-gcmSIVTagSize]
+
+	ret := slices.Grow(dst, len(ciphertext))[:len(dst)+len(ciphertext)]
+	plaintext := ret[len(dst):]
+	gcmSIVCTR(block, tag, plaintext, ciphertext)
+
+	expected := gcmSIVTag(authKey, block, nonce, plaintext, additionalData)
+	if subtle.ConstantTimeCompare(expected[:], tag[:]) != 1 {
+		clear(plaintext)
+		return nil, ErrAuthentication
+	}
+	return ret, nil
+}
+
+// deriveKeys encrypts counter | nonce blocks and keeps the first half of
+// each: two for the POLYVAL key, then two or four for the AES key.
+func (g *gcmSIV) deriveKeys(nonce []byte) (authKey [16]byte, block cipher.Block) {
+	var in, out [aes.BlockSize]byte
+	copy(in[4:], nonce)
+
+	derived := make([]byte, 0, 16+g.keySize)
+	for i := uint32(0); len(derived) < cap(derived); i++ {
+		binary.LittleEndian.PutUint32(in[:4], i)
+		g.key.Encrypt(out[:], in[:])
+		derived = append(derived, out[:8]...)
+	}
+
+	// the size was checked in NewGCMSIV
+	block, _ = aes.NewCipher(derived[16:])
+	return [16]byte(derived[:16]), block
+}
+
+func gcmSIVTag(authKey [16]byte, block cipher.Block, nonce, plaintext, additionalData []byte) [gcmSIVTagSize]byte {
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+
+	p := newPolyval(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+	p.update(lengths[:])
+	s := p.sum()
+
+	for i := range nonce {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+	block.Encrypt(s[:], s[:])
+	return s
+}
+
+// gcmSIVCTR is CTR with the tag as IV, its top bit set, and a 32 bit little
+// endian counter in the first four bytes.
+func gcmSIVCTR(block cipher.Block, tag [gcmSIVTagSize]byte, dst, src []byte) {
+	counter := tag
+	counter[15] |= 0x80
+
+	var keystream [aes.BlockSize]byte
+	for i := 0; i < len(src); i += aes.BlockSize {
+		block.Encrypt(keystream[:], counter[:])
+		binary.LittleEndian.PutUint32(counter[:4], binary.LittleEndian.Uint32(counter[:4])+1)
+
+		end := min(i+aes.BlockSize, len(src))
+		subtle.XORBytes(dst[i:end], src[i:end], keystream[:end-i])
+	}
+}
+
+// polyval computes POLYVAL through GHASH, as in RFC 8452 appendix A:
+// POLYVAL(H, X) = rev(GHASH(mulX(rev(H)), rev(X))), with rev reversing bytes.
+type polyval struct {
+	h, s gf128
+}
+
+// gf128 is an element of the GHASH field, bit 0 being the top bit of hi.
+type gf128 struct {
+	hi, lo uint64
+}
+
+func newPolyval(key [16]byte) *polyval {
+	h := reversedGF128(key[:])
+	h.mulX()
+	return &polyval{h: h}
+}
+
+// update absorbs data, zero padded to a multiple of 16 bytes.
+func (p *polyval) update(data []byte) {
+	for len(data) > 0 {
+		var block [16]byte
+		n := copy(block[:], data)
+		data = data[n:]
+
+		x := reversedGF128(block[:])
+		p.s.hi ^= x.hi
+		p.s.lo ^= x.lo
+		p.s = p.s.mul(p.h)
+	}
+}
+
+func (p *polyval) sum() [16]byte {
+	var out [16]byte
+	binary.LittleEndian.PutUint64(out[:8], p.s.lo)
+	binary.LittleEndian.PutUint64(out[8:], p.s.hi)
+	return out
+}
+
+func reversedGF128(b []byte) gf128 {
+	return gf128{hi: binary.LittleEndian.Uint64(b[8:]), lo: binary.LittleEndian.Uint64(b[:8])}
+}
+
+// mulX multiplies by x, which in the bit reflected GHASH order is a right shift.
+func (v *gf128) mulX() {
+	carry := -(v.lo & 1)
+	v.lo = v.lo>>1 | v.hi<<63
+	v.hi = v.hi>>1 ^ 0xe1<<56&carry
+}
+
+// mul is the bitwise GHASH multiplication of NIST SP 800-38D, without
+// branches on the data.
+func (x gf128) mul(y gf128) gf128 {
+	var z gf128
+	v := y
+	for i := range 128 {
+		var bit uint64
+		if i < 64 {
+			bit = x.hi >> (63 - i) & 1
+		} else {
+			bit = x.lo >> (127 - i) & 1
+		}
+		z.hi ^= v.hi & -bit
+		z.lo ^= v.lo & -bit
+		v.mulX()
+	}
+	return z
+}
+
+func encryptGCMSIV(key, data []byte) ([]byte, error) {
+	aead, err := NewGCMSIV(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcmSIVNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	if len(data) > gcmSIVMaxSize {
+		return nil, errors.New("message too large for GCM-SIV")
+	}
+
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+func decryptGCMSIV(key, data []byte) ([]byte, error) {
+	aead, err := NewGCMSIV(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcmSIVNonceSize {
+		return nil, ErrCiphertextTooShort
+	}
+
+	return aead.Open(nil, data[:gcmSIVNonceSize], data[gcmSIVNonceSize:], nil)
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// testdata/gcmsiv.json holds the Wycheproof AES-GCM-SIV vectors
+// (github.com/google/wycheproof, aes_gcm_siv_test.json). The ones marked
+// draft-irtf-cfrg-gcmsiv-09 are the RFC 8452 appendix C vectors, the invalid
+// ones have a flipped tag bit.
+type gcmSIVVector struct {
+	TcID    int    `json:"tcId"`
+	Comment string `json:"comment"`
+	Key     string `json:"key"`
+	IV      string `json:"iv"`
+	AAD     string `json:"aad"`
+	Msg     string `json:"msg"`
+	CT      string `json:"ct"`
+	Tag     string `json:"tag"`
+	Result  string `json:"result"`
+}
+
+func TestPolyval(t *testing.T) {
+	// RFC 8452 appendix A
+	var h [16]byte
+	copy(h[:], fromHex("25629347589242761d31f826ba4b757b"))
+	p := newPolyval(h)
+	p.update(fromHex("4f4f95668c83dfb6401762bb2d01a262 d1a24ddd2721d006bbe45f20d3c9f362"))
+
+	got := p.sum()
+	if want := fromHex("f7a3b47b846119fae5b7866cf5e5b77e"); !bytes.Equal(got[:], want) {
+		t.Errorf("POLYVAL = %x, want %x", got, want)
+	}
+}
+
+func TestGCMSIVVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/gcmsiv.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []gcmSIVVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range vectors {
+		aead, err := NewGCMSIV(fromHex(v.Key))
+		if err != nil {
+			t.Fatalf("#%d: %v", v.TcID, err)
+		}
+		nonce, aad, msg := fromHex(v.IV), fromHex(v.AAD), fromHex(v.Msg)
+		sealed := fromHex(v.CT + v.Tag)
+
+		got, err := aead.Open(nil, nonce, sealed, aad)
+		if v.Result == "invalid" {
+			if err == nil {
+				t.Errorf("#%d %s: opened", v.TcID, v.Comment)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d %s: %v", v.TcID, v.Comment, err)
+		} else if !bytes.Equal(got, msg) {
+			t.Errorf("#%d %s: opened %x, want %x", v.TcID, v.Comment, got, msg)
+		}
+
+		if got := aead.Seal(nil, nonce, msg, aad); !bytes.Equal(got, sealed) {
+			t.Errorf("#%d %s: sealed %x, want %x", v.TcID, v.Comment, got, sealed)
+		}
+	}
+}
+
+func TestGCMSIVRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{3}, 32)
+	encrypted, err := encryptGCMSIV(key, sunscreen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := decryptGCMSIV(key, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, sunscreen) {
+		t.Errorf("round trip gave %q", decrypted)
+	}
+
+	if _, err := decryptGCMSIV(key, encrypted[:gcmSIVNonceSize-1]); err == nil {
+		t.Error("short input was accepted")
+	}
+	if _, err := NewGCMSIV(key[:24]); err == nil {
+		t.Error("24 byte key was accepted")
+	}
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+)
+
+// ShaAlgorithms lists the SHA family digests in the order the SHA form shows them.
+var ShaAlgorithms = []string{"sha1", "sha224", "sha256", "sha3-224", "sha3-256", "sha3-384", "sha3-512", "sha512-224", "sha512-256", "sha384", "sha512"}
+
+// HashAlgorithms lists every digest supported by NewHash.
+var HashAlgorithms = append([]string{"md5"}, ShaAlgorithms...)
+
+var hashConstructors = map[string]func() hash.Hash{
+	"md5":        md5.New,
+	"sha1":       sha1.New,
+	"sha224":     sha256.New224,
+	"sha256":     sha256.New,
+	"sha3-224":   func() hash.Hash { return sha3.New224() },
+	"sha3-256":   func() hash.Hash { return sha3.New256() },
+	"sha3-384":   func() hash.Hash { return sha3.New384() },
+	"sha3-512":   func() hash.Hash { return sha3.New512() },
+	"sha512-224": sha512.New512_224,
+	"sha512-256": sha512.New512_256,
+	"sha384":     sha512.New384,
+	"sha512":     sha512.New,
+}
+
+// HashConstructor returns the constructor of the named digest.
+func HashConstructor(name string) (func() hash.Hash, error) {
+	newHash, ok := hashConstructors[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+	}
+	return newHash, nil
+}
+
+func NewHash(name string) (hash.Hash, error) {
+	newHash, err := HashConstructor(name)
+	if err != nil {
+		return nil, err
+	}
+	return newHash(), nil
+}
+
+// Hash returns the digest of data using the named algorithm.
+func Hash(name string, data []byte) ([]byte, error) {
+	h, err := NewHash(name)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+func HashOperation(name string) *Operation {
+	return &Operation{
+		Name:     name,
+		Category: "hash",
+		run: func(input []byte, opts Options) ([]byte, error) {
+			return Hash(name, input)
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// digests of "abc" from RFC 1321, FIPS 180-4 and FIPS 202
+var abcDigests = map[string]string{
+	"md5":        "900150983cd24fb0d6963f7d28e17f72",
+	"sha1":       "a9993e364706816aba3e25717850c26c9cd0d89d",
+	"sha224":     "23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7",
+	"sha256":     "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+	"sha3-224":   "e642824c3f8cf24ad09234ee7d3c766fc9a3a5168d0c94ad73b46fdf",
+	"sha3-256":   "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532",
+	"sha3-384":   "ec01498288516fc926459f58e2c6ad8df9b473cb0fc08c2596da7cf0e49be4b298d88cea927ac7f539f1edf228376d25",
+	"sha3-512":   "b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0",
+	"sha512-224": "4634270f707b6a54daae7530460842e20e37ed265ceee9a43e8924aa",
+	"sha512-256": "53048e2681941ef99b2e29b76b4c7dabe4c2d0c634fc6d46e0e2f13107e7af23",
+	"sha384":     "cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7",
+	"sha512":     "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+}
+
+func TestHash(t *testing.T) {
+	if len(abcDigests) != len(HashAlgorithms) {
+		t.Fatalf("%d vectors for %d algorithms", len(abcDigests), len(HashAlgorithms))
+	}
+
+	for _, name := range HashAlgorithms {
+		want := fromHex(abcDigests[name])
+		got, err := Hash(name, []byte("abc"))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s(abc) = %x, want %x", name, got, want)
+		}
+
+		got, err = HashOperation(name).Run([]byte("abc"), nil)
+		if err != nil {
+			t.Fatalf("%s operation: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s operation = %x, want %x", name, got, want)
+		}
+	}
+}
+
+func TestHashUnknown(t *testing.T) {
+	if _, err := Hash("sha0", nil); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("Hash(sha0) error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
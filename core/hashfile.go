@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// MultiHash computes several digests in a single pass over the data written to it.
+type MultiHash struct {
+	hashes []hash.Hash
+	io.Writer
+}
+
+func NewMultiHash(algorithms []string) (*MultiHash, error) {
+	m := &MultiHash{}
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, name := range algorithms {
+		h, err := NewHash(name)
+		if err != nil {
+			return nil, err
+		}
+		m.hashes = append(m.hashes, h)
+		writers = append(writers, h)
+	}
+	m.Writer = io.MultiWriter(writers...)
+	return m, nil
+}
+
+// Sums returns the digests in the order the algorithms were given.
+func (m *MultiHash) Sums() [][]byte {
+	sums := make([][]byte, len(m.hashes))
+	for i, h := range m.hashes {
+		sums[i] = h.Sum(nil)
+	}
+	return sums
+}
+
+// FileDigest is the result of hashing one file of a tree.
+type FileDigest struct {
+	Path    string   // slash separated, relative to the hashed root
+	Digests [][]byte // in the order of the requested algorithms
+	Err     error
+}
+
+// ListFiles returns the regular files under root (or root itself if it is a
+// file), relative to the directory they are reported against, and their total size.
+func ListFiles(ctx context.Context, root string) (base string, files []string, total int64, err error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	if !info.IsDir() {
+		return filepath.Dir(root), []string{filepath.Base(root)}, info.Size(), nil
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		total += info.Size()
+		return nil
+	})
+
+	return root, files, total, err
+}
+
+// HashFiles streams every file through all algorithms at once. result is
+// called once per file; a file that cannot be read is reported through
+// FileDigest.Err and does not stop the others. progress receives the total
+// number of bytes hashed so far.
+func HashFiles(ctx context.Context, algorithms []string, base string, files []string, progress func(int64), result func(FileDigest)) error {
+	var done int64
+	buf := make([]byte, 1024*1024)
+	for _, name := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m, err := NewMultiHash(algorithms)
+		if err != nil {
+			return err
+		}
+
+		err = hashFile(ctx, m, filepath.Join(base, filepath.FromSlash(name)), buf, &done, progress)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		digest := FileDigest{Path: name, Err: err}
+		if err == nil {
+			digest.Digests = m.Sums()
+		}
+		result(digest)
+	}
+
+	return nil
+}
+
+func hashFile(ctx context.Context, w io.Writer, path string, buf []byte, done *int64, progress func(int64)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := f.Read(buf)
+		if n > 0 {
+			w.Write(buf[:n])
+			*done += int64(n)
+			if progress != nil {
+				progress(*done)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestMultiHash(t *testing.T) {
+	algorithms := []string{"md5", "sha256", "sha3-512", "sha512-224"}
+	data := bytes.Repeat(sunscreen, 100)
+
+	m, err := NewMultiHash(algorithms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// written in uneven pieces, as hashFile does
+	for rest := data; len(rest) > 0; {
+		n := min(len(rest), 777)
+		m.Write(rest[:n])
+		rest = rest[n:]
+	}
+
+	sums := m.Sums()
+	for i, name := range algorithms {
+		want, err := Hash(name, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sums[i], want) {
+			t.Errorf("%s: %x, want %x", name, sums[i], want)
+		}
+	}
+
+	if _, err := NewMultiHash([]string{"sha256", "whirlpool"}); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("unknown algorithm error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"b.txt":     "bb",
+		"a/z.txt":   "z",
+		"a/y/x.txt": "xxx",
+		"c/empty":   "",
+	})
+	if err := os.Symlink("b.txt", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	base, files, total, err := ListFiles(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a/y/x.txt", "a/z.txt", "b.txt", "c/empty"}
+	if base != root || !reflect.DeepEqual(files, want) || total != 6 {
+		t.Errorf("ListFiles = %s, %q, %d, want %q and 6 bytes", base, files, total, want)
+	}
+
+	base, files, total, err = ListFiles(context.Background(), filepath.Join(root, "a", "z.txt"))
+	if err != nil || base != filepath.Join(root, "a") || !reflect.DeepEqual(files, []string{"z.txt"}) || total != 1 {
+		t.Errorf("single file: %s, %q, %d, %v", base, files, total, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, _, err := ListFiles(ctx, root); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled walk error = %v", err)
+	}
+	if _, _, _, err := ListFiles(context.Background(), filepath.Join(root, "gone")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing root error = %v", err)
+	}
+}
+
+func TestHashFiles(t *testing.T) {
+	contents := map[string]string{"a/one": "abc", "b/two": string(sunscreen), "c/three": ""}
+	root := writeTree(t, contents)
+	algorithms := []string{"sha1", "sha256"}
+
+	base, files, total, err := ListFiles(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, "gone")
+
+	var results []FileDigest
+	var done int64
+	err = HashFiles(context.Background(), algorithms, base, files, func(n int64) { done = n }, func(d FileDigest) {
+		results = append(results, d)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(files) || done != total {
+		t.Fatalf("%d results, %d of %d bytes", len(results), done, total)
+	}
+	for _, d := range results[:3] {
+		if d.Err != nil {
+			t.Fatalf("%s: %v", d.Path, d.Err)
+		}
+		for i, name := range algorithms {
+			want, _ := Hash(name, []byte(contents[d.Path]))
+			if !bytes.Equal(d.Digests[i], want) {
+				t.Errorf("%s %s: %x, want %x", d.Path, name, d.Digests[i], want)
+			}
+		}
+	}
+	if last := results[3]; last.Path != "gone" || !errors.Is(last.Err, os.ErrNotExist) || last.Digests != nil {
+		t.Errorf("missing file reported as %+v", last)
+	}
+
+	// canceling after the first file stops the run without more results
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results = nil
+	err = HashFiles(ctx, algorithms, base, files, nil, func(d FileDigest) {
+		results = append(results, d)
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) || len(results) != 1 {
+		t.Errorf("canceled run: %d results, error %v", len(results), err)
+	}
+}
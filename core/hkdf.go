@@ -0,0 +1,65 @@
+package core
+
+import (
+	"crypto/hkdf"
+	"fmt"
+)
+
+// HKDF (RFC 5869) is not meant for passwords: it turns a secret that already
+// has enough entropy, such as a shared key, into one or more keys.
+
+// HKDFModes lists the steps an HKDF operation can run.
+var HKDFModes = []string{"extract-expand", "extract", "expand"}
+
+// HKDFExtract returns the pseudorandom key for secret and salt.
+func HKDFExtract(hash string, secret, salt []byte) ([]byte, error) {
+	newHash, err := HashConstructor(hash)
+	if err != nil {
+		return nil, err
+	}
+	return hkdf.Extract(newHash, secret, salt)
+}
+
+// HKDFExpand stretches a pseudorandom key into length bytes bound to info.
+func HKDFExpand(hash string, prk, info []byte, length int) ([]byte, error) {
+	newHash, err := HashConstructor(hash)
+	if err != nil {
+		return nil, err
+	}
+	return hkdf.Expand(newHash, prk, string(info), length)
+}
+
+// HKDF runs extract and expand in one go.
+func HKDF(hash string, secret, salt, info []byte, length int) ([]byte, error) {
+	newHash, err := HashConstructor(hash)
+	if err != nil {
+		return nil, err
+	}
+	return hkdf.Key(newHash, secret, salt, string(info), length)
+}
+
+func HKDFOperation() *Operation {
+	return &Operation{
+		Name:     "hkdf",
+		Category: "kdf",
+		Params: []Param{
+			{Name: "mode", Kind: KindString, Choices: HKDFModes, Default: "extract-expand", Usage: "HKDF steps to run"},
+			{Name: "hash", Kind: KindString, Choices: HashAlgorithms, Default: "sha256", Usage: "HMAC digest"},
+			{Name: "salt", Kind: KindBytes, Usage: "extract salt"},
+			{Name: "info", Kind: KindBytes, Usage: "expand context and application info"},
+			{Name: "length", Kind: KindInt, Default: 32, Usage: "output length in bytes"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			hash := opts.String("hash")
+			switch opts.String("mode") {
+			case "extract":
+				return HKDFExtract(hash, input, opts.Bytes("salt"))
+			case "expand":
+				return HKDFExpand(hash, input, opts.Bytes("info"), opts.Int("length"))
+			case "extract-expand":
+				return HKDF(hash, input, opts.Bytes("salt"), opts.Bytes("info"), opts.Int("length"))
+			}
+			return nil, fmt.Errorf("%w: HKDF mode %q", ErrUnknownAlgorithm, opts.String("mode"))
+		},
+	}
+}
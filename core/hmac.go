@@ -0,0 +1,50 @@
+package core
+
+import (
+	"crypto/hmac"
+	"hash"
+)
+
+// HMACPrefix names the keyed variant of a digest operation, e.g. "hmac-sha256".
+const HMACPrefix = "hmac-"
+
+func NewHMAC(name string, key []byte) (hash.Hash, error) {
+	newHash, err := HashConstructor(name)
+	if err != nil {
+		return nil, err
+	}
+	return hmac.New(newHash, key), nil
+}
+
+// HMAC returns the tag of data keyed with key using the named digest.
+func HMAC(name string, key, data []byte) ([]byte, error) {
+	h, err := NewHMAC(name, key)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// VerifyHMAC reports whether tag is the HMAC of data. The comparison takes
+// constant time, so it leaks nothing about how much of the tag matched.
+func VerifyHMAC(name string, key, data, tag []byte) (bool, error) {
+	sum, err := HMAC(name, key, data)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal(sum, tag), nil
+}
+
+func HMACOperation(name string) *Operation {
+	return &Operation{
+		Name:     HMACPrefix + name,
+		Category: "hash",
+		Params: []Param{
+			{Name: "key", Kind: KindBytes, Usage: "HMAC key"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			return HMAC(name, opts.Bytes("key"), input)
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestHMAC(t *testing.T) {
+	// RFC 2202 and RFC 4231 test case 2
+	key, data := []byte("Jefe"), []byte("what do ya want for nothing?")
+	tests := map[string]string{
+		"md5":    "750c783e6ab0b503eaa86e310a5db738",
+		"sha1":   "effcdf6ae5eb2fa2d27416d5f184df9c259a7c79",
+		"sha224": "a30e01098bc6dbbf45690f3a7e9e6d0f8bbea2a39e6148008fd05e44",
+		"sha256": "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		"sha384": "af45d2e376484031617f78d2b58a6b1b9c7ef464f5a01b47e42ec3736322445e8e2240ca5e69e2c78b3239ecfab21649",
+		"sha512": "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+	}
+
+	for name, hexTag := range tests {
+		want := fromHex(hexTag)
+		got, err := HMAC(name, key, data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("HMAC-%s = %x, want %x", name, got, want)
+		}
+
+		got, err = HMACOperation(name).Run(data, Options{"key": key})
+		if err != nil {
+			t.Fatalf("%s operation: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s%s operation = %x, want %x", HMACPrefix, name, got, want)
+		}
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	key, data := []byte("Jefe"), []byte("what do ya want for nothing?")
+	tag := fromHex("5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843")
+
+	if ok, err := VerifyHMAC("sha256", key, data, tag); err != nil || !ok {
+		t.Errorf("VerifyHMAC = %v, %v, want true", ok, err)
+	}
+
+	tag[len(tag)-1] ^= 1
+	if ok, err := VerifyHMAC("sha256", key, data, tag); err != nil || ok {
+		t.Errorf("VerifyHMAC of a changed tag = %v, %v, want false", ok, err)
+	}
+	if ok, _ := VerifyHMAC("sha256", key, data, tag[:16]); ok {
+		t.Error("VerifyHMAC accepted a truncated tag")
+	}
+	if _, err := VerifyHMAC("sha0", key, data, tag); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("VerifyHMAC(sha0) error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
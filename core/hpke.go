@@ -0,0 +1,585 @@
+package core
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// HPKE (RFC 9180) in the Base and PSK modes. The ML-KEM KEMs follow
+// draft-ietf-hpke-pq: enc is the ML-KEM ciphertext, the shared secret is
+// used as is, and private keys are 64 byte seeds.
+
+type HPKEMode byte
+
+const (
+	HPKEModeBase HPKEMode = 0x00
+	HPKEModePSK  HPKEMode = 0x01
+)
+
+func (m HPKEMode) String() string {
+	if m == HPKEModePSK {
+		return "PSK"
+	}
+	return "Base"
+}
+
+func HPKEModes() []string {
+	return []string{HPKEModeBase.String(), HPKEModePSK.String()}
+}
+
+func ParseHPKEMode(s string) (HPKEMode, error) {
+	for _, m := range []HPKEMode{HPKEModeBase, HPKEModePSK} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: HPKE mode %q", ErrUnknownAlgorithm, s)
+}
+
+// HPKEKEM, HPKEKDF and HPKEAEAD are the identifiers of the IANA HPKE registry.
+type (
+	HPKEKEM  uint16
+	HPKEKDF  uint16
+	HPKEAEAD uint16
+)
+
+const (
+	HPKEKEMP256      HPKEKEM = 0x0010
+	HPKEKEMP384      HPKEKEM = 0x0011
+	HPKEKEMP521      HPKEKEM = 0x0012
+	HPKEKEMX25519    HPKEKEM = 0x0020
+	HPKEKEMMLKEM768  HPKEKEM = 0x0041
+	HPKEKEMMLKEM1024 HPKEKEM = 0x0042
+
+	HPKEKDFSHA256 HPKEKDF = 0x0001
+	HPKEKDFSHA384 HPKEKDF = 0x0002
+	HPKEKDFSHA512 HPKEKDF = 0x0003
+
+	HPKEAEADAES128GCM        HPKEAEAD = 0x0001
+	HPKEAEADAES256GCM        HPKEAEAD = 0x0002
+	HPKEAEADChaCha20Poly1305 HPKEAEAD = 0x0003
+	HPKEAEADExportOnly       HPKEAEAD = 0xFFFF
+)
+
+type hpkeKEMInfo struct {
+	name    string
+	curve   ecdh.Curve       // nil for ML-KEM
+	newHash func() hash.Hash // KDF of a DHKEM
+	nSk     int              // private key size for DeriveKeyPair
+	bitmask byte
+	mlkem   MLKEMParameterSet
+}
+
+var hpkeKEMs = []HPKEKEM{HPKEKEMX25519, HPKEKEMP256, HPKEKEMP384, HPKEKEMP521, HPKEKEMMLKEM768, HPKEKEMMLKEM1024}
+
+var hpkeKEMInfos = map[HPKEKEM]hpkeKEMInfo{
+	HPKEKEMP256:      {name: "DHKEM(P-256, HKDF-SHA256)", curve: ecdh.P256(), newHash: sha256.New, nSk: 32, bitmask: 0xff},
+	HPKEKEMP384:      {name: "DHKEM(P-384, HKDF-SHA384)", curve: ecdh.P384(), newHash: sha512.New384, nSk: 48, bitmask: 0xff},
+	HPKEKEMP521:      {name: "DHKEM(P-521, HKDF-SHA512)", curve: ecdh.P521(), newHash: sha512.New, nSk: 66, bitmask: 0x01},
+	HPKEKEMX25519:    {name: "DHKEM(X25519, HKDF-SHA256)", curve: ecdh.X25519(), newHash: sha256.New, nSk: 32},
+	HPKEKEMMLKEM768:  {name: "ML-KEM-768", mlkem: MLKEM768},
+	HPKEKEMMLKEM1024: {name: "ML-KEM-1024", mlkem: MLKEM1024},
+}
+
+var hpkeKDFs = []HPKEKDF{HPKEKDFSHA256, HPKEKDFSHA384, HPKEKDFSHA512}
+
+var hpkeKDFNames = map[HPKEKDF]string{
+	HPKEKDFSHA256: "HKDF-SHA256",
+	HPKEKDFSHA384: "HKDF-SHA384",
+	HPKEKDFSHA512: "HKDF-SHA512",
+}
+
+var hpkeAEADs = []HPKEAEAD{HPKEAEADAES128GCM, HPKEAEADAES256GCM, HPKEAEADChaCha20Poly1305, HPKEAEADExportOnly}
+
+var hpkeAEADNames = map[HPKEAEAD]string{
+	HPKEAEADAES128GCM:        "AES-128-GCM",
+	HPKEAEADAES256GCM:        "AES-256-GCM",
+	HPKEAEADChaCha20Poly1305: "ChaCha20-Poly1305",
+	HPKEAEADExportOnly:       "Export-only",
+}
+
+func (k HPKEKEM) String() string {
+	if info, ok := hpkeKEMInfos[k]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("KEM 0x%04x", uint16(k))
+}
+
+func (k HPKEKDF) String() string {
+	if name, ok := hpkeKDFNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("KDF 0x%04x", uint16(k))
+}
+
+func (a HPKEAEAD) String() string {
+	if name, ok := hpkeAEADNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("AEAD 0x%04x", uint16(a))
+}
+
+func HPKEKEMs() []string {
+	names := make([]string, len(hpkeKEMs))
+	for i, k := range hpkeKEMs {
+		names[i] = k.String()
+	}
+	return names
+}
+
+func HPKEKDFs() []string {
+	names := make([]string, len(hpkeKDFs))
+	for i, k := range hpkeKDFs {
+		names[i] = k.String()
+	}
+	return names
+}
+
+func HPKEAEADs() []string {
+	names := make([]string, len(hpkeAEADs))
+	for i, a := range hpkeAEADs {
+		names[i] = a.String()
+	}
+	return names
+}
+
+func ParseHPKEKEM(s string) (HPKEKEM, error) {
+	for _, k := range hpkeKEMs {
+		if k.String() == s {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: HPKE KEM %q", ErrUnknownAlgorithm, s)
+}
+
+func ParseHPKEKDF(s string) (HPKEKDF, error) {
+	for _, k := range hpkeKDFs {
+		if k.String() == s {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: HPKE KDF %q", ErrUnknownAlgorithm, s)
+}
+
+func ParseHPKEAEAD(s string) (HPKEAEAD, error) {
+	for _, a := range hpkeAEADs {
+		if a.String() == s {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: HPKE AEAD %q", ErrUnknownAlgorithm, s)
+}
+
+var (
+	ErrHPKEPSK        = errors.New("HPKE PSK mode needs both psk and psk_id, Base mode neither")
+	ErrHPKEExportOnly = errors.New("the Export-only AEAD cannot seal or open messages")
+)
+
+// HPKEParams select the cipher suite and mode. PSK and PSKID are only used
+// in PSK mode.
+type HPKEParams struct {
+	Mode  HPKEMode
+	KEM   HPKEKEM
+	KDF   HPKEKDF
+	AEAD  HPKEAEAD
+	Info  []byte
+	PSK   []byte
+	PSKID []byte
+}
+
+// HPKEContext is the encryption context of a sender or a recipient. Each
+// Seal or Open advances the sequence number, so messages must be opened in
+// the order they were sealed.
+type HPKEContext struct {
+	aead           cipher.AEAD // nil for Export-only
+	baseNonce      []byte
+	seq            uint64
+	exporterSecret []byte
+	suite          []byte
+	newHash        func() hash.Hash
+}
+
+// GenerateHPKEKey returns a new key pair for kem. ML-KEM private keys are in seed form.
+func GenerateHPKEKey(kem HPKEKEM) (privateKey, publicKey []byte, err error) {
+	info, ok := hpkeKEMInfos[kem]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnknownAlgorithm, kem)
+	}
+	if info.curve == nil {
+		return GenerateMLKEMKey(info.mlkem)
+	}
+	key, err := info.curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key.Bytes(), key.PublicKey().Bytes(), nil
+}
+
+// HPKEPublicKey returns the serialized public key of privateKey.
+func HPKEPublicKey(kem HPKEKEM, privateKey []byte) ([]byte, error) {
+	info, ok := hpkeKEMInfos[kem]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownAlgorithm, kem)
+	}
+	if info.curve == nil {
+		return MLKEMPublicKey(info.mlkem, privateKey)
+	}
+	key, err := info.curve.NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicKey().Bytes(), nil
+}
+
+// NewHPKESender sets up the context to encrypt to publicKey and returns it
+// with enc, the encapsulated key the recipient needs.
+func NewHPKESender(p HPKEParams, publicKey []byte) (enc []byte, ctx *HPKEContext, err error) {
+	return newHPKESender(p, publicKey, nil)
+}
+
+// newHPKESender takes the ephemeral key from ikm when set, for test vectors.
+func newHPKESender(p HPKEParams, publicKey, ikm []byte) (enc []byte, ctx *HPKEContext, err error) {
+	info, ok := hpkeKEMInfos[p.KEM]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnknownAlgorithm, p.KEM)
+	}
+
+	var sharedSecret []byte
+	if info.curve == nil {
+		if sharedSecret, enc, err = MLKEMEncapsulate(info.mlkem, publicKey); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		peer, err := info.curve.NewPublicKey(publicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		var ephemeral *ecdh.PrivateKey
+		if ikm != nil {
+			ephemeral, err = hpkeDeriveKeyPair(p.KEM, ikm)
+		} else {
+			ephemeral, err = info.curve.GenerateKey(rand.Reader)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		dh, err := ephemeral.ECDH(peer)
+		if err != nil {
+			return nil, nil, err
+		}
+		enc = ephemeral.PublicKey().Bytes()
+		sharedSecret = hpkeExtractAndExpand(p.KEM, dh, append(enc[:len(enc):len(enc)], publicKey...))
+	}
+
+	ctx, err = hpkeKeySchedule(p, sharedSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ctx, nil
+}
+
+// NewHPKERecipient sets up the context to decrypt what a sender encrypted with enc.
+func NewHPKERecipient(p HPKEParams, privateKey, enc []byte) (*HPKEContext, error) {
+	info, ok := hpkeKEMInfos[p.KEM]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownAlgorithm, p.KEM)
+	}
+
+	var sharedSecret []byte
+	if info.curve == nil {
+		var err error
+		if sharedSecret, err = MLKEMDecapsulate(info.mlkem, privateKey, enc); err != nil {
+			return nil, err
+		}
+	} else {
+		key, err := info.curve.NewPrivateKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		ephemeral, err := info.curve.NewPublicKey(enc)
+		if err != nil {
+			return nil, err
+		}
+		dh, err := key.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+		sharedSecret = hpkeExtractAndExpand(p.KEM, dh, append(enc[:len(enc):len(enc)], key.PublicKey().Bytes()...))
+	}
+
+	return hpkeKeySchedule(p, sharedSecret)
+}
+
+func (c *HPKEContext) Seal(aad, plaintext []byte) ([]byte, error) {
+	nonce, err := c.nonce()
+	if err != nil {
+		return nil, err
+	}
+	c.seq++
+	return c.aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// Open only moves on to the next sequence number if ciphertext was authentic.
+func (c *HPKEContext) Open(aad, ciphertext []byte) ([]byte, error) {
+	nonce, err := c.nonce()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	c.seq++
+	return plaintext, nil
+}
+
+// Export derives a secret of length bytes bound to exporterContext, the same
+// on both sides.
+func (c *HPKEContext) Export(exporterContext []byte, length int) ([]byte, error) {
+	if length < 0 || length > 255*c.newHash().Size() {
+		return nil, fmt.Errorf("invalid HPKE export length %d", length)
+	}
+	return hpkeLabeledExpand(c.newHash, c.suite, c.exporterSecret, "sec", exporterContext, length)
+}
+
+func (c *HPKEContext) nonce() ([]byte, error) {
+	if c.aead == nil {
+		return nil, ErrHPKEExportOnly
+	}
+	if c.seq == math.MaxUint64 {
+		return nil, errors.New("HPKE message limit reached")
+	}
+	nonce := make([]byte, len(c.baseNonce))
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], c.seq)
+	for i := range nonce {
+		nonce[i] ^= c.baseNonce[i]
+	}
+	return nonce, nil
+}
+
+func hpkeKeySchedule(p HPKEParams, sharedSecret []byte) (*HPKEContext, error) {
+	gotPSK, gotPSKID := len(p.PSK) > 0, len(p.PSKID) > 0
+	if gotPSK != gotPSKID || gotPSK != (p.Mode == HPKEModePSK) {
+		return nil, ErrHPKEPSK
+	}
+	if p.Mode != HPKEModeBase && p.Mode != HPKEModePSK {
+		return nil, fmt.Errorf("%w: HPKE mode %d", ErrUnknownAlgorithm, p.Mode)
+	}
+
+	var newHash func() hash.Hash
+	switch p.KDF {
+	case HPKEKDFSHA256:
+		newHash = sha256.New
+	case HPKEKDFSHA384:
+		newHash = sha512.New384
+	case HPKEKDFSHA512:
+		newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnknownAlgorithm, p.KDF)
+	}
+
+	var keySize int
+	switch p.AEAD {
+	case HPKEAEADAES128GCM:
+		keySize = 16
+	case HPKEAEADAES256GCM, HPKEAEADChaCha20Poly1305:
+		keySize = 32
+	case HPKEAEADExportOnly:
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnknownAlgorithm, p.AEAD)
+	}
+
+	suite := []byte("HPKE")
+	suite = binary.BigEndian.AppendUint16(suite, uint16(p.KEM))
+	suite = binary.BigEndian.AppendUint16(suite, uint16(p.KDF))
+	suite = binary.BigEndian.AppendUint16(suite, uint16(p.AEAD))
+
+	pskIDHash := hpkeLabeledExtract(newHash, suite, nil, "psk_id_hash", p.PSKID)
+	infoHash := hpkeLabeledExtract(newHash, suite, nil, "info_hash", p.Info)
+	context := append([]byte{byte(p.Mode)}, pskIDHash...)
+	context = append(context, infoHash...)
+
+	secret := hpkeLabeledExtract(newHash, suite, sharedSecret, "secret", p.PSK)
+	exporterSecret, err := hpkeLabeledExpand(newHash, suite, secret, "exp", context, newHash().Size())
+	if err != nil {
+		return nil, err
+	}
+	ctx := &HPKEContext{exporterSecret: exporterSecret, suite: suite, newHash: newHash}
+	if p.AEAD == HPKEAEADExportOnly {
+		return ctx, nil
+	}
+
+	key, err := hpkeLabeledExpand(newHash, suite, secret, "key", context, keySize)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.baseNonce, err = hpkeLabeledExpand(newHash, suite, secret, "base_nonce", context, 12); err != nil {
+		return nil, err
+	}
+	if p.AEAD == HPKEAEADChaCha20Poly1305 {
+		ctx.aead, err = chacha20poly1305.New(key)
+	} else {
+		ctx.aead, err = newAESGCM(key, 12)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+func hpkeKEMSuite(kem HPKEKEM) []byte {
+	return binary.BigEndian.AppendUint16([]byte("KEM"), uint16(kem))
+}
+
+func hpkeExtractAndExpand(kem HPKEKEM, dh, kemContext []byte) []byte {
+	info := hpkeKEMInfos[kem]
+	suite := hpkeKEMSuite(kem)
+	prk := hpkeLabeledExtract(info.newHash, suite, nil, "eae_prk", dh)
+	sharedSecret, _ := hpkeLabeledExpand(info.newHash, suite, prk, "shared_secret", kemContext, info.newHash().Size())
+	return sharedSecret
+}
+
+// hpkeDeriveKeyPair is DeriveKeyPair of the DHKEMs.
+func hpkeDeriveKeyPair(kem HPKEKEM, ikm []byte) (*ecdh.PrivateKey, error) {
+	info := hpkeKEMInfos[kem]
+	suite := hpkeKEMSuite(kem)
+	prk := hpkeLabeledExtract(info.newHash, suite, nil, "dkp_prk", ikm)
+
+	if kem == HPKEKEMX25519 {
+		sk, err := hpkeLabeledExpand(info.newHash, suite, prk, "sk", nil, info.nSk)
+		if err != nil {
+			return nil, err
+		}
+		return info.curve.NewPrivateKey(sk)
+	}
+
+	// NIST curves: retry until the candidate is a valid scalar
+	for counter := 0; counter < 256; counter++ {
+		sk, err := hpkeLabeledExpand(info.newHash, suite, prk, "candidate", []byte{byte(counter)}, info.nSk)
+		if err != nil {
+			return nil, err
+		}
+		sk[0] &= info.bitmask
+		if key, err := info.curve.NewPrivateKey(sk); err == nil {
+			return key, nil
+		}
+	}
+	return nil, errors.New("HPKE DeriveKeyPair failed")
+}
+
+func hpkeLabeledExtract(newHash func() hash.Hash, suite, salt []byte, label string, ikm []byte) []byte {
+	labeled := append([]byte("HPKE-v1"), suite...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	prk, _ := hkdf.Extract(newHash, labeled, salt)
+	return prk
+}
+
+func hpkeLabeledExpand(newHash func() hash.Hash, suite, prk []byte, label string, info []byte, length int) ([]byte, error) {
+	labeled := binary.BigEndian.AppendUint16(nil, uint16(length))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, suite...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+	return hkdf.Expand(newHash, prk, string(labeled), length)
+}
+
+// HPKESeal encrypts a single message (single-shot API of RFC 9180).
+func HPKESeal(p HPKEParams, publicKey, aad, plaintext []byte) (enc, ciphertext []byte, err error) {
+	enc, ctx, err := NewHPKESender(p, publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err = ctx.Seal(aad, plaintext)
+	return enc, ciphertext, err
+}
+
+// HPKEOpen decrypts a message from HPKESeal.
+func HPKEOpen(p HPKEParams, privateKey, enc, aad, ciphertext []byte) ([]byte, error) {
+	ctx, err := NewHPKERecipient(p, privateKey, enc)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Open(aad, ciphertext)
+}
+
+// HPKEEncSize is the size of enc for kem, which the command line puts in
+// front of the ciphertext.
+func HPKEEncSize(kem HPKEKEM) int {
+	switch kem {
+	case HPKEKEMP256:
+		return 65
+	case HPKEKEMP384:
+		return 97
+	case HPKEKEMP521:
+		return 133
+	case HPKEKEMX25519:
+		return 32
+	case HPKEKEMMLKEM768:
+		return MLKEM768.ciphertextSize()
+	case HPKEKEMMLKEM1024:
+		return MLKEM1024.ciphertextSize()
+	}
+	return 0
+}
+
+func HPKEOperation() *Operation {
+	return &Operation{
+		Name:     "hpke",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "mode", Kind: KindString, Choices: HPKEModes(), Default: "Base", Usage: "HPKE mode"},
+			{Name: "kem", Kind: KindString, Choices: HPKEKEMs(), Default: HPKEKEMX25519.String(), Usage: "key encapsulation"},
+			{Name: "kdf", Kind: KindString, Choices: HPKEKDFs(), Default: HPKEKDFSHA256.String(), Usage: "key schedule KDF"},
+			{Name: "aead", Kind: KindString, Choices: HPKEAEADs()[:3], Default: HPKEAEADAES128GCM.String(), Usage: "message cipher"},
+			{Name: "public-key", Kind: KindBytes, Usage: "recipient public key, to seal"},
+			{Name: "private-key", Kind: KindBytes, Usage: "recipient private key, to open"},
+			{Name: "info", Kind: KindBytes, Usage: "application info bound to the key schedule"},
+			{Name: "aad", Kind: KindBytes, Usage: "associated data"},
+			{Name: "psk", Kind: KindBytes, Usage: "pre-shared key, PSK mode"},
+			{Name: "psk-id", Kind: KindBytes, Usage: "pre-shared key identifier, PSK mode"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "open enc | ciphertext instead of sealing"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			var p HPKEParams
+			var err error
+			if p.Mode, err = ParseHPKEMode(opts.String("mode")); err != nil {
+				return nil, err
+			}
+			if p.KEM, err = ParseHPKEKEM(opts.String("kem")); err != nil {
+				return nil, err
+			}
+			if p.KDF, err = ParseHPKEKDF(opts.String("kdf")); err != nil {
+				return nil, err
+			}
+			if p.AEAD, err = ParseHPKEAEAD(opts.String("aead")); err != nil {
+				return nil, err
+			}
+			p.Info, p.PSK, p.PSKID = opts.Bytes("info"), opts.Bytes("psk"), opts.Bytes("psk-id")
+
+			if opts.Bool(Reverse) {
+				size := HPKEEncSize(p.KEM)
+				if len(input) < size {
+					return nil, ErrCiphertextTooShort
+				}
+				return HPKEOpen(p, opts.Bytes("private-key"), input[:size], opts.Bytes("aad"), input[size:])
+			}
+			enc, ciphertext, err := HPKESeal(p, opts.Bytes("public-key"), opts.Bytes("aad"), input)
+			if err != nil {
+				return nil, err
+			}
+			return append(enc, ciphertext...), nil
+		},
+	}
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// testdata/hpke.json holds the RFC 9180 appendix A vectors for the Base and
+// PSK modes of the supported suites (from github.com/cloudflare/circl, with
+// the encryptions cut to sequence numbers 0, 1, 2 and 256), and the
+// draft-ietf-hpke-pq vectors for ML-KEM-768 and ML-KEM-1024 (from the Go
+// standard library).
+type hpkeVector struct {
+	Mode           HPKEMode `json:"mode"`
+	KEM            HPKEKEM  `json:"kem_id"`
+	KDF            HPKEKDF  `json:"kdf_id"`
+	AEAD           HPKEAEAD `json:"aead_id"`
+	Info           string   `json:"info"`
+	PSK            string   `json:"psk"`
+	PSKID          string   `json:"psk_id"`
+	IKME           string   `json:"ikmE"`
+	IKMR           string   `json:"ikmR"`
+	SKRm           string   `json:"skRm"`
+	PKRm           string   `json:"pkRm"`
+	Enc            string   `json:"enc"`
+	SharedSecret   string   `json:"shared_secret"`
+	BaseNonce      string   `json:"base_nonce"`
+	ExporterSecret string   `json:"exporter_secret"`
+	Encryptions    []struct {
+		Seq   uint64 `json:"seq"`
+		AAD   string `json:"aad"`
+		PT    string `json:"pt"`
+		Nonce string `json:"nonce"`
+		CT    string `json:"ct"`
+	} `json:"encryptions"`
+	Exports []struct {
+		Context string `json:"exporter_context"`
+		L       int    `json:"L"`
+		Value   string `json:"exported_value"`
+	} `json:"exports"`
+}
+
+func (v *hpkeVector) params() HPKEParams {
+	return HPKEParams{
+		Mode:  v.Mode,
+		KEM:   v.KEM,
+		KDF:   v.KDF,
+		AEAD:  v.AEAD,
+		Info:  fromHex(v.Info),
+		PSK:   fromHex(v.PSK),
+		PSKID: fromHex(v.PSKID),
+	}
+}
+
+func TestHPKEVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/hpke.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []hpkeVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range vectors {
+		name := fmt.Sprintf("%v/%v/%v/%v", v.Mode, v.KEM, v.KDF, v.AEAD)
+		t.Run(name, func(t *testing.T) {
+			p := v.params()
+			skRm, pkRm, enc := fromHex(v.SKRm), fromHex(v.PKRm), fromHex(v.Enc)
+
+			if pk, err := HPKEPublicKey(v.KEM, skRm); err != nil || !bytes.Equal(pk, pkRm) {
+				t.Errorf("public key = %x, %v, want %x", pk, err, pkRm)
+			}
+
+			var contexts []*HPKEContext
+			if hpkeKEMInfos[v.KEM].curve != nil {
+				// DHKEM: both key pairs come from DeriveKeyPair, so the sender is deterministic
+				skR, err := hpkeDeriveKeyPair(v.KEM, fromHex(v.IKMR))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(skR.Bytes(), skRm) {
+					t.Errorf("derived skR = %x, want %x", skR.Bytes(), skRm)
+				}
+
+				gotEnc, sender, err := newHPKESender(p, pkRm, fromHex(v.IKME))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(gotEnc, enc) {
+					t.Errorf("enc = %x, want %x", gotEnc, enc)
+				}
+
+				pkE, err := hpkeKEMInfos[v.KEM].curve.NewPublicKey(enc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				dh, err := skR.ECDH(pkE)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := hpkeExtractAndExpand(v.KEM, dh, append(bytes.Clone(enc), pkRm...)); !bytes.Equal(got, fromHex(v.SharedSecret)) {
+					t.Errorf("shared secret = %x, want %s", got, v.SharedSecret)
+				}
+				contexts = append(contexts, sender)
+			}
+
+			recipient, err := NewHPKERecipient(p, skRm, enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contexts = append(contexts, recipient)
+
+			for i, ctx := range contexts {
+				side := [...]string{"sender", "recipient"}[i+2-len(contexts)]
+				if !bytes.Equal(ctx.baseNonce, fromHex(v.BaseNonce)) {
+					t.Errorf("%s base nonce = %x, want %s", side, ctx.baseNonce, v.BaseNonce)
+				}
+				if !bytes.Equal(ctx.exporterSecret, fromHex(v.ExporterSecret)) {
+					t.Errorf("%s exporter secret = %x, want %s", side, ctx.exporterSecret, v.ExporterSecret)
+				}
+
+				for _, e := range v.Encryptions {
+					ctx.seq = e.Seq
+					aad, pt, ct := fromHex(e.AAD), fromHex(e.PT), fromHex(e.CT)
+					if nonce, _ := ctx.nonce(); !bytes.Equal(nonce, fromHex(e.Nonce)) {
+						t.Errorf("%s nonce %d = %x, want %s", side, e.Seq, nonce, e.Nonce)
+					}
+
+					var got []byte
+					var err error
+					if side == "sender" {
+						got, err = ctx.Seal(aad, pt)
+						if err == nil && !bytes.Equal(got, ct) {
+							t.Errorf("sealed %d = %x, want %s", e.Seq, got, e.CT)
+						}
+					} else {
+						got, err = ctx.Open(aad, ct)
+						if err == nil && !bytes.Equal(got, pt) {
+							t.Errorf("opened %d = %x, want %s", e.Seq, got, e.PT)
+						}
+					}
+					if err != nil {
+						t.Errorf("%s %d: %v", side, e.Seq, err)
+					}
+					if ctx.seq != e.Seq+1 {
+						t.Errorf("%s sequence number %d after %d", side, ctx.seq, e.Seq)
+					}
+				}
+
+				for _, e := range v.Exports {
+					got, err := ctx.Export(fromHex(e.Context), e.L)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.Equal(got, fromHex(e.Value)) {
+						t.Errorf("%s export %q = %x, want %s", side, e.Context, got, e.Value)
+					}
+				}
+			}
+
+			if v.AEAD == HPKEAEADExportOnly {
+				if _, err := recipient.Seal(nil, nil); !errors.Is(err, ErrHPKEExportOnly) {
+					t.Errorf("Export-only seal error = %v, want ErrHPKEExportOnly", err)
+				}
+			}
+		})
+	}
+}
+
+func TestHPKERoundTrip(t *testing.T) {
+	for _, kem := range hpkeKEMs {
+		privateKey, publicKey, err := GenerateHPKEKey(kem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, mode := range []HPKEMode{HPKEModeBase, HPKEModePSK} {
+			p := HPKEParams{Mode: mode, KEM: kem, KDF: HPKEKDFSHA384, AEAD: HPKEAEADChaCha20Poly1305, Info: []byte("chify")}
+			if mode == HPKEModePSK {
+				p.PSK, p.PSKID = bytes.Repeat([]byte{1}, 32), []byte("id")
+			}
+
+			enc, ciphertext, err := HPKESeal(p, publicKey, []byte("aad"), sunscreen)
+			if err != nil {
+				t.Fatalf("%v %v: %v", kem, mode, err)
+			}
+			if len(enc) != HPKEEncSize(kem) {
+				t.Errorf("%v: enc is %d bytes, HPKEEncSize says %d", kem, len(enc), HPKEEncSize(kem))
+			}
+			got, err := HPKEOpen(p, privateKey, enc, []byte("aad"), ciphertext)
+			if err != nil {
+				t.Fatalf("%v %v open: %v", kem, mode, err)
+			}
+			if !bytes.Equal(got, sunscreen) {
+				t.Errorf("%v %v: round trip gave %q", kem, mode, got)
+			}
+			if _, err := HPKEOpen(p, privateKey, enc, []byte("AAD"), ciphertext); !errors.Is(err, ErrAuthentication) {
+				t.Errorf("%v %v: changed aad error = %v, want ErrAuthentication", kem, mode, err)
+			}
+		}
+	}
+
+	_, publicKey, err := GenerateHPKEKey(HPKEKEMX25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []HPKEParams{
+		{Mode: HPKEModePSK, KEM: HPKEKEMX25519, KDF: HPKEKDFSHA256, AEAD: HPKEAEADAES128GCM},
+		{Mode: HPKEModeBase, KEM: HPKEKEMX25519, KDF: HPKEKDFSHA256, AEAD: HPKEAEADAES128GCM, PSK: []byte("psk"), PSKID: []byte("id")},
+		{Mode: HPKEModePSK, KEM: HPKEKEMX25519, KDF: HPKEKDFSHA256, AEAD: HPKEAEADAES128GCM, PSK: []byte("psk")},
+	} {
+		if _, _, err := HPKESeal(p, publicKey, nil, nil); !errors.Is(err, ErrHPKEPSK) {
+			t.Errorf("%v with psk %q, psk_id %q: error = %v, want ErrHPKEPSK", p.Mode, p.PSK, p.PSKID, err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"crypto/pbkdf2"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF names a password based key derivation function.
+type KDF byte
+
+const (
+	KDFPBKDF2 KDF = iota + 1 // PBKDF2-HMAC, SHA-256 unless KDFParams.Hash says otherwise
+	KDFScrypt
+	KDFArgon2id
+	KDFArgon2i
+)
+
+var kdfNames = map[KDF]string{
+	KDFPBKDF2:   "PBKDF2",
+	KDFScrypt:   "scrypt",
+	KDFArgon2id: "Argon2id",
+	KDFArgon2i:  "Argon2i",
+}
+
+func (k KDF) String() string {
+	if name, ok := kdfNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("KDF(%d)", byte(k))
+}
+
+// PassphraseKDFs lists the names accepted by ParseKDF, in menu order.
+func PassphraseKDFs() []string {
+	return []string{KDFArgon2id.String(), KDFScrypt.String(), KDFPBKDF2.String()}
+}
+
+func ParseKDF(s string) (KDF, error) {
+	for k, name := range kdfNames {
+		if name == s {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: KDF %q", ErrUnknownAlgorithm, s)
+}
+
+// KDFParams holds a KDF with its cost parameters and salt. Which fields are
+// used depends on the KDF.
+type KDFParams struct {
+	KDF        KDF
+	Hash       string // PBKDF2 digest, sha256 if empty
+	Iterations uint32 // PBKDF2 iterations, Argon2 passes
+	Memory     uint32 // Argon2 memory in KiB
+	Threads    uint8  // Argon2 parallelism
+	LogN       uint8  // scrypt cost N = 2^LogN
+	R, P       uint32 // scrypt block size and parallelism
+	Salt       []byte
+}
+
+var ErrKDFParams = errors.New("invalid KDF parameters")
+
+const maxKDFOutput = 1 << 16
+
+// DefaultKDFParams returns the OWASP recommended costs for kdf, without a salt.
+func DefaultKDFParams(kdf KDF) KDFParams {
+	switch kdf {
+	case KDFPBKDF2:
+		return KDFParams{KDF: kdf, Iterations: 600000}
+	case KDFScrypt:
+		return KDFParams{KDF: kdf, LogN: 17, R: 8, P: 1}
+	case KDFArgon2i:
+		return KDFParams{KDF: kdf, Iterations: 2, Memory: 19 * 1024, Threads: 1}
+	default:
+		return KDFParams{KDF: KDFArgon2id, Iterations: 2, Memory: 19 * 1024, Threads: 1}
+	}
+}
+
+// Validate rejects parameters that are unusable or so expensive that a
+// crafted header could stall the application.
+func (p KDFParams) Validate() error {
+	switch p.KDF {
+	case KDFPBKDF2:
+		if _, err := HashConstructor(p.hash()); err != nil {
+			return err
+		}
+		if p.Iterations == 0 || p.Iterations > 100_000_000 {
+			return fmt.Errorf("%w: PBKDF2 iterations %d", ErrKDFParams, p.Iterations)
+		}
+	case KDFScrypt:
+		if p.LogN == 0 || p.LogN > 24 || p.R == 0 || p.P == 0 || uint64(p.R)*uint64(p.P) >= 1<<30 ||
+			uint64(128)*uint64(p.R)<<p.LogN > 4<<30 {
+			return fmt.Errorf("%w: scrypt N=2^%d r=%d p=%d", ErrKDFParams, p.LogN, p.R, p.P)
+		}
+	case KDFArgon2id, KDFArgon2i:
+		if p.Iterations == 0 || p.Iterations > 1000 || p.Threads == 0 || p.Memory < 8*uint32(p.Threads) || p.Memory > 4*1024*1024 {
+			return fmt.Errorf("%w: Argon2 t=%d m=%d p=%d", ErrKDFParams, p.Iterations, p.Memory, p.Threads)
+		}
+	default:
+		return fmt.Errorf("%w: %v", ErrUnknownAlgorithm, p.KDF)
+	}
+	return nil
+}
+
+// DeriveKey stretches passphrase into a key of the given size.
+func (p KDFParams) DeriveKey(passphrase []byte, size int) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if size <= 0 || size > maxKDFOutput {
+		return nil, fmt.Errorf("%w: output length %d", ErrKDFParams, size)
+	}
+
+	switch p.KDF {
+	case KDFPBKDF2:
+		newHash, _ := HashConstructor(p.hash())
+		return pbkdf2.Key(newHash, string(passphrase), p.Salt, int(p.Iterations), size)
+	case KDFScrypt:
+		return scrypt.Key(passphrase, p.Salt, 1<<p.LogN, int(p.R), int(p.P), size)
+	case KDFArgon2i:
+		return argon2.Key(passphrase, p.Salt, p.Iterations, p.Memory, p.Threads, uint32(size)), nil
+	default:
+		return argon2.IDKey(passphrase, p.Salt, p.Iterations, p.Memory, p.Threads, uint32(size)), nil
+	}
+}
+
+func (p KDFParams) hash() string {
+	if p.Hash == "" {
+		return "sha256"
+	}
+	return p.Hash
+}
+
+// Calibrate scales the time cost of p (PBKDF2 iterations, scrypt N, Argon2
+// passes) until one derivation takes about target on this machine. Memory
+// and parallelism are kept as given.
+func Calibrate(p KDFParams, target time.Duration) (KDFParams, error) {
+	if err := p.Validate(); err != nil {
+		return p, err
+	}
+
+	salt := make([]byte, passphraseSaltSize)
+	for range 8 {
+		start := time.Now()
+		if _, err := p.withSalt(salt).DeriveKey([]byte("calibrate"), 32); err != nil {
+			return p, err
+		}
+		elapsed := max(time.Since(start), time.Microsecond)
+
+		factor := min(float64(target)/float64(elapsed), 1000)
+		if factor > 0.8 && factor < 1.25 {
+			break
+		}
+
+		next := p
+		switch p.KDF {
+		case KDFPBKDF2:
+			next.Iterations = scaleCost(p.Iterations, factor, 100_000_000)
+		case KDFScrypt:
+			next.LogN = uint8(min(max(math.Round(float64(p.LogN)+math.Log2(factor)), 1), 24))
+		default:
+			next.Iterations = scaleCost(p.Iterations, factor, 1000)
+		}
+		if next.Validate() != nil || (next.Iterations == p.Iterations && next.LogN == p.LogN) {
+			break
+		}
+		p = next
+	}
+
+	return p, nil
+}
+
+func scaleCost(cost uint32, factor float64, limit uint32) uint32 {
+	return uint32(min(max(math.Round(float64(cost)*factor), 1), float64(limit)))
+}
+
+func (p KDFParams) withSalt(salt []byte) KDFParams {
+	if len(p.Salt) == 0 {
+		p.Salt = salt
+	}
+	return p
+}
+
+// kdfIntLimits bound the int options of KDFOperation by the KDFParams field
+// they are stored in, so out of range values fail instead of wrapping.
+var kdfIntLimits = []struct {
+	name  string
+	limit uint64
+}{
+	{"iterations", math.MaxUint32},
+	{"passes", math.MaxUint32},
+	{"memory", math.MaxUint32},
+	{"threads", math.MaxUint8},
+	{"logn", math.MaxUint8},
+	{"r", math.MaxUint32},
+	{"p", math.MaxUint32},
+}
+
+// KDFOperation derives length bytes from the input with a password based KDF.
+func KDFOperation(kdf KDF) *Operation {
+	defaults := DefaultKDFParams(kdf)
+	params := []Param{
+		{Name: "salt", Kind: KindBytes, Usage: "salt"},
+		{Name: "length", Kind: KindInt, Default: 32, Usage: "output length in bytes"},
+	}
+	switch kdf {
+	case KDFPBKDF2:
+		params = append(params,
+			Param{Name: "hash", Kind: KindString, Choices: HashAlgorithms, Default: "sha256", Usage: "HMAC digest"},
+			Param{Name: "iterations", Kind: KindInt, Default: int(defaults.Iterations), Usage: "iteration count"},
+		)
+	case KDFScrypt:
+		params = append(params,
+			Param{Name: "logn", Kind: KindInt, Default: int(defaults.LogN), Usage: "CPU/memory cost, N = 2^logn"},
+			Param{Name: "r", Kind: KindInt, Default: int(defaults.R), Usage: "block size"},
+			Param{Name: "p", Kind: KindInt, Default: int(defaults.P), Usage: "parallelism"},
+		)
+	default:
+		params = append(params,
+			Param{Name: "passes", Kind: KindInt, Default: int(defaults.Iterations), Usage: "number of passes over memory"},
+			Param{Name: "memory", Kind: KindInt, Default: int(defaults.Memory), Usage: "memory in KiB"},
+			Param{Name: "threads", Kind: KindInt, Default: int(defaults.Threads), Usage: "parallelism"},
+		)
+	}
+
+	return &Operation{
+		Name:     strings.ToLower(kdf.String()),
+		Category: "kdf",
+		Params:   params,
+		run: func(input []byte, opts Options) ([]byte, error) {
+			for _, l := range kdfIntLimits {
+				if v := opts.Int(l.name); v < 0 || uint64(v) > l.limit {
+					return nil, fmt.Errorf("%w: %s %d out of range", ErrKDFParams, l.name, v)
+				}
+			}
+			p := KDFParams{
+				KDF:        kdf,
+				Hash:       opts.String("hash"),
+				Iterations: uint32(max(opts.Int("iterations"), opts.Int("passes"))),
+				Memory:     uint32(opts.Int("memory")),
+				Threads:    uint8(opts.Int("threads")),
+				LogN:       uint8(opts.Int("logn")),
+				R:          uint32(opts.Int("r")),
+				P:          uint32(opts.Int("p")),
+				Salt:       opts.Bytes("salt"),
+			}
+			return p.DeriveKey(input, opts.Int("length"))
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestKDFOperation(t *testing.T) {
+	tests := []struct {
+		kdf      KDF
+		password string
+		opts     Options
+		want     string
+	}{
+		{
+			// RFC 7914 section 11
+			KDFPBKDF2, "passwd",
+			Options{"salt": []byte("salt"), "iterations": 1, "length": 64},
+			`55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc
+			49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783`,
+		},
+		{
+			// RFC 7914 section 12
+			KDFScrypt, "password",
+			Options{"salt": []byte("NaCl"), "logn": 10, "r": 8, "p": 16, "length": 64},
+			`fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b373162
+			2eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640`,
+		},
+		{
+			// reference implementation (phc-winner-argon2) test vectors
+			KDFArgon2id, "password",
+			Options{"salt": []byte("somesalt"), "passes": 2, "memory": 64, "threads": 1, "length": 24},
+			"068d62b26455936aa6ebe60060b0a65870dbfa3ddf8d41f7",
+		},
+		{
+			KDFArgon2i, "password",
+			Options{"salt": []byte("somesalt"), "passes": 2, "memory": 64, "threads": 2, "length": 24},
+			"2089f3e78a799720f80af806553128f29b132cafe40d059f",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := KDFOperation(tt.kdf).Run([]byte(tt.password), tt.opts)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.kdf, err)
+		}
+		if want := fromHex(tt.want); !bytes.Equal(got, want) {
+			t.Errorf("%v = %x, want %x", tt.kdf, got, want)
+		}
+	}
+}
+
+func TestKDFOperationRange(t *testing.T) {
+	tests := []struct {
+		name string
+		kdf  KDF
+		opts Options
+	}{
+		// each of these used to wrap to a valid, much cheaper value
+		{"iterations above uint32", KDFPBKDF2, Options{"iterations": 1<<32 + 1000}},
+		{"negative iterations", KDFPBKDF2, Options{"iterations": -1}},
+		{"logn above uint8", KDFScrypt, Options{"logn": 256 + 10}},
+		{"negative logn", KDFScrypt, Options{"logn": -246}},
+		{"r above uint32", KDFScrypt, Options{"r": 1<<32 + 8}},
+		{"negative p", KDFScrypt, Options{"p": -1}},
+		{"threads above uint8", KDFArgon2id, Options{"threads": 256 + 1}},
+		{"memory above uint32", KDFArgon2id, Options{"memory": 1<<32 + 64}},
+		{"negative passes", KDFArgon2i, Options{"passes": -2}},
+		{"negative length", KDFArgon2id, Options{"length": -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts["salt"] = []byte("salt")
+			if _, err := KDFOperation(tt.kdf).Run([]byte("password"), tt.opts); !errors.Is(err, ErrKDFParams) {
+				t.Errorf("error = %v, want ErrKDFParams", err)
+			}
+		})
+	}
+}
+
+func TestKDFParamsValidate(t *testing.T) {
+	valid := []KDFParams{
+		DefaultKDFParams(KDFPBKDF2),
+		DefaultKDFParams(KDFScrypt),
+		DefaultKDFParams(KDFArgon2id),
+		DefaultKDFParams(KDFArgon2i),
+		{KDF: KDFPBKDF2, Hash: "sha512", Iterations: 1},
+	}
+	for _, p := range valid {
+		if err := p.Validate(); err != nil {
+			t.Errorf("%+v: %v", p, err)
+		}
+	}
+
+	invalid := []KDFParams{
+		{KDF: KDFPBKDF2},
+		{KDF: KDFPBKDF2, Iterations: 100_000_001},
+		{KDF: KDFScrypt, LogN: 25, R: 8, P: 1},
+		{KDF: KDFScrypt, LogN: 23, R: 8, P: 1},
+		{KDF: KDFScrypt, LogN: 10, R: 0, P: 1},
+		{KDF: KDFArgon2id, Iterations: 1, Memory: 7, Threads: 1},
+		{KDF: KDFArgon2id, Iterations: 1001, Memory: 64, Threads: 1},
+		{KDF: KDFArgon2i, Iterations: 1, Memory: 4<<20 + 1, Threads: 1},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); !errors.Is(err, ErrKDFParams) {
+			t.Errorf("%+v: error = %v, want ErrKDFParams", p, err)
+		}
+	}
+
+	if err := (KDFParams{KDF: KDFPBKDF2, Hash: "sha0", Iterations: 1}).Validate(); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("PBKDF2-sha0 error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/mlkem"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// A message sealed to an ML-KEM public key carries everything the recipient
+// needs besides their private key:
+//
+//	"CHKM" | version 1 | parameter set | KEM ciphertext | AES-256-GCM ciphertext | tag
+//
+// The AES key is HKDF-SHA256 of the KEM shared key, salted with the header
+// (everything up to the GCM ciphertext) and the recipient public key. The
+// header is also the associated data. Every message has its own key, so the
+// GCM nonce is all zero.
+
+const (
+	kemDEMMagic   = "CHKM"
+	kemDEMVersion = 1
+	kemDEMLabel   = "chify ML-KEM AES-256-GCM"
+)
+
+var ErrKEMDEMHeader = errors.New("not an ML-KEM sealed message")
+
+func (p MLKEMParameterSet) ciphertextSize() int {
+	switch p {
+	case MLKEM768:
+		return mlkem.CiphertextSize768
+	case MLKEM1024:
+		return mlkem.CiphertextSize1024
+	case X25519MLKEM768:
+		return mlkem.CiphertextSize768 + 32
+	}
+	return 0
+}
+
+// SealMLKEM encapsulates a key to publicKey and encrypts data with it.
+func SealMLKEM(params MLKEMParameterSet, publicKey, data []byte) ([]byte, error) {
+	sharedKey, kemCiphertext, err := MLKEMEncapsulate(params, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte(kemDEMMagic), kemDEMVersion, byte(params))
+	header = append(header, kemCiphertext...)
+	aead, err := kemDEMCipher(sharedKey, header, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(header, make([]byte, aead.NonceSize()), data, header), nil
+}
+
+// OpenMLKEM decrypts a message from SealMLKEM. The parameter set is read
+// from the message and must match privateKey.
+func OpenMLKEM(privateKey, data []byte) ([]byte, error) {
+	if len(data) < len(kemDEMMagic)+2 || !bytes.HasPrefix(data, []byte(kemDEMMagic)) {
+		return nil, ErrKEMDEMHeader
+	}
+	if version := data[len(kemDEMMagic)]; version != kemDEMVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrKEMDEMHeader, version)
+	}
+	params := MLKEMParameterSet(data[len(kemDEMMagic)+1])
+	size := params.ciphertextSize()
+	if size == 0 {
+		return nil, fmt.Errorf("%w: ML-KEM parameter set %d", ErrUnknownAlgorithm, params)
+	}
+	headerSize := len(kemDEMMagic) + 2 + size
+	if len(data) < headerSize {
+		return nil, ErrCiphertextTooShort
+	}
+	header := data[:headerSize]
+
+	publicKey, err := MLKEMPublicKey(params, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("message is for %v: %w", params, err)
+	}
+	sharedKey, err := MLKEMDecapsulate(params, privateKey, header[len(kemDEMMagic)+2:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := kemDEMCipher(sharedKey, header, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// ML-KEM decapsulates to a wrong but valid key with the wrong private
+	// key, which shows up here
+	plaintext, err := aead.Open(nil, make([]byte, aead.NonceSize()), data[headerSize:], header)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
+
+func kemDEMCipher(sharedKey, header, publicKey []byte) (cipher.AEAD, error) {
+	salt := append(header[:len(header):len(header)], publicKey...)
+	key, err := hkdf.Key(sha256.New, sharedKey, salt, kemDEMLabel, 32)
+	if err != nil {
+		return nil, err
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
+
+func MLKEMOperation() *Operation {
+	return &Operation{
+		Name:     "ml-kem",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "set", Kind: KindString, Choices: MLKEMParameterSets(), Default: MLKEM768.String(), Usage: "parameter set of the recipient key"},
+			{Name: "public-key", Kind: KindBytes, Usage: "recipient public key, to seal"},
+			{Name: "private-key", Kind: KindBytes, Usage: "own private key in seed form, to open"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "open a sealed message instead of sealing"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			if opts.Bool(Reverse) {
+				return OpenMLKEM(opts.Bytes("private-key"), input)
+			}
+			params, err := ParseMLKEMParameterSet(opts.String("set"))
+			if err != nil {
+				return nil, err
+			}
+			return SealMLKEM(params, opts.Bytes("public-key"), input)
+		},
+	}
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+	"fmt"
+
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/cast5"
+	"golang.org/x/crypto/tea"
+	"golang.org/x/crypto/twofish"
+	"golang.org/x/crypto/xtea"
+)
+
+// Legacy block ciphers, for reading old exports. All but Twofish have a 64
+// bit block, which leaks after a few GB under one key.
+
+type blockCipher struct {
+	name     string
+	keySizes string // for error messages
+	validKey func(n int) bool
+	new      func(key []byte) (cipher.Block, error)
+}
+
+func keySizeIn(sizes ...int) func(int) bool {
+	return func(n int) bool {
+		for _, size := range sizes {
+			if n == size {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var blockCiphers = []blockCipher{
+	{"3DES", "16 or 24", keySizeIn(16, 24), func(key []byte) (cipher.Block, error) {
+		// a 16 byte key is keying option 2, K1 K2 K1
+		if len(key) == 16 {
+			key = append(key[:16:16], key[:8]...)
+		}
+		return des.NewTripleDESCipher(key)
+	}},
+	{"DES", "8", keySizeIn(8), des.NewCipher},
+	{"Blowfish", "1 to 56", func(n int) bool { return n >= 1 && n <= 56 }, func(key []byte) (cipher.Block, error) {
+		return blowfish.NewCipher(key)
+	}},
+	{"Twofish", "16, 24 or 32", keySizeIn(16, 24, 32), func(key []byte) (cipher.Block, error) {
+		return twofish.NewCipher(key)
+	}},
+	{"CAST5", "16", keySizeIn(16), func(key []byte) (cipher.Block, error) {
+		return cast5.NewCipher(key)
+	}},
+	{"TEA", "16", keySizeIn(16), func(key []byte) (cipher.Block, error) {
+		return tea.NewCipher(key)
+	}},
+	{"XTEA", "16", keySizeIn(16), func(key []byte) (cipher.Block, error) {
+		return xtea.NewCipher(key)
+	}},
+}
+
+// BlockCiphers lists the names accepted by NewBlockCipher.
+func BlockCiphers() []string {
+	var names []string
+	for _, c := range blockCiphers {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+func findBlockCipher(name string) (blockCipher, error) {
+	for _, c := range blockCiphers {
+		if c.name == name {
+			return c, nil
+		}
+	}
+	return blockCipher{}, fmt.Errorf("%w: block cipher %q", ErrUnknownAlgorithm, name)
+}
+
+// CheckBlockCipherKey reports whether a key of n bytes fits the cipher.
+func CheckBlockCipherKey(name string, n int) error {
+	c, err := findBlockCipher(name)
+	if err != nil {
+		return err
+	}
+	if !c.validKey(n) {
+		return fmt.Errorf("%s key must be %s bytes, got %d", name, c.keySizes, n)
+	}
+	return nil
+}
+
+func NewBlockCipher(name string, key []byte) (cipher.Block, error) {
+	if err := CheckBlockCipherKey(name, len(key)); err != nil {
+		return nil, err
+	}
+	c, _ := findBlockCipher(name)
+	return c.new(key)
+}
+
+// EncryptBlockCipher encrypts data with one of BlockCiphers in one of BlockModes.
+func EncryptBlockCipher(name string, mode BlockMode, padding Padding, key, data []byte) ([]byte, error) {
+	c, err := NewBlockCipher(name, key)
+	if err != nil {
+		return nil, err
+	}
+	return EncryptBlockMode(c, mode, padding, data)
+}
+
+// DecryptBlockCipher reverses EncryptBlockCipher.
+func DecryptBlockCipher(name string, mode BlockMode, padding Padding, key, data []byte) ([]byte, error) {
+	c, err := NewBlockCipher(name, key)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptBlockMode(c, mode, padding, data)
+}
+
+func BlockCipherOperation() *Operation {
+	return &Operation{
+		Name:     "block",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "cipher", Kind: KindString, Choices: BlockCiphers(), Default: "3DES", Usage: "block cipher"},
+			{Name: "mode", Kind: KindString, Choices: BlockModes(), Default: "CBC", Usage: "block cipher mode"},
+			{Name: "key", Kind: KindBytes, Usage: "key, its size depends on the cipher"},
+			{Name: "padding", Kind: KindString, Choices: Paddings(), Default: "PKCS7", Usage: "CBC and ECB padding"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decrypt instead of encrypt"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			mode, err := ParseBlockMode(opts.String("mode"))
+			if err != nil {
+				return nil, err
+			}
+			padding, err := ParsePadding(opts.String("padding"))
+			if err != nil {
+				return nil, err
+			}
+			if opts.Bool(Reverse) {
+				return DecryptBlockCipher(opts.String("cipher"), mode, padding, opts.Bytes("key"), input)
+			}
+			return EncryptBlockCipher(opts.String("cipher"), mode, padding, opts.Bytes("key"), input)
+		},
+	}
+}
@@ -0,0 +1,234 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Checksum manifests list file digests in the layouts of GNU coreutils
+// (sha256sum: "digest  path") and BSD ("SHA256 (path) = digest").
+
+type ManifestFormat int
+
+const (
+	ManifestGNU ManifestFormat = iota
+	ManifestBSD
+)
+
+var manifestFormats = []string{"GNU", "BSD"}
+
+func (f ManifestFormat) String() string {
+	return manifestFormats[f]
+}
+
+func ManifestFormats() []string {
+	return append([]string(nil), manifestFormats...)
+}
+
+func ParseManifestFormat(s string) (ManifestFormat, error) {
+	for i, name := range manifestFormats {
+		if name == s {
+			return ManifestFormat(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: manifest format %q", ErrUnknownAlgorithm, s)
+}
+
+type ManifestEntry struct {
+	Algorithm string
+	Path      string // slash separated, relative to the manifest directory
+	Digest    []byte
+}
+
+// HashTag is the BSD style name of an algorithm, e.g. "SHA3-256".
+func HashTag(algorithm string) string {
+	return strings.ToUpper(algorithm)
+}
+
+// ManifestFileName is the conventional manifest name, e.g. "SHA256SUMS".
+func ManifestFileName(algorithm string) string {
+	return HashTag(algorithm) + "SUMS"
+}
+
+// FormatManifestLine renders one entry including the trailing newline.
+// GNU lines for paths with a newline or backslash are escaped the way
+// coreutils does it, with a leading backslash.
+func FormatManifestLine(format ManifestFormat, e ManifestEntry) string {
+	path, escaped := escapeManifestPath(e.Path)
+	prefix := ""
+	if escaped {
+		prefix = "\\"
+	}
+
+	if format == ManifestBSD {
+		return fmt.Sprintf("%s%s (%s) = %x\n", prefix, HashTag(e.Algorithm), path, e.Digest)
+	}
+	return fmt.Sprintf("%s%x  %s\n", prefix, e.Digest, path)
+}
+
+func escapeManifestPath(path string) (string, bool) {
+	if !strings.ContainsAny(path, "\\\n\r") {
+		return path, false
+	}
+	r := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+	return r.Replace(path), true
+}
+
+func unescapeManifestPath(path string) string {
+	r := strings.NewReplacer("\\\\", "\\", "\\n", "\n", "\\r", "\r")
+	return r.Replace(path)
+}
+
+// ParseManifest reads GNU and BSD lines (they may be mixed). GNU lines carry
+// no algorithm name, so they use defaultAlgorithm when its digest size
+// matches and are otherwise guessed from the digest length.
+func ParseManifest(data []byte, defaultAlgorithm string) ([]ManifestEntry, error) {
+	var entries []ManifestEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, err := parseManifestLine(line, defaultAlgorithm)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+var errManifestLine = errors.New("not a checksum line")
+
+func parseManifestLine(line, defaultAlgorithm string) (ManifestEntry, error) {
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+	unescape := func(path string) string {
+		if escaped {
+			return unescapeManifestPath(path)
+		}
+		return path
+	}
+
+	// BSD: TAG (path) = digest. A GNU path may contain " (" and ") = " too,
+	// so the tag has to name a known algorithm.
+	open, closing := strings.Index(line, " ("), strings.LastIndex(line, ") = ")
+	if open > 0 && closing > open {
+		algorithm := strings.ReplaceAll(strings.ToLower(line[:open]), "/", "-")
+		if _, err := HashConstructor(algorithm); err == nil {
+			digest, err := hex.DecodeString(line[closing+4:])
+			if err != nil {
+				return ManifestEntry{}, err
+			}
+			return ManifestEntry{Algorithm: algorithm, Path: unescape(line[open+2 : closing]), Digest: digest}, nil
+		}
+	}
+
+	// GNU: digest, a space, then ' ' (text) or '*' (binary) and the path
+	digestText, rest, ok := strings.Cut(line, " ")
+	if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '*') {
+		return ManifestEntry{}, errManifestLine
+	}
+	digest, err := hex.DecodeString(digestText)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	algorithm, err := algorithmForSize(defaultAlgorithm, len(digest))
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	return ManifestEntry{Algorithm: algorithm, Path: unescape(rest[1:]), Digest: digest}, nil
+}
+
+func algorithmForSize(preferred string, size int) (string, error) {
+	candidates := append([]string{preferred}, "md5", "sha1", "sha224", "sha256", "sha384", "sha512")
+	for _, name := range candidates {
+		h, err := NewHash(name)
+		if err == nil && h.Size() == size {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no algorithm with a %d byte digest", size)
+}
+
+type VerifyStatus int
+
+const (
+	VerifyOK VerifyStatus = iota
+	VerifyFailed
+	VerifyMissing
+)
+
+func (s VerifyStatus) String() string {
+	return [...]string{"OK", "FAILED", "MISSING"}[s]
+}
+
+type VerifyResult struct {
+	ManifestEntry
+	Status VerifyStatus
+	Err    error // read error for FAILED entries, nil on a digest mismatch
+}
+
+// VerifyManifest hashes every listed file under base and reports it as OK,
+// FAILED (digest mismatch or read error) or MISSING. progress receives the
+// number of bytes hashed so far; the returned total is known up front.
+func VerifyManifest(ctx context.Context, base string, entries []ManifestEntry, progress func(int64), result func(VerifyResult)) error {
+	var done int64
+	buf := make([]byte, 1024*1024)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		path := filepath.Join(base, filepath.FromSlash(entry.Path))
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			result(VerifyResult{ManifestEntry: entry, Status: VerifyMissing})
+			continue
+		}
+
+		h, err := NewHash(entry.Algorithm)
+		if err != nil {
+			return err
+		}
+
+		err = hashFile(ctx, h, path, buf, &done, progress)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			result(VerifyResult{ManifestEntry: entry, Status: VerifyFailed, Err: err})
+		case !bytes.Equal(h.Sum(nil), entry.Digest):
+			result(VerifyResult{ManifestEntry: entry, Status: VerifyFailed})
+		default:
+			result(VerifyResult{ManifestEntry: entry, Status: VerifyOK})
+		}
+	}
+
+	return nil
+}
+
+// ManifestSize sums the sizes of the listed files that exist, for progress reporting.
+func ManifestSize(base string, entries []ManifestEntry) int64 {
+	var total int64
+	for _, entry := range entries {
+		if info, err := os.Stat(filepath.Join(base, filepath.FromSlash(entry.Path))); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
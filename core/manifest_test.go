@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	abcSHA256 = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	abcMD5    = "900150983cd24fb0d6963f7d28e17f72"
+)
+
+func TestParseManifest(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want ManifestEntry
+	}{
+		{"GNU text", abcSHA256 + "  docs/a.txt", ManifestEntry{"sha256", "docs/a.txt", fromHex(abcSHA256)}},
+		{"GNU binary", abcSHA256 + " *a.bin", ManifestEntry{"sha256", "a.bin", fromHex(abcSHA256)}},
+		{"GNU guessed size", abcMD5 + "  a", ManifestEntry{"md5", "a", fromHex(abcMD5)}},
+		{"GNU path like a BSD line", abcSHA256 + "  docs/a (copy) = b.txt", ManifestEntry{"sha256", "docs/a (copy) = b.txt", fromHex(abcSHA256)}},
+		{"GNU escaped", "\\" + abcSHA256 + "  a\\nb\\\\c", ManifestEntry{"sha256", "a\nb\\c", fromHex(abcSHA256)}},
+		{"GNU CRLF", abcSHA256 + "  a\r", ManifestEntry{"sha256", "a", fromHex(abcSHA256)}},
+		{"BSD", "SHA256 (a (1).txt) = " + abcSHA256, ManifestEntry{"sha256", "a (1).txt", fromHex(abcSHA256)}},
+		{"BSD other algorithm", "MD5 (a) = " + abcMD5, ManifestEntry{"md5", "a", fromHex(abcMD5)}},
+		{"BSD SHA3", "SHA3-256 (a) = " + abcSHA256, ManifestEntry{"sha3-256", "a", fromHex(abcSHA256)}},
+		{"BSD escaped", "\\SHA256 (a\\nb) = " + abcSHA256, ManifestEntry{"sha256", "a\nb", fromHex(abcSHA256)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseManifest([]byte("# comment\n\n"+tt.line+"\n"), "sha256")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("parsed %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, line := range []string{
+		"not a checksum",
+		abcSHA256 + " a",
+		"zz" + abcSHA256[2:] + "  a",
+		"WHIRLPOOL (a) = " + abcSHA256,
+		"SHA256 (a) = zz",
+	} {
+		if _, err := ParseManifest([]byte("\n"+line), "sha256"); err == nil {
+			t.Errorf("%q was accepted", line)
+		} else if !strings.Contains(err.Error(), "line 2:") {
+			t.Errorf("%q: error %q does not name the line", line, err)
+		}
+	}
+}
+
+func TestFormatManifestLine(t *testing.T) {
+	tests := []struct {
+		format ManifestFormat
+		path   string
+		want   string
+	}{
+		{ManifestGNU, "docs/a.txt", abcSHA256 + "  docs/a.txt\n"},
+		{ManifestGNU, "a\nb\\c", "\\" + abcSHA256 + "  a\\nb\\\\c\n"},
+		{ManifestBSD, "docs/a.txt", "SHA256 (docs/a.txt) = " + abcSHA256 + "\n"},
+		{ManifestBSD, "a\rb", "\\SHA256 (a\\rb) = " + abcSHA256 + "\n"},
+	}
+
+	for _, tt := range tests {
+		entry := ManifestEntry{Algorithm: "sha256", Path: tt.path, Digest: fromHex(abcSHA256)}
+		line := FormatManifestLine(tt.format, entry)
+		if line != tt.want {
+			t.Errorf("%v %q: %q, want %q", tt.format, tt.path, line, tt.want)
+		}
+		parsed, err := ParseManifest([]byte(line), "md5")
+		if err != nil || len(parsed) != 1 || !reflect.DeepEqual(parsed[0], entry) {
+			t.Errorf("%v %q: parsed back %+v, %v", tt.format, tt.path, parsed, err)
+		}
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{"docs/a.txt": "abc", "b.txt": "abd"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest := abcSHA256 + "  docs/a.txt\n" +
+		"SHA256 (b.txt) = " + abcSHA256 + "\n" +
+		"MD5 (docs/a.txt) = " + abcMD5 + "\n" +
+		abcSHA256 + "  gone.txt\n"
+	entries, err := ParseManifest([]byte(manifest), "sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []VerifyStatus
+	var done int64
+	err = VerifyManifest(context.Background(), dir, entries, func(n int64) { done = n }, func(r VerifyResult) {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Path, r.Err)
+		}
+		got = append(got, r.Status)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VerifyStatus{VerifyOK, VerifyFailed, VerifyOK, VerifyMissing}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses %v, want %v", got, want)
+	}
+	if size := ManifestSize(dir, entries); done != size || size != 9 {
+		t.Errorf("progress %d, size %d, want 9", done, size)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = VerifyManifest(ctx, dir, entries, func(int64) {}, func(VerifyResult) { t.Error("result after cancel") })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled error = %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"crypto/ecdh"
+	"crypto/mlkem"
+	"crypto/rand"
+	"fmt"
+)
+
+type MLKEMParameterSet int
+
+const (
+	MLKEM768 MLKEMParameterSet = iota
+	MLKEM1024
+	// X25519MLKEM768 combines ML-KEM-768 with X25519 as in TLS: public
+	// keys, ciphertexts and shared keys are the ML-KEM part followed by
+	// the X25519 part, private keys the 64 byte seed followed by the
+	// X25519 scalar. The shared key stays secret while either part holds.
+	X25519MLKEM768
+)
+
+func (p MLKEMParameterSet) String() string {
+	return [...]string{"ML-KEM-768", "ML-KEM-1024", "X25519MLKEM768"}[p]
+}
+
+func MLKEMParameterSets() []string {
+	return []string{MLKEM768.String(), MLKEM1024.String(), X25519MLKEM768.String()}
+}
+
+func ParseMLKEMParameterSet(name string) (MLKEMParameterSet, error) {
+	for _, p := range []MLKEMParameterSet{MLKEM768, MLKEM1024, X25519MLKEM768} {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, name)
+}
+
+// GenerateMLKEMKey returns a new private key in its 64 byte seed form
+// together with the matching public (encapsulation) key.
+func GenerateMLKEMKey(params MLKEMParameterSet) (privateKey, publicKey []byte, err error) {
+	switch params {
+	case MLKEM768:
+		dk, err := mlkem.GenerateKey768()
+		if err != nil {
+			return nil, nil, err
+		}
+		return dk.Bytes(), dk.EncapsulationKey().Bytes(), nil
+	case MLKEM1024:
+		dk, err := mlkem.GenerateKey1024()
+		if err != nil {
+			return nil, nil, err
+		}
+		return dk.Bytes(), dk.EncapsulationKey().Bytes(), nil
+	case X25519MLKEM768:
+		dk, err := mlkem.GenerateKey768()
+		if err != nil {
+			return nil, nil, err
+		}
+		x, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(dk.Bytes(), x.Bytes()...), append(dk.EncapsulationKey().Bytes(), x.PublicKey().Bytes()...), nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %d", ErrUnknownAlgorithm, params)
+}
+
+// MLKEMEncapsulate derives a fresh shared key for the holder of publicKey
+// and returns it with the ciphertext to send them.
+func MLKEMEncapsulate(params MLKEMParameterSet, publicKey []byte) (sharedKey, ciphertext []byte, err error) {
+	switch params {
+	case MLKEM768:
+		ek, err := mlkem.NewEncapsulationKey768(publicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		sharedKey, ciphertext = ek.Encapsulate()
+		return sharedKey, ciphertext, nil
+	case MLKEM1024:
+		ek, err := mlkem.NewEncapsulationKey1024(publicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		sharedKey, ciphertext = ek.Encapsulate()
+		return sharedKey, ciphertext, nil
+	case X25519MLKEM768:
+		ek, peer, err := hybridPublicKey(publicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		shared, err := ephemeral.ECDH(peer)
+		if err != nil {
+			return nil, nil, err
+		}
+		sharedKey, ciphertext = ek.Encapsulate()
+		return append(sharedKey, shared...), append(ciphertext, ephemeral.PublicKey().Bytes()...), nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %d", ErrUnknownAlgorithm, params)
+}
+
+// MLKEMDecapsulate recovers the shared key from ciphertext using the seed form private key.
+func MLKEMDecapsulate(params MLKEMParameterSet, privateKey, ciphertext []byte) ([]byte, error) {
+	switch params {
+	case MLKEM768:
+		dk, err := mlkem.NewDecapsulationKey768(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		return dk.Decapsulate(ciphertext)
+	case MLKEM1024:
+		dk, err := mlkem.NewDecapsulationKey1024(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		return dk.Decapsulate(ciphertext)
+	case X25519MLKEM768:
+		dk, x, err := hybridPrivateKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(ciphertext) != mlkem.CiphertextSize768+32 {
+			return nil, fmt.Errorf("invalid %v ciphertext size %d", params, len(ciphertext))
+		}
+		sharedKey, err := dk.Decapsulate(ciphertext[:mlkem.CiphertextSize768])
+		if err != nil {
+			return nil, err
+		}
+		ephemeral, err := ecdh.X25519().NewPublicKey(ciphertext[mlkem.CiphertextSize768:])
+		if err != nil {
+			return nil, err
+		}
+		shared, err := x.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+		return append(sharedKey, shared...), nil
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrUnknownAlgorithm, params)
+}
+
+func hybridPublicKey(publicKey []byte) (*mlkem.EncapsulationKey768, *ecdh.PublicKey, error) {
+	if len(publicKey) != mlkem.EncapsulationKeySize768+32 {
+		return nil, nil, fmt.Errorf("invalid %v public key size %d", X25519MLKEM768, len(publicKey))
+	}
+	ek, err := mlkem.NewEncapsulationKey768(publicKey[:mlkem.EncapsulationKeySize768])
+	if err != nil {
+		return nil, nil, err
+	}
+	x, err := ecdh.X25519().NewPublicKey(publicKey[mlkem.EncapsulationKeySize768:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return ek, x, nil
+}
+
+func hybridPrivateKey(privateKey []byte) (*mlkem.DecapsulationKey768, *ecdh.PrivateKey, error) {
+	if len(privateKey) != mlkem.SeedSize+32 {
+		return nil, nil, fmt.Errorf("invalid %v private key size %d", X25519MLKEM768, len(privateKey))
+	}
+	dk, err := mlkem.NewDecapsulationKey768(privateKey[:mlkem.SeedSize])
+	if err != nil {
+		return nil, nil, err
+	}
+	x, err := ecdh.X25519().NewPrivateKey(privateKey[mlkem.SeedSize:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return dk, x, nil
+}
@@ -0,0 +1,240 @@
+package core
+
+import (
+	"crypto/mlkem"
+	"crypto/sha3"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ML-KEM keys in PEM use the X.509 encodings of draft-ietf-lamps-kyber-certificates:
+// a SubjectPublicKeyInfo under "PUBLIC KEY" and a PKCS #8 OneAsymmetricKey
+// holding the 64 byte seed under "PRIVATE KEY", as OpenSSL 3.5 writes them.
+// X25519MLKEM768 has no such encoding, its raw keys are stored in blocks of
+// type "X25519MLKEM768 PUBLIC KEY" and "X25519MLKEM768 PRIVATE KEY".
+
+var (
+	oidMLKEM768  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 2}
+	oidMLKEM1024 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 3}
+)
+
+var ErrMLKEMKey = errors.New("invalid ML-KEM key")
+
+type pkixAlgorithm struct {
+	Algorithm asn1.ObjectIdentifier
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkixAlgorithm
+	PublicKey asn1.BitString
+}
+
+type pkcs8PrivateKey struct {
+	Version    int
+	Algorithm  pkixAlgorithm
+	PrivateKey []byte
+}
+
+// mlkemBothKey is the "both" choice of ML-KEM-PrivateKey, seed with expanded key.
+type mlkemBothKey struct {
+	Seed        []byte
+	ExpandedKey []byte
+}
+
+func (p MLKEMParameterSet) oid() asn1.ObjectIdentifier {
+	if p == MLKEM1024 {
+		return oidMLKEM1024
+	}
+	return oidMLKEM768
+}
+
+func mlkemParameterSetFromOID(oid asn1.ObjectIdentifier) (MLKEMParameterSet, error) {
+	switch {
+	case oid.Equal(oidMLKEM768):
+		return MLKEM768, nil
+	case oid.Equal(oidMLKEM1024):
+		return MLKEM1024, nil
+	}
+	return 0, fmt.Errorf("%w: key algorithm %v", ErrUnknownAlgorithm, oid)
+}
+
+// MLKEMPublicKey checks a seed form private key and derives its public key.
+func MLKEMPublicKey(params MLKEMParameterSet, privateKey []byte) ([]byte, error) {
+	switch params {
+	case MLKEM768:
+		dk, err := mlkem.NewDecapsulationKey768(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMLKEMKey, err)
+		}
+		return dk.EncapsulationKey().Bytes(), nil
+	case MLKEM1024:
+		dk, err := mlkem.NewDecapsulationKey1024(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMLKEMKey, err)
+		}
+		return dk.EncapsulationKey().Bytes(), nil
+	case X25519MLKEM768:
+		dk, x, err := hybridPrivateKey(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMLKEMKey, err)
+		}
+		return append(dk.EncapsulationKey().Bytes(), x.PublicKey().Bytes()...), nil
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrUnknownAlgorithm, params)
+}
+
+// CheckMLKEMPublicKey reports whether publicKey is a valid encapsulation key.
+func CheckMLKEMPublicKey(params MLKEMParameterSet, publicKey []byte) error {
+	var err error
+	switch params {
+	case MLKEM768:
+		_, err = mlkem.NewEncapsulationKey768(publicKey)
+	case MLKEM1024:
+		_, err = mlkem.NewEncapsulationKey1024(publicKey)
+	case X25519MLKEM768:
+		_, _, err = hybridPublicKey(publicKey)
+	default:
+		return fmt.Errorf("%w: %d", ErrUnknownAlgorithm, params)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMLKEMKey, err)
+	}
+	return nil
+}
+
+// EncodeMLKEMPEM returns key as a "PUBLIC KEY" or, if private, a "PRIVATE KEY" block.
+func EncodeMLKEMPEM(params MLKEMParameterSet, key []byte, private bool) ([]byte, error) {
+	if params == X25519MLKEM768 {
+		var err error
+		if private {
+			_, err = MLKEMPublicKey(params, key)
+		} else {
+			err = CheckMLKEMPublicKey(params, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: hybridPEMType(private), Bytes: key}), nil
+	}
+
+	algorithm := pkixAlgorithm{Algorithm: params.oid()}
+
+	if !private {
+		if err := CheckMLKEMPublicKey(params, key); err != nil {
+			return nil, err
+		}
+		der, err := asn1.Marshal(subjectPublicKeyInfo{
+			Algorithm: algorithm,
+			PublicKey: asn1.BitString{Bytes: key, BitLength: 8 * len(key)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+	}
+
+	if _, err := MLKEMPublicKey(params, key); err != nil {
+		return nil, err
+	}
+	seed, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: key})
+	if err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(pkcs8PrivateKey{Algorithm: algorithm, PrivateKey: seed})
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// DecodeMLKEMPEM reads the first PEM block of data. Private keys are returned
+// in seed form, keys stored only in expanded form are not supported.
+func DecodeMLKEMPEM(data []byte) (params MLKEMParameterSet, key []byte, private bool, err error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return 0, nil, false, fmt.Errorf("%w: no PEM block found", ErrMLKEMKey)
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		var spki subjectPublicKeyInfo
+		if rest, err := asn1.Unmarshal(block.Bytes, &spki); err != nil || len(rest) > 0 {
+			return 0, nil, false, fmt.Errorf("%w: malformed public key", ErrMLKEMKey)
+		}
+		if params, err = mlkemParameterSetFromOID(spki.Algorithm.Algorithm); err != nil {
+			return 0, nil, false, err
+		}
+		key = spki.PublicKey.RightAlign()
+		return params, key, false, CheckMLKEMPublicKey(params, key)
+	case "PRIVATE KEY":
+		var p8 pkcs8PrivateKey
+		if rest, err := asn1.Unmarshal(block.Bytes, &p8); err != nil || len(rest) > 0 {
+			return 0, nil, false, fmt.Errorf("%w: malformed private key", ErrMLKEMKey)
+		}
+		if params, err = mlkemParameterSetFromOID(p8.Algorithm.Algorithm); err != nil {
+			return 0, nil, false, err
+		}
+		key, err = mlkemSeed(params, p8.PrivateKey)
+		return params, key, true, err
+	case hybridPEMType(false):
+		return X25519MLKEM768, block.Bytes, false, CheckMLKEMPublicKey(X25519MLKEM768, block.Bytes)
+	case hybridPEMType(true):
+		_, err = MLKEMPublicKey(X25519MLKEM768, block.Bytes)
+		return X25519MLKEM768, block.Bytes, true, err
+	}
+
+	return 0, nil, false, fmt.Errorf("%w: unexpected PEM type %q", ErrMLKEMKey, block.Type)
+}
+
+// mlkemSeed returns the seed of an ML-KEM-PrivateKey after checking that it
+// makes a valid key and, in the "both" form, that the expanded key belongs to it.
+func mlkemSeed(params MLKEMParameterSet, der []byte) ([]byte, error) {
+	var raw asn1.RawValue
+	if rest, err := asn1.Unmarshal(der, &raw); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("%w: malformed private key", ErrMLKEMKey)
+	}
+
+	switch {
+	case raw.Class == asn1.ClassContextSpecific && raw.Tag == 0:
+		if _, err := MLKEMPublicKey(params, raw.Bytes); err != nil {
+			return nil, err
+		}
+		return raw.Bytes, nil
+	case raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagSequence:
+		var both mlkemBothKey
+		if _, err := asn1.Unmarshal(raw.FullBytes, &both); err != nil {
+			return nil, fmt.Errorf("%w: malformed private key", ErrMLKEMKey)
+		}
+		publicKey, err := MLKEMPublicKey(params, both.Seed)
+		if err != nil {
+			return nil, err
+		}
+		if !mlkemExpandedMatches(both.ExpandedKey, publicKey, both.Seed) {
+			return nil, fmt.Errorf("%w: expanded key does not match the seed", ErrMLKEMKey)
+		}
+		return both.Seed, nil
+	}
+	return nil, fmt.Errorf("%w: only seed form private keys are supported", ErrMLKEMKey)
+}
+
+// mlkemExpandedMatches compares the parts of an expanded decapsulation key
+// dk_PKE || ek || H(ek) || z that can be rebuilt from the seed d || z. crypto/mlkem
+// does not expose dk_PKE, so only its length is checked.
+func mlkemExpandedMatches(expanded, publicKey, seed []byte) bool {
+	h := sha3.Sum256(publicKey)
+	tail := slices.Concat(publicKey, h[:], seed[32:])
+	dkPKE := len(publicKey) - 32
+	return len(expanded) == dkPKE+len(tail) && subtle.ConstantTimeCompare(expanded[dkPKE:], tail) == 1
+}
+
+func hybridPEMType(private bool) string {
+	if private {
+		return "X25519MLKEM768 PRIVATE KEY"
+	}
+	return "X25519MLKEM768 PUBLIC KEY"
+}
@@ -0,0 +1,225 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+// testdata/mlkem.json holds NIST ACVP ML-KEM keyGen vectors (FIPS 203): the
+// seed d | z and the public key ek. The ciphertext c and shared key k were
+// encapsulated to ek from the randomness m with an independent implementation
+// (github.com/cloudflare/circl).
+type mlkemVector struct {
+	ParameterSet string `json:"parameterSet"`
+	TcID         string `json:"tcId"`
+	D            string `json:"d"`
+	Z            string `json:"z"`
+	EK           string `json:"ek"`
+	M            string `json:"m"`
+	C            string `json:"c"`
+	K            string `json:"k"`
+}
+
+func TestMLKEMVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/mlkem.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []mlkemVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range vectors {
+		t.Run(v.ParameterSet+"/"+v.TcID, func(t *testing.T) {
+			params, err := ParseMLKEMParameterSet(v.ParameterSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seed := fromHex(v.D + v.Z)
+
+			publicKey, err := MLKEMPublicKey(params, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(publicKey, fromHex(v.EK)) {
+				t.Errorf("public key = %x, want %s", publicKey, v.EK)
+			}
+
+			sharedKey, err := MLKEMDecapsulate(params, seed, fromHex(v.C))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(sharedKey, fromHex(v.K)) {
+				t.Errorf("shared key = %x, want %s", sharedKey, v.K)
+			}
+		})
+	}
+}
+
+func TestMLKEMRoundTrip(t *testing.T) {
+	for _, name := range MLKEMParameterSets() {
+		params, err := ParseMLKEMParameterSet(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey, publicKey, err := GenerateMLKEMKey(params)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if derived, err := MLKEMPublicKey(params, privateKey); err != nil || !bytes.Equal(derived, publicKey) {
+			t.Errorf("%s: public key does not follow from the private key: %v", name, err)
+		}
+
+		sharedKey, ciphertext, err := MLKEMEncapsulate(params, publicKey)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(ciphertext) != params.ciphertextSize() {
+			t.Errorf("%s: ciphertext is %d bytes, want %d", name, len(ciphertext), params.ciphertextSize())
+		}
+		decapsulated, err := MLKEMDecapsulate(params, privateKey, ciphertext)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(decapsulated, sharedKey) {
+			t.Errorf("%s: decapsulated %x, want %x", name, decapsulated, sharedKey)
+		}
+
+		// implicit rejection: a changed ciphertext gives an unrelated key
+		ciphertext[0] ^= 1
+		rejected, err := MLKEMDecapsulate(params, privateKey, ciphertext)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if bytes.Equal(rejected, sharedKey) {
+			t.Errorf("%s: changed ciphertext gave the same key", name)
+		}
+	}
+}
+
+// testdata/mlkem holds ML-KEM keys for the seed 00 01 ... 3f in the layouts
+// OpenSSL 3.5 reads and writes (draft-ietf-lamps-kyber-certificates, appendix
+// C): seed only, both seed and expanded key, and expanded key only. The
+// expanded keys were computed with github.com/cloudflare/circl, the mismatch
+// files pair the seed with the expanded key of the seed 3f 3e ... 00.
+func TestDecodeMLKEMPEM(t *testing.T) {
+	seed := sequence(64)
+
+	for _, params := range []MLKEMParameterSet{MLKEM768, MLKEM1024} {
+		name := params.String()
+		read := func(file string) []byte {
+			data, err := os.ReadFile("testdata/mlkem/" + name + file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return data
+		}
+		publicKey, err := MLKEMPublicKey(params, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, key, private, err := DecodeMLKEMPEM(read(".pub"))
+		if err != nil || got != params || private || !bytes.Equal(key, publicKey) {
+			t.Errorf("%s public key: %v %x %v %v", name, got, key, private, err)
+		}
+		for _, form := range []string{"-seed", "-both"} {
+			got, key, private, err := DecodeMLKEMPEM(read(form + ".priv"))
+			if err != nil || got != params || !private || !bytes.Equal(key, seed) {
+				t.Errorf("%s%s private key: %v %x %v %v", name, form, got, key, private, err)
+			}
+		}
+		for _, form := range []string{"-mismatch", "-expanded"} {
+			if _, _, _, err := DecodeMLKEMPEM(read(form + ".priv")); !errors.Is(err, ErrMLKEMKey) {
+				t.Errorf("%s%s error = %v, want ErrMLKEMKey", name, form, err)
+			}
+		}
+
+		encoded, err := EncodeMLKEMPEM(params, seed, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := read("-seed.priv"); !bytes.Equal(encoded, want) {
+			t.Errorf("%s: encoded private key\n%s\nwant\n%s", name, encoded, want)
+		}
+		encoded, err = EncodeMLKEMPEM(params, publicKey, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := read(".pub"); !bytes.Equal(encoded, want) {
+			t.Errorf("%s: encoded public key\n%s\nwant\n%s", name, encoded, want)
+		}
+	}
+}
+
+func TestMLKEMPEMRoundTrip(t *testing.T) {
+	for _, name := range MLKEMParameterSets() {
+		params, _ := ParseMLKEMParameterSet(name)
+		privateKey, publicKey, err := GenerateMLKEMKey(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []struct {
+			data    []byte
+			private bool
+		}{{privateKey, true}, {publicKey, false}} {
+			encoded, err := EncodeMLKEMPEM(params, key.data, key.private)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			got, data, private, err := DecodeMLKEMPEM(encoded)
+			if err != nil || got != params || private != key.private || !bytes.Equal(data, key.data) {
+				t.Errorf("%s private %v: round trip gave %v %x %v %v", name, key.private, got, data, private, err)
+			}
+		}
+	}
+
+	if _, err := EncodeMLKEMPEM(MLKEM768, make([]byte, 32), true); !errors.Is(err, ErrMLKEMKey) {
+		t.Errorf("short seed error = %v, want ErrMLKEMKey", err)
+	}
+	if _, _, _, err := DecodeMLKEMPEM([]byte("no pem here")); !errors.Is(err, ErrMLKEMKey) {
+		t.Errorf("missing block error = %v, want ErrMLKEMKey", err)
+	}
+}
+
+func TestSealMLKEM(t *testing.T) {
+	message := []byte("post-quantum hello")
+
+	for _, name := range MLKEMParameterSets() {
+		params, _ := ParseMLKEMParameterSet(name)
+		privateKey, publicKey, err := GenerateMLKEMKey(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		otherKey, _, err := GenerateMLKEMKey(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sealed, err := SealMLKEM(params, publicKey, message)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		opened, err := OpenMLKEM(privateKey, sealed)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(opened, message) {
+			t.Errorf("%s: opened %q", name, opened)
+		}
+
+		if _, err := OpenMLKEM(otherKey, sealed); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: wrong key error = %v, want ErrAuthentication", name, err)
+		}
+		if _, err := OpenMLKEM(privateKey, sealed[:len(sealed)-1]); !errors.Is(err, ErrAuthentication) {
+			t.Errorf("%s: truncated message error = %v, want ErrAuthentication", name, err)
+		}
+		if _, err := OpenMLKEM(privateKey, sealed[4:]); !errors.Is(err, ErrKEMDEMHeader) {
+			t.Errorf("%s: missing magic error = %v, want ErrKEMDEMHeader", name, err)
+		}
+	}
+}
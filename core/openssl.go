@@ -0,0 +1,216 @@
+package core
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// The OpenSSL enc format, as written by
+//
+//	openssl enc -aes-256-cbc -salt [-pbkdf2] [-md sha256] [-iter 10000]
+//
+// is "Salted__" | 8 byte salt | ciphertext. Key and IV are both derived from
+// the password, so unlike EncryptAES there is no IV in the output.
+
+const (
+	openSSLMagic    = "Salted__"
+	openSSLSaltSize = 8
+)
+
+// OpenSSLKDFs lists the key derivations of openssl enc.
+var OpenSSLKDFs = []string{"pbkdf2", "evp-bytestokey"}
+
+var ErrOpenSSLHeader = errors.New("not an OpenSSL salted message")
+
+// ErrBadDecrypt mirrors the "bad decrypt" of openssl: wrong password or corrupt data.
+var ErrBadDecrypt = errors.New("bad decrypt")
+
+type OpenSSLParams struct {
+	Mode       BlockMode // CBC or CTR
+	KeySize    int       // 16, 24 or 32, 32 if 0
+	KDF        string    // one of OpenSSLKDFs, pbkdf2 if empty
+	Digest     string    // -md, sha256 if empty
+	Iterations int       // -iter for PBKDF2, 10000 if 0
+}
+
+func (p OpenSSLParams) withDefaults() (OpenSSLParams, error) {
+	if p.Mode != BlockModeCBC && p.Mode != BlockModeCTR {
+		return p, fmt.Errorf("%w: OpenSSL profile with AES mode %v", ErrUnknownAlgorithm, p.Mode)
+	}
+	if p.KeySize == 0 {
+		p.KeySize = 32
+	}
+	if p.KDF == "" {
+		p.KDF = "pbkdf2"
+	}
+	if p.Digest == "" {
+		p.Digest = "sha256"
+	}
+	if p.Iterations == 0 {
+		p.Iterations = 10000
+	}
+	if p.KeySize != 16 && p.KeySize != 24 && p.KeySize != 32 {
+		return p, aes.KeySizeError(p.KeySize)
+	}
+	if p.Iterations < 1 {
+		return p, fmt.Errorf("invalid PBKDF2 iteration count %d", p.Iterations)
+	}
+	return p, nil
+}
+
+// EVPBytesToKey is the legacy OpenSSL key derivation with an iteration count
+// of 1: D_i = H(D_i-1 | password | salt), concatenated until key and IV are filled.
+func EVPBytesToKey(digest string, password, salt []byte, keySize, ivSize int) (key, iv []byte, err error) {
+	newHash, err := HashConstructor(digest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var derived, block []byte
+	for len(derived) < keySize+ivSize {
+		h := newHash()
+		h.Write(block)
+		h.Write(password)
+		h.Write(salt)
+		block = h.Sum(nil)
+		derived = append(derived, block...)
+	}
+
+	return derived[:keySize], derived[keySize : keySize+ivSize], nil
+}
+
+func openSSLKeyIV(p OpenSSLParams, password, salt []byte) (key, iv []byte, err error) {
+	switch p.KDF {
+	case "evp-bytestokey":
+		return EVPBytesToKey(p.Digest, password, salt, p.KeySize, aes.BlockSize)
+	case "pbkdf2":
+		newHash, err := HashConstructor(p.Digest)
+		if err != nil {
+			return nil, nil, err
+		}
+		derived, err := pbkdf2.Key(newHash, string(password), salt, p.Iterations, p.KeySize+aes.BlockSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		return derived[:p.KeySize], derived[p.KeySize:], nil
+	}
+	return nil, nil, fmt.Errorf("%w: OpenSSL KDF %q", ErrUnknownAlgorithm, p.KDF)
+}
+
+// EncryptOpenSSL produces the same output as openssl enc -salt with the
+// matching options.
+func EncryptOpenSSL(p OpenSSLParams, password, data []byte) ([]byte, error) {
+	p, err := p.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, openSSLSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, iv, err := openSSLKeyIV(p, password, salt)
+	if err != nil {
+		return nil, err
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := append([]byte(openSSLMagic), salt...)
+	if p.Mode == BlockModeCBC {
+		data, err = PaddingPKCS7.Pad(data, aes.BlockSize)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext := make([]byte, len(data))
+		cipher.NewCBCEncrypter(c, iv).CryptBlocks(ciphertext, data)
+		return append(result, ciphertext...), nil
+	}
+
+	ciphertext := make([]byte, len(data))
+	cipher.NewCTR(c, iv).XORKeyStream(ciphertext, data)
+	return append(result, ciphertext...), nil
+}
+
+// DecryptOpenSSL reads the output of openssl enc -salt. Parameters that are
+// not stored in the file (mode, key size, KDF, digest, iterations) must match.
+func DecryptOpenSSL(p OpenSSLParams, password, data []byte) ([]byte, error) {
+	p, err := p.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < len(openSSLMagic)+openSSLSaltSize || !bytes.HasPrefix(data, []byte(openSSLMagic)) {
+		return nil, ErrOpenSSLHeader
+	}
+	salt := data[len(openSSLMagic) : len(openSSLMagic)+openSSLSaltSize]
+	data = data[len(openSSLMagic)+openSSLSaltSize:]
+
+	key, iv, err := openSSLKeyIV(p, password, salt)
+	if err != nil {
+		return nil, err
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Mode == BlockModeCTR {
+		plaintext := make([]byte, len(data))
+		cipher.NewCTR(c, iv).XORKeyStream(plaintext, data)
+		return plaintext, nil
+	}
+
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, ErrBadDecrypt
+	}
+	plaintext := make([]byte, len(data))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(plaintext, data)
+
+	// CBC has no authentication, a wrong password shows up as bad padding
+	plaintext, err = PaddingPKCS7.Unpad(plaintext, aes.BlockSize)
+	if err != nil {
+		return nil, ErrBadDecrypt
+	}
+	return plaintext, nil
+}
+
+func OpenSSLOperation() *Operation {
+	return &Operation{
+		Name:     "openssl",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "mode", Kind: KindString, Choices: []string{"CBC", "CTR"}, Default: "CBC", Usage: "block cipher mode"},
+			{Name: "passphrase", Kind: KindBytes, Usage: "password"},
+			{Name: "key-size", Kind: KindInt, Default: 32, Usage: "AES key size in bytes (16, 24 or 32)"},
+			{Name: "kdf", Kind: KindString, Choices: OpenSSLKDFs, Default: "pbkdf2", Usage: "key derivation, pbkdf2 for openssl enc -pbkdf2"},
+			{Name: "md", Kind: KindString, Choices: HashAlgorithms, Default: "sha256", Usage: "digest of the key derivation"},
+			{Name: "iter", Kind: KindInt, Default: 10000, Usage: "PBKDF2 iterations"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decrypt instead of encrypt"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			mode, err := ParseAESMode(opts.String("mode"))
+			if err != nil {
+				return nil, err
+			}
+			p := OpenSSLParams{
+				Mode:       mode,
+				KeySize:    opts.Int("key-size"),
+				KDF:        opts.String("kdf"),
+				Digest:     opts.String("md"),
+				Iterations: opts.Int("iter"),
+			}
+			if opts.Bool(Reverse) {
+				return DecryptOpenSSL(p, opts.Bytes("passphrase"), input)
+			}
+			return EncryptOpenSSL(p, opts.Bytes("passphrase"), input)
+		},
+	}
+}
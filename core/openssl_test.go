@@ -0,0 +1,138 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata/openssl were written by openssl enc, see generate.sh.
+const openSSLTestPassword = "chify test password"
+
+var openSSLFixtures = []struct {
+	file      string
+	params    OpenSSLParams
+	plaintext string
+}{
+	{"aes-256-cbc-md5.enc", OpenSSLParams{Mode: BlockModeCBC, KDF: "evp-bytestokey", Digest: "md5"}, "plaintext.txt"},
+	{"aes-256-cbc-sha256.enc", OpenSSLParams{Mode: BlockModeCBC, KDF: "evp-bytestokey", Digest: "sha256"}, "plaintext.txt"},
+	{"aes-128-cbc-sha1.enc", OpenSSLParams{Mode: BlockModeCBC, KeySize: 16, KDF: "evp-bytestokey", Digest: "sha1"}, "plaintext.txt"},
+	{"aes-256-cbc-pbkdf2.enc", OpenSSLParams{Mode: BlockModeCBC}, "plaintext.txt"},
+	{"aes-192-cbc-pbkdf2-sha512.enc", OpenSSLParams{Mode: BlockModeCBC, KeySize: 24, Digest: "sha512", Iterations: 1000}, "plaintext.txt"},
+	{"aes-256-ctr-pbkdf2.enc", OpenSSLParams{Mode: BlockModeCTR}, "plaintext.txt"},
+	{"aes-128-ctr-md5.enc", OpenSSLParams{Mode: BlockModeCTR, KeySize: 16, KDF: "evp-bytestokey", Digest: "md5"}, "plaintext.txt"},
+	{"empty-aes-256-cbc-pbkdf2.enc", OpenSSLParams{Mode: BlockModeCBC}, ""},
+}
+
+func readOpenSSLFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	if name == "" {
+		return []byte{}
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "openssl", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecryptOpenSSLFixtures(t *testing.T) {
+	for _, f := range openSSLFixtures {
+		t.Run(f.file, func(t *testing.T) {
+			data := readOpenSSLFixture(t, f.file)
+			want := readOpenSSLFixture(t, f.plaintext)
+
+			got, err := DecryptOpenSSL(f.params, []byte(openSSLTestPassword), data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("decrypted %q, want %q", got, want)
+			}
+
+			// CTR cannot tell a wrong password, CBC fails on the padding
+			// (or, once in about 256 times, gives garbage)
+			if f.params.Mode == BlockModeCBC {
+				got, err := DecryptOpenSSL(f.params, []byte("wrong password"), data)
+				if err == nil && bytes.Equal(got, want) {
+					t.Error("decrypted with the wrong password")
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptOpenSSLErrors(t *testing.T) {
+	data := readOpenSSLFixture(t, "aes-256-cbc-pbkdf2.enc")
+	password := []byte(openSSLTestPassword)
+	p := OpenSSLParams{Mode: BlockModeCBC}
+
+	if _, err := DecryptOpenSSL(p, password, data[8:]); !errors.Is(err, ErrOpenSSLHeader) {
+		t.Errorf("missing magic error = %v, want ErrOpenSSLHeader", err)
+	}
+	if _, err := DecryptOpenSSL(p, password, data[:len(data)-1]); !errors.Is(err, ErrBadDecrypt) {
+		t.Errorf("truncated error = %v, want ErrBadDecrypt", err)
+	}
+	if _, err := DecryptOpenSSL(p, password, data[:16]); !errors.Is(err, ErrBadDecrypt) {
+		t.Errorf("header only error = %v, want ErrBadDecrypt", err)
+	}
+	if _, err := DecryptOpenSSL(OpenSSLParams{Mode: BlockModeGCM}, password, data); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("GCM error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
+
+func TestOpenSSLRoundTrip(t *testing.T) {
+	want := readOpenSSLFixture(t, "plaintext.txt")
+	password := []byte(openSSLTestPassword)
+
+	for _, f := range openSSLFixtures {
+		encrypted, err := EncryptOpenSSL(f.params, password, want)
+		if err != nil {
+			t.Fatalf("%s: %v", f.file, err)
+		}
+		decrypted, err := DecryptOpenSSL(f.params, password, encrypted)
+		if err != nil {
+			t.Fatalf("%s: %v", f.file, err)
+		}
+		if !bytes.Equal(decrypted, want) {
+			t.Errorf("%s: round trip gave %q", f.file, decrypted)
+		}
+	}
+}
+
+// TestEncryptOpenSSLInterop has the openssl command decrypt our output, when
+// it is installed.
+func TestEncryptOpenSSLInterop(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl not found")
+	}
+	want := readOpenSSLFixture(t, "plaintext.txt")
+
+	for _, tt := range []struct {
+		params OpenSSLParams
+		args   []string
+	}{
+		{OpenSSLParams{Mode: BlockModeCBC}, []string{"-aes-256-cbc", "-pbkdf2"}},
+		{OpenSSLParams{Mode: BlockModeCTR, KeySize: 16, Digest: "sha512", Iterations: 1000}, []string{"-aes-128-ctr", "-pbkdf2", "-md", "sha512", "-iter", "1000"}},
+		{OpenSSLParams{Mode: BlockModeCBC, KDF: "evp-bytestokey", Digest: "md5"}, []string{"-aes-256-cbc", "-md", "md5"}},
+	} {
+		encrypted, err := EncryptOpenSSL(tt.params, []byte(openSSLTestPassword), want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := append([]string{"enc", "-d", "-pass", "pass:" + openSSLTestPassword}, tt.args...)
+		cmd := exec.Command(openssl, args...)
+		cmd.Stdin = bytes.NewReader(encrypted)
+		got, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("openssl %v: %v", tt.args, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("openssl %v decrypted %q", tt.args, got)
+		}
+	}
+}
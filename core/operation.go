@@ -0,0 +1,131 @@
+// Package core holds chify's algorithms without any UI dependency.
+// The desktop forms are thin views over the functions and operations
+// defined here, so the same code can be tested and reused from other tools.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type ParamKind int
+
+const (
+	KindBytes ParamKind = iota
+	KindString
+	KindInt
+	KindBool
+)
+
+func (k ParamKind) String() string {
+	return [...]string{"bytes", "string", "int", "bool"}[k]
+}
+
+// Param describes one option accepted by an Operation.
+type Param struct {
+	Name    string
+	Kind    ParamKind
+	Choices []string // allowed values for KindString, empty means any
+	Default any
+	Usage   string
+}
+
+// Options are the values of an operation's params, keyed by Param.Name.
+// Values have the Go type matching the param kind: []byte, string, int or bool.
+type Options map[string]any
+
+func (o Options) Bytes(name string) []byte {
+	v, _ := o[name].([]byte)
+	return v
+}
+
+func (o Options) String(name string) string {
+	v, _ := o[name].(string)
+	return v
+}
+
+func (o Options) Int(name string) int {
+	v, _ := o[name].(int)
+	return v
+}
+
+func (o Options) Bool(name string) bool {
+	v, _ := o[name].(bool)
+	return v
+}
+
+// Reverse is the bool param of operations that can be undone (decrypt, decode).
+const Reverse = "reverse"
+
+var ErrUnknownAlgorithm = errors.New("unknown algorithm")
+
+// Operation is a named transformation of bytes driven by Options.
+type Operation struct {
+	Name     string
+	Category string
+	Params   []Param
+	run      func(input []byte, opts Options) ([]byte, error)
+}
+
+func (op *Operation) Param(name string) (Param, bool) {
+	for _, p := range op.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Param{}, false
+}
+
+func (op *Operation) Reversible() bool {
+	_, ok := op.Param(Reverse)
+	return ok
+}
+
+// Run applies the operation to input. Missing options take the param
+// defaults; unknown or mistyped options are rejected.
+func (op *Operation) Run(input []byte, opts Options) ([]byte, error) {
+	resolved := make(Options, len(op.Params))
+	for _, p := range op.Params {
+		if p.Default != nil {
+			resolved[p.Name] = p.Default
+		}
+	}
+
+	for name, value := range opts {
+		p, ok := op.Param(name)
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown option %q", op.Name, name)
+		}
+		if err := p.check(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", op.Name, err)
+		}
+		resolved[name] = value
+	}
+
+	return op.run(input, resolved)
+}
+
+func (p Param) check(value any) error {
+	var ok bool
+	switch p.Kind {
+	case KindBytes:
+		_, ok = value.([]byte)
+	case KindString:
+		var s string
+		s, ok = value.(string)
+		if ok && len(p.Choices) > 0 && !slices.Contains(p.Choices, s) {
+			return fmt.Errorf("option %q must be one of %v, got %q", p.Name, p.Choices, s)
+		}
+	case KindInt:
+		_, ok = value.(int)
+	case KindBool:
+		_, ok = value.(bool)
+	}
+
+	if !ok {
+		return fmt.Errorf("option %q must be %s, got %T", p.Name, p.Kind, value)
+	}
+
+	return nil
+}
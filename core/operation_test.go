@@ -0,0 +1,121 @@
+package core
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fromHex decodes a test vector, ignoring spaces and line breaks.
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// echoOperation returns an operation whose run records the resolved options.
+func echoOperation(got *Options) *Operation {
+	return &Operation{
+		Name:     "echo",
+		Category: "test",
+		Params: []Param{
+			{Name: "mode", Kind: KindString, Choices: []string{"a", "b"}, Default: "a"},
+			{Name: "key", Kind: KindBytes},
+			{Name: "size", Kind: KindInt, Default: 16},
+			{Name: Reverse, Kind: KindBool, Default: false},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			*got = opts
+			return input, nil
+		},
+	}
+}
+
+func TestOperationRun(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want Options // resolved options, nil if Run must fail
+		err  string
+	}{
+		{
+			name: "defaults",
+			opts: nil,
+			want: Options{"mode": "a", "size": 16, Reverse: false},
+		},
+		{
+			name: "overrides",
+			opts: Options{"mode": "b", "key": []byte{1}, "size": 32, Reverse: true},
+			want: Options{"mode": "b", "key": []byte{1}, "size": 32, Reverse: true},
+		},
+		{
+			name: "partial",
+			opts: Options{"size": 24},
+			want: Options{"mode": "a", "size": 24, Reverse: false},
+		},
+		{
+			name: "unknown option",
+			opts: Options{"nonce": []byte{1}},
+			err:  `echo: unknown option "nonce"`,
+		},
+		{
+			name: "string for int",
+			opts: Options{"size": "16"},
+			err:  `echo: option "size" must be int, got string`,
+		},
+		{
+			name: "string for bytes",
+			opts: Options{"key": "secret"},
+			err:  `echo: option "key" must be bytes, got string`,
+		},
+		{
+			name: "int for bool",
+			opts: Options{Reverse: 1},
+			err:  `echo: option "reverse" must be bool, got int`,
+		},
+		{
+			name: "choice not allowed",
+			opts: Options{"mode": "c"},
+			err:  `echo: option "mode" must be one of [a b], got "c"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Options
+			out, err := echoOperation(&got).Run([]byte("input"), tt.opts)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("Run error = %v, want %q", err, tt.err)
+				}
+				if got != nil {
+					t.Fatal("run was called with rejected options")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != "input" {
+				t.Errorf("Run = %q, want %q", out, "input")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolved options = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperationRunDoesNotModifyOptions(t *testing.T) {
+	var got Options
+	opts := Options{"size": 24}
+	if _, err := echoOperation(&got).Run(nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(opts) != 1 {
+		t.Errorf("Run changed the caller's options: %v", opts)
+	}
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Padding fills the last block of the block modes (CBC, ECB).
+type Padding int
+
+const (
+	PaddingPKCS7    Padding = iota // n bytes of value n
+	PaddingX923                    // zeros, then the count
+	PaddingISO7816                 // 0x80, then zeros
+	PaddingISO10126                // random bytes, then the count
+	PaddingZero                    // zeros, nothing if the data fills the block; trailing zeros of the data are lost
+	PaddingNone                    // the data must fill whole blocks
+)
+
+var paddings = []string{"PKCS7", "X9.23", "ISO7816-4", "ISO10126", "Zero", "None"}
+
+func (p Padding) String() string {
+	return paddings[p]
+}
+
+// Paddings lists the names accepted by ParsePadding.
+func Paddings() []string {
+	return append([]string(nil), paddings...)
+}
+
+func ParsePadding(s string) (Padding, error) {
+	for i, name := range paddings {
+		if name == s {
+			return Padding(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: padding %q", ErrUnknownAlgorithm, s)
+}
+
+// ErrPadding means the decrypted data does not end in valid padding, usually
+// because the key or the padding scheme is wrong.
+var ErrPadding = errors.New("invalid padding")
+
+// ErrBlockLength means a block mode ciphertext is not made of whole blocks.
+var ErrBlockLength = errors.New("ciphertext is not a multiple of the block size")
+
+// Pad returns data extended to a multiple of blockSize.
+func (p Padding) Pad(data []byte, blockSize int) ([]byte, error) {
+	n := blockSize - len(data)%blockSize
+	data = data[:len(data):len(data)]
+
+	switch p {
+	case PaddingPKCS7:
+		return append(data, bytes.Repeat([]byte{byte(n)}, n)...), nil
+	case PaddingX923:
+		return append(append(data, make([]byte, n-1)...), byte(n)), nil
+	case PaddingISO7816:
+		return append(append(data, 0x80), make([]byte, n-1)...), nil
+	case PaddingISO10126:
+		random := make([]byte, n-1)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		return append(append(data, random...), byte(n)), nil
+	case PaddingZero:
+		if n == blockSize {
+			return data, nil
+		}
+		return append(data, make([]byte, n)...), nil
+	case PaddingNone:
+		if n != blockSize {
+			return nil, fmt.Errorf("data of %d bytes needs padding for a %d byte block", len(data), blockSize)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("%w: padding %d", ErrUnknownAlgorithm, p)
+}
+
+// Unpad strips the padding added by Pad and returns ErrPadding if it is malformed.
+func (p Padding) Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data)%blockSize != 0 {
+		return nil, ErrBlockLength
+	}
+
+	switch p {
+	case PaddingPKCS7, PaddingX923, PaddingISO10126:
+		if len(data) == 0 {
+			return nil, ErrPadding
+		}
+		n := int(data[len(data)-1])
+		if n == 0 || n > blockSize {
+			return nil, ErrPadding
+		}
+		padding := data[len(data)-n : len(data)-1]
+		if p == PaddingPKCS7 && !bytes.Equal(padding, bytes.Repeat([]byte{byte(n)}, n-1)) {
+			return nil, ErrPadding
+		}
+		if p == PaddingX923 && !bytes.Equal(padding, make([]byte, n-1)) {
+			return nil, ErrPadding
+		}
+		return data[:len(data)-n], nil
+	case PaddingISO7816:
+		if len(data) == 0 {
+			return nil, ErrPadding
+		}
+		i := len(data) - 1
+		for i > 0 && data[i] == 0 {
+			i--
+		}
+		if data[i] != 0x80 || len(data)-i > blockSize {
+			return nil, ErrPadding
+		}
+		return data[:i], nil
+	case PaddingZero:
+		return bytes.TrimRight(data, "\x00"), nil
+	case PaddingNone:
+		return data, nil
+	}
+	return nil, fmt.Errorf("%w: padding %d", ErrUnknownAlgorithm, p)
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		padding Padding
+		data    string
+		want    string
+	}{
+		{PaddingPKCS7, "", "0808080808080808"},
+		{PaddingPKCS7, "616263", "6162630505050505"},
+		{PaddingPKCS7, "0102030405060708", "0102030405060708 0808080808080808"},
+		{PaddingX923, "616263", "6162630000000005"},
+		{PaddingX923, "0102030405060708", "0102030405060708 0000000000000008"},
+		{PaddingISO7816, "616263", "6162638000000000"},
+		{PaddingISO7816, "", "8000000000000000"},
+		{PaddingZero, "616263", "6162630000000000"},
+		{PaddingZero, "0102030405060708", "0102030405060708"},
+		{PaddingNone, "0102030405060708", "0102030405060708"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.padding.Pad(fromHex(tt.data), 8)
+		if err != nil {
+			t.Fatalf("%v %s: %v", tt.padding, tt.data, err)
+		}
+		if want := fromHex(tt.want); !bytes.Equal(got, want) {
+			t.Errorf("%v %s: padded %x, want %x", tt.padding, tt.data, got, want)
+		}
+	}
+
+	got, err := PaddingISO10126.Pad([]byte("abc"), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 8 || !bytes.Equal(got[:3], []byte("abc")) || got[7] != 5 {
+		t.Errorf("ISO10126 padded %x", got)
+	}
+
+	if _, err := PaddingNone.Pad([]byte("abc"), 8); err == nil {
+		t.Error("None padded a partial block")
+	}
+}
+
+func TestUnpad(t *testing.T) {
+	tests := []struct {
+		name    string
+		padding Padding
+		data    string
+		want    string
+		err     error
+	}{
+		{"PKCS7 one byte", PaddingPKCS7, "0102030405060701", "01020304050607", nil},
+		{"PKCS7 short", PaddingPKCS7, "6162630505050505", "616263", nil},
+		{"PKCS7 full block", PaddingPKCS7, "0102030405060708 0808080808080808", "0102030405060708", nil},
+		{"PKCS7 zero pad byte", PaddingPKCS7, "0102030405060700", "", ErrPadding},
+		{"PKCS7 pad longer than the block", PaddingPKCS7, "0909090909090909 0909090909090909", "", ErrPadding},
+		{"PKCS7 inconsistent pad bytes", PaddingPKCS7, "6162630504050505", "", ErrPadding},
+		{"PKCS7 X9.23 padding", PaddingPKCS7, "6162630000000005", "", ErrPadding},
+		{"PKCS7 not whole blocks", PaddingPKCS7, "61626305050505", "", ErrBlockLength},
+		{"PKCS7 empty", PaddingPKCS7, "", "", ErrPadding},
+		{"X9.23 short", PaddingX923, "6162630000000005", "616263", nil},
+		{"X9.23 full block", PaddingX923, "0102030405060708 0000000000000008", "0102030405060708", nil},
+		{"X9.23 zero pad byte", PaddingX923, "0102030405060700", "", ErrPadding},
+		{"X9.23 pad longer than the block", PaddingX923, "0000000000000000 0000000000000009", "", ErrPadding},
+		{"X9.23 nonzero pad bytes", PaddingX923, "6162630000010005", "", ErrPadding},
+		{"X9.23 PKCS7 padding", PaddingX923, "6162630505050505", "", ErrPadding},
+		{"X9.23 not whole blocks", PaddingX923, "010203040506070801", "", ErrBlockLength},
+		{"ISO10126 random bytes", PaddingISO10126, "616263a1b2c3d405", "616263", nil},
+		{"ISO10126 zero pad byte", PaddingISO10126, "616263a1b2c3d400", "", ErrPadding},
+		{"ISO10126 pad longer than the block", PaddingISO10126, "0102030405060708 a1b2c3d4e5f6a709", "", ErrPadding},
+		{"ISO7816 short", PaddingISO7816, "6162638000000000", "616263", nil},
+		{"ISO7816 full block", PaddingISO7816, "0102030405060708 8000000000000000", "0102030405060708", nil},
+		{"ISO7816 no marker", PaddingISO7816, "6162630000000000", "", ErrPadding},
+		{"ISO7816 all zeros", PaddingISO7816, "0000000000000000", "", ErrPadding},
+		{"ISO7816 pad longer than the block", PaddingISO7816, "0102038000000000 0000000000000000", "", ErrPadding},
+		{"Zero", PaddingZero, "6162630000000000", "616263", nil},
+		{"None", PaddingNone, "6162630000000000", "6162630000000000", nil},
+		{"None not whole blocks", PaddingNone, "616263", "", ErrBlockLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.padding.Unpad(fromHex(tt.data), 8)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := fromHex(tt.want); !bytes.Equal(got, want) {
+				t.Errorf("unpadded %x, want %x", got, want)
+			}
+		})
+	}
+}
+
+func TestPaddingRoundTrip(t *testing.T) {
+	for _, name := range []string{"PKCS7", "X9.23", "ISO7816-4", "ISO10126"} {
+		p, err := ParsePadding(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := range 33 {
+			data := bytes.Repeat([]byte{0xaa}, n)
+			padded, err := p.Pad(data, 16)
+			if err != nil {
+				t.Fatalf("%s %d: %v", name, n, err)
+			}
+			got, err := p.Unpad(padded, 16)
+			if err != nil {
+				t.Fatalf("%s %d: %v", name, n, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("%s %d: round trip gave %x", name, n, got)
+			}
+		}
+	}
+
+	if _, err := ParsePadding("PKCS5"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("PKCS5 error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
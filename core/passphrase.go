@@ -0,0 +1,266 @@
+package core
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Messages encrypted with a passphrase start with a header that records how
+// the key was derived, so decrypting needs nothing but the passphrase:
+//
+//	"CHPW" | version 1 | KDF | KDF parameters | salt length | salt
+//
+// The parameters are, all integers big endian:
+//
+//	PBKDF2:   iterations uint32
+//	scrypt:   log2(N) uint8 | r uint32 | p uint32
+//	Argon2:   passes uint32 | memory KiB uint32 | threads uint8
+//
+// PBKDF2 always uses HMAC-SHA256 here. Anyone can write a header, and its
+// cost is paid before the passphrase is known to be right, so the costs a
+// header may ask for are much lower than what KDFParams.Validate allows.
+
+const (
+	passphraseMagic    = "CHPW"
+	passphraseVersion  = 1
+	passphraseSaltSize = 16
+	passphraseKeySize  = 32
+
+	// Cost limits of a passphrase header, about 16 times the defaults
+	maxHeaderIterations = 10_000_000 // PBKDF2
+	maxHeaderMemory     = 1 << 30    // bytes of scrypt or Argon2 memory
+	maxHeaderWork       = 4 << 30    // memory times scrypt parallelism or Argon2 passes
+)
+
+var (
+	ErrPassphraseHeader = errors.New("not a passphrase encrypted message")
+	ErrKDFTooCostly     = errors.New("KDF cost above the passphrase header limit")
+)
+
+// NewPassphraseKey derives a key for a new message using a fresh random salt
+// and returns it with the header to put in front of the ciphertext.
+func NewPassphraseKey(passphrase []byte, params KDFParams, size int) (key, header []byte, err error) {
+	if params.KDF == KDFPBKDF2 && params.hash() != "sha256" {
+		return nil, nil, fmt.Errorf("%w: PBKDF2 with %s", ErrKDFParams, params.Hash)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, nil, err
+	}
+	// never write a header that OpenPassphraseKey refuses to read
+	if err := params.checkHeaderCost(); err != nil {
+		return nil, nil, err
+	}
+
+	params.Salt = make([]byte, passphraseSaltSize)
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, nil, err
+	}
+
+	key, err = params.DeriveKey(passphrase, size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header = append([]byte(passphraseMagic), passphraseVersion, byte(params.KDF))
+	switch params.KDF {
+	case KDFPBKDF2:
+		header = binary.BigEndian.AppendUint32(header, params.Iterations)
+	case KDFScrypt:
+		header = append(header, params.LogN)
+		header = binary.BigEndian.AppendUint32(header, params.R)
+		header = binary.BigEndian.AppendUint32(header, params.P)
+	case KDFArgon2id, KDFArgon2i:
+		header = binary.BigEndian.AppendUint32(header, params.Iterations)
+		header = binary.BigEndian.AppendUint32(header, params.Memory)
+		header = append(header, params.Threads)
+	}
+	header = append(header, byte(len(params.Salt)))
+	header = append(header, params.Salt...)
+
+	return key, header, nil
+}
+
+// OpenPassphraseKey parses the header at the start of data, derives the key
+// and returns it with the data that follows the header.
+func OpenPassphraseKey(passphrase, data []byte, size int) (key, rest []byte, err error) {
+	params, rest, err := parsePassphraseHeader(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err = params.DeriveKey(passphrase, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, rest, nil
+}
+
+func parsePassphraseHeader(data []byte) (KDFParams, []byte, error) {
+	var params KDFParams
+	if len(data) < len(passphraseMagic)+2 || !bytes.HasPrefix(data, []byte(passphraseMagic)) {
+		return params, nil, ErrPassphraseHeader
+	}
+	if data[4] != passphraseVersion {
+		return params, nil, fmt.Errorf("%w: version %d", ErrPassphraseHeader, data[4])
+	}
+	params.KDF = KDF(data[5])
+	data = data[6:]
+
+	var fieldsSize int
+	switch params.KDF {
+	case KDFPBKDF2:
+		fieldsSize = 4
+	case KDFScrypt, KDFArgon2id, KDFArgon2i:
+		fieldsSize = 9
+	default:
+		return params, nil, fmt.Errorf("%w: %v", ErrUnknownAlgorithm, params.KDF)
+	}
+	if len(data) < fieldsSize+1 {
+		return params, nil, ErrCiphertextTooShort
+	}
+
+	switch params.KDF {
+	case KDFPBKDF2:
+		params.Iterations = binary.BigEndian.Uint32(data)
+	case KDFScrypt:
+		params.LogN = data[0]
+		params.R = binary.BigEndian.Uint32(data[1:])
+		params.P = binary.BigEndian.Uint32(data[5:])
+	case KDFArgon2id, KDFArgon2i:
+		params.Iterations = binary.BigEndian.Uint32(data)
+		params.Memory = binary.BigEndian.Uint32(data[4:])
+		params.Threads = data[8]
+	}
+	data = data[fieldsSize:]
+
+	saltSize := int(data[0])
+	if len(data) < 1+saltSize {
+		return params, nil, ErrCiphertextTooShort
+	}
+	params.Salt = data[1 : 1+saltSize]
+
+	if err := params.Validate(); err != nil {
+		return params, nil, err
+	}
+	return params, data[1+saltSize:], params.checkHeaderCost()
+}
+
+// checkHeaderCost applies the passphrase header limits to parameters that
+// passed Validate.
+func (p KDFParams) checkHeaderCost() error {
+	var memory, work uint64
+	switch p.KDF {
+	case KDFPBKDF2:
+		if p.Iterations > maxHeaderIterations {
+			return fmt.Errorf("%w: PBKDF2 iterations %d, at most %d", ErrKDFTooCostly, p.Iterations, maxHeaderIterations)
+		}
+		return nil
+	case KDFScrypt:
+		memory = 128 * uint64(p.R) << p.LogN
+		work = memory * uint64(p.P)
+	case KDFArgon2id, KDFArgon2i:
+		memory = uint64(p.Memory) * 1024
+		work = memory * uint64(p.Iterations)
+	}
+
+	if memory > maxHeaderMemory || work > maxHeaderWork {
+		return fmt.Errorf("%w: %v with %d MiB of memory and %d MiB of work, at most %d and %d",
+			ErrKDFTooCostly, p.KDF, memory>>20, work>>20, maxHeaderMemory>>20, maxHeaderWork>>20)
+	}
+	return nil
+}
+
+// SealWithPassphrase derives a key for a new message and puts the passphrase
+// header in front of what seal produces with it.
+func SealWithPassphrase(passphrase []byte, params KDFParams, seal func(key []byte) ([]byte, error)) ([]byte, error) {
+	key, header, err := NewPassphraseKey(passphrase, params, passphraseKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(key)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, ciphertext...), nil
+}
+
+// OpenWithPassphrase reverses SealWithPassphrase, passing open the derived
+// key and the data after the header.
+func OpenWithPassphrase(passphrase, data []byte, open func(key, ciphertext []byte) ([]byte, error)) ([]byte, error) {
+	key, ciphertext, err := OpenPassphraseKey(passphrase, data, passphraseKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return open(key, ciphertext)
+}
+
+// EncryptAESPassphrase encrypts data with AES-256 under a key derived from
+// passphrase. The result is the passphrase header followed by the output of EncryptAES.
+func EncryptAESPassphrase(mode BlockMode, passphrase []byte, params KDFParams, data []byte) ([]byte, error) {
+	return SealWithPassphrase(passphrase, params, func(key []byte) ([]byte, error) {
+		return EncryptAES(mode, key, data)
+	})
+}
+
+// DecryptAESPassphrase reverses EncryptAESPassphrase.
+func DecryptAESPassphrase(mode BlockMode, passphrase, data []byte) ([]byte, error) {
+	return OpenWithPassphrase(passphrase, data, func(key, ciphertext []byte) ([]byte, error) {
+		return DecryptAES(mode, key, ciphertext)
+	})
+}
+
+// EncryptChaCha20Passphrase encrypts data under a key derived from
+// passphrase. In ChaCha20 mode a random 12 byte nonce follows the passphrase
+// header; the AEAD modes append the output of SealChaCha20.
+func EncryptChaCha20Passphrase(mode ChaChaMode, passphrase []byte, params KDFParams, counter uint32, aad, data []byte) ([]byte, error) {
+	return SealWithPassphrase(passphrase, params, func(key []byte) ([]byte, error) {
+		if mode != ChaChaModeRaw {
+			return SealChaCha20(mode, key, aad, data)
+		}
+
+		nonce := make([]byte, 12)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		ciphertext, err := XORChaCha20(key, nonce, counter, data)
+		if err != nil {
+			return nil, err
+		}
+		return append(nonce, ciphertext...), nil
+	})
+}
+
+// DecryptChaCha20Passphrase reverses EncryptChaCha20Passphrase.
+func DecryptChaCha20Passphrase(mode ChaChaMode, passphrase []byte, counter uint32, aad, data []byte) ([]byte, error) {
+	return OpenWithPassphrase(passphrase, data, func(key, ciphertext []byte) ([]byte, error) {
+		if mode != ChaChaModeRaw {
+			return OpenChaCha20(mode, key, aad, ciphertext)
+		}
+		if len(ciphertext) < 12 {
+			return nil, ErrCiphertextTooShort
+		}
+		return XORChaCha20(key, ciphertext[:12], counter, ciphertext[12:])
+	})
+}
+
+// passphraseParams are the params shared by operations that accept a
+// passphrase in place of a raw key.
+func passphraseParams() []Param {
+	return []Param{
+		{Name: "passphrase", Kind: KindBytes, Usage: "passphrase to derive the key from, instead of -key"},
+		{Name: "kdf", Kind: KindString, Choices: PassphraseKDFs(), Default: KDFArgon2id.String(), Usage: "key derivation for -passphrase"},
+	}
+}
+
+// passphraseKDF reads the kdf option of an operation with passphraseParams.
+func passphraseKDF(opts Options) (KDFParams, error) {
+	kdf, err := ParseKDF(opts.String("kdf"))
+	if err != nil {
+		return KDFParams{}, err
+	}
+	return DefaultKDFParams(kdf), nil
+}
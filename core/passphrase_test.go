@@ -0,0 +1,127 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// cheap parameters, so the tests do not spend their time in the KDF
+var testKDFParams = KDFParams{KDF: KDFScrypt, LogN: 10, R: 8, P: 1}
+
+func TestPassphraseRoundTrip(t *testing.T) {
+	passphrase := []byte("correct horse battery staple")
+	data := []byte("meet me at the usual place")
+
+	for _, params := range []KDFParams{
+		testKDFParams,
+		{KDF: KDFPBKDF2, Iterations: 1000},
+		{KDF: KDFArgon2id, Iterations: 1, Memory: 64, Threads: 1},
+		{KDF: KDFArgon2i, Iterations: 1, Memory: 64, Threads: 2},
+	} {
+		encrypted, err := EncryptAESPassphrase(BlockModeGCM, passphrase, params, data)
+		if err != nil {
+			t.Fatalf("%v: %v", params.KDF, err)
+		}
+		decrypted, err := DecryptAESPassphrase(BlockModeGCM, passphrase, encrypted)
+		if err != nil {
+			t.Fatalf("%v decrypt: %v", params.KDF, err)
+		}
+		if !bytes.Equal(decrypted, data) {
+			t.Errorf("%v: round trip gave %q", params.KDF, decrypted)
+		}
+		if _, err := DecryptAESPassphrase(BlockModeGCM, []byte("wrong"), encrypted); err == nil {
+			t.Errorf("%v: decrypted with the wrong passphrase", params.KDF)
+		}
+	}
+
+	for _, mode := range []ChaChaMode{ChaChaModeRaw, ChaChaModeXPoly1305} {
+		encrypted, err := EncryptChaCha20Passphrase(mode, passphrase, testKDFParams, 1, nil, data)
+		if err != nil {
+			t.Fatalf("%v: %v", mode, err)
+		}
+		decrypted, err := DecryptChaCha20Passphrase(mode, passphrase, 1, nil, encrypted)
+		if err != nil {
+			t.Fatalf("%v decrypt: %v", mode, err)
+		}
+		if !bytes.Equal(decrypted, data) {
+			t.Errorf("%v: round trip gave %q", mode, decrypted)
+		}
+	}
+}
+
+// passphraseHeader builds a header with the given KDF fields and a 16 byte salt.
+func passphraseHeader(kdf KDF, fields ...any) []byte {
+	header := append([]byte(passphraseMagic), passphraseVersion, byte(kdf))
+	for _, field := range fields {
+		header, _ = binary.Append(header, binary.BigEndian, field)
+	}
+	header = append(header, passphraseSaltSize)
+	return append(header, make([]byte, passphraseSaltSize)...)
+}
+
+func TestPassphraseHeaderLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		err    error
+	}{
+		{"PBKDF2 default", passphraseHeader(KDFPBKDF2, uint32(600_000)), nil},
+		{"PBKDF2 at the limit", passphraseHeader(KDFPBKDF2, uint32(maxHeaderIterations)), nil},
+		{"PBKDF2 1e8 iterations", passphraseHeader(KDFPBKDF2, uint32(100_000_000)), ErrKDFTooCostly},
+		{"PBKDF2 no iterations", passphraseHeader(KDFPBKDF2, uint32(0)), ErrKDFParams},
+		{"scrypt default", passphraseHeader(KDFScrypt, uint8(17), uint32(8), uint32(1)), nil},
+		{"scrypt 1 GiB", passphraseHeader(KDFScrypt, uint8(20), uint32(8), uint32(1)), nil},
+		{"scrypt 4 GiB", passphraseHeader(KDFScrypt, uint8(22), uint32(8), uint32(1)), ErrKDFTooCostly},
+		{"scrypt parallel", passphraseHeader(KDFScrypt, uint8(20), uint32(8), uint32(16)), ErrKDFTooCostly},
+		{"scrypt N=2^30", passphraseHeader(KDFScrypt, uint8(30), uint32(8), uint32(1)), ErrKDFParams},
+		{"Argon2id default", passphraseHeader(KDFArgon2id, uint32(2), uint32(19*1024), uint8(1)), nil},
+		{"Argon2id 1 GiB", passphraseHeader(KDFArgon2id, uint32(4), uint32(1<<20), uint8(4)), nil},
+		{"Argon2id 4 GiB x 1000", passphraseHeader(KDFArgon2id, uint32(1000), uint32(4<<20), uint8(1)), ErrKDFTooCostly},
+		{"Argon2i many passes", passphraseHeader(KDFArgon2i, uint32(100), uint32(64<<10), uint8(1)), ErrKDFTooCostly},
+		{"Argon2id no threads", passphraseHeader(KDFArgon2id, uint32(2), uint32(19*1024), uint8(0)), ErrKDFParams},
+		{"unknown KDF", passphraseHeader(KDF(9), uint32(1)), ErrUnknownAlgorithm},
+		{"truncated", passphraseHeader(KDFScrypt, uint8(17))[:8], ErrCiphertextTooShort},
+		{"not a header", []byte("CHFY\x01"), ErrPassphraseHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// parse only: deriving with the accepted costs takes seconds
+			_, _, err := parsePassphraseHeader(tt.header)
+			if tt.err == nil && err != nil {
+				t.Fatalf("header rejected: %v", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				// OpenPassphraseKey must fail before deriving anything
+				if _, _, err := OpenPassphraseKey([]byte("x"), tt.header, 32); !errors.Is(err, tt.err) {
+					t.Errorf("OpenPassphraseKey error = %v, want %v", err, tt.err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPassphraseKeyLimits(t *testing.T) {
+	// Validate accepts these, but no header may ask for them
+	for _, params := range []KDFParams{
+		{KDF: KDFPBKDF2, Iterations: 50_000_000},
+		{KDF: KDFScrypt, LogN: 22, R: 8, P: 1},
+		{KDF: KDFArgon2id, Iterations: 1000, Memory: 4 << 20, Threads: 4},
+	} {
+		if err := params.Validate(); err != nil {
+			t.Fatalf("%v: %v", params.KDF, err)
+		}
+		if _, _, err := NewPassphraseKey([]byte("x"), params, 32); !errors.Is(err, ErrKDFTooCostly) {
+			t.Errorf("%v: error = %v, want ErrKDFTooCostly", params.KDF, err)
+		}
+	}
+
+	if _, _, err := NewPassphraseKey([]byte("x"), KDFParams{KDF: KDFPBKDF2, Hash: "sha512", Iterations: 1000}, 32); !errors.Is(err, ErrKDFParams) {
+		t.Errorf("PBKDF2-SHA512 error = %v, want ErrKDFParams", err)
+	}
+}
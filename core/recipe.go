@@ -0,0 +1,144 @@
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Step is one configured operation of a Recipe.
+type Step struct {
+	Op       *Operation
+	Options  Options
+	Disabled bool
+}
+
+// Recipe chains operations: each enabled step gets the previous step's output.
+type Recipe struct {
+	Steps []*Step
+}
+
+func (r *Recipe) Run(input []byte) ([]byte, error) {
+	data := input
+	for i, step := range r.Steps {
+		if step.Disabled {
+			continue
+		}
+		result, err := step.Op.Run(data, step.Options)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, step.Op.Name, err)
+		}
+		data = result
+	}
+	return data, nil
+}
+
+// Move swaps step i with its neighbour in direction delta (-1 or 1).
+func (r *Recipe) Move(i, delta int) {
+	j := i + delta
+	if i < 0 || j < 0 || i >= len(r.Steps) || j >= len(r.Steps) {
+		return
+	}
+	r.Steps[i], r.Steps[j] = r.Steps[j], r.Steps[i]
+}
+
+func (r *Recipe) Remove(i int) {
+	if i < 0 || i >= len(r.Steps) {
+		return
+	}
+	r.Steps = append(r.Steps[:i], r.Steps[i+1:]...)
+}
+
+// recipeFile is the JSON layout of a saved recipe. Byte options are stored as hex.
+type recipeFile struct {
+	Steps []stepFile `json:"steps"`
+}
+
+type stepFile struct {
+	Category  string                     `json:"category"`
+	Operation string                     `json:"operation"`
+	Disabled  bool                       `json:"disabled,omitempty"`
+	Options   map[string]json.RawMessage `json:"options,omitempty"`
+}
+
+func (r *Recipe) MarshalJSON() ([]byte, error) {
+	file := recipeFile{Steps: make([]stepFile, 0, len(r.Steps))}
+	for _, step := range r.Steps {
+		sf := stepFile{
+			Category:  step.Op.Category,
+			Operation: step.Op.Name,
+			Disabled:  step.Disabled,
+			Options:   map[string]json.RawMessage{},
+		}
+		for name, value := range step.Options {
+			if b, ok := value.([]byte); ok {
+				value = hex.EncodeToString(b)
+			}
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			sf.Options[name] = raw
+		}
+		file.Steps = append(file.Steps, sf)
+	}
+	return json.Marshal(file)
+}
+
+// ParseRecipe reads a recipe saved with MarshalJSON. lookup resolves the
+// category and operation names of every step.
+func ParseRecipe(data []byte, lookup func(category, name string) (*Operation, bool)) (*Recipe, error) {
+	var file recipeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	recipe := &Recipe{}
+	for i, sf := range file.Steps {
+		op, ok := lookup(sf.Category, sf.Operation)
+		if !ok {
+			return nil, fmt.Errorf("step %d: %w: %s/%s", i+1, ErrUnknownAlgorithm, sf.Category, sf.Operation)
+		}
+
+		opts := Options{}
+		for name, raw := range sf.Options {
+			p, ok := op.Param(name)
+			if !ok {
+				return nil, fmt.Errorf("step %d (%s): unknown option %q", i+1, op.Name, name)
+			}
+			value, err := p.unmarshal(raw)
+			if err != nil {
+				return nil, fmt.Errorf("step %d (%s): option %q: %w", i+1, op.Name, name, err)
+			}
+			opts[name] = value
+		}
+
+		recipe.Steps = append(recipe.Steps, &Step{Op: op, Options: opts, Disabled: sf.Disabled})
+	}
+
+	return recipe, nil
+}
+
+func (p Param) unmarshal(raw json.RawMessage) (any, error) {
+	switch p.Kind {
+	case KindBytes:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return hex.DecodeString(s)
+	case KindString:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	case KindInt:
+		var n int
+		err := json.Unmarshal(raw, &n)
+		return n, err
+	case KindBool:
+		var b bool
+		err := json.Unmarshal(raw, &b)
+		return b, err
+	}
+	return nil, fmt.Errorf("unsupported kind %s", p.Kind)
+}
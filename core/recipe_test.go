@@ -0,0 +1,153 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func recipeLookup(category, name string) (*Operation, bool) {
+	for _, op := range []*Operation{EncodeOperation("hex"), EncodeOperation("base64"), HMACOperation("sha256"), CompressOperation("gzip")} {
+		if op.Category == category && op.Name == name {
+			return op, true
+		}
+	}
+	return nil, false
+}
+
+func TestRecipeRun(t *testing.T) {
+	r := &Recipe{Steps: []*Step{
+		{Op: EncodeOperation("hex"), Options: Options{}},
+		{Op: EncodeOperation("base64"), Options: Options{}, Disabled: true},
+		{Op: EncodeOperation("hex"), Options: Options{Reverse: true}},
+		{Op: EncodeOperation("base64"), Options: Options{}},
+	}}
+	got, err := r.Run([]byte("chify"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "Y2hpZnk=" {
+		t.Errorf("got %q, want %q", got, "Y2hpZnk=")
+	}
+
+	if got, err := (&Recipe{}).Run([]byte("chify")); err != nil || string(got) != "chify" {
+		t.Errorf("empty recipe gave %q, %v", got, err)
+	}
+
+	r.Steps[2].Disabled = true
+	r.Steps = append(r.Steps, &Step{Op: EncodeOperation("hex"), Options: Options{Reverse: true}})
+	_, err = r.Run([]byte("chify"))
+	var invalid hex.InvalidByteError
+	if !errors.As(err, &invalid) || !strings.HasPrefix(err.Error(), "step 5 (hex): ") {
+		t.Errorf("error = %v, want an InvalidByteError from step 5", err)
+	}
+}
+
+func TestRecipeJSON(t *testing.T) {
+	r := &Recipe{Steps: []*Step{
+		{Op: HMACOperation("sha256"), Options: Options{"key": []byte{0, 1, 0xfe, 0xff}}},
+		{Op: EncodeOperation("hex"), Options: Options{Reverse: false}, Disabled: true},
+		{Op: CompressOperation("gzip"), Options: Options{}},
+	}}
+	data, err := r.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"key":"0001feff"`)) {
+		t.Errorf("byte option is not hex in %s", data)
+	}
+
+	parsed, err := ParseRecipe(data, recipeLookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Steps) != len(r.Steps) {
+		t.Fatalf("parsed %d steps, want %d", len(parsed.Steps), len(r.Steps))
+	}
+	for i, step := range parsed.Steps {
+		want := r.Steps[i]
+		if step.Op.Category != want.Op.Category || step.Op.Name != want.Op.Name || step.Disabled != want.Disabled {
+			t.Errorf("step %d: %s/%s disabled %v", i+1, step.Op.Category, step.Op.Name, step.Disabled)
+		}
+	}
+	if key := parsed.Steps[0].Options.Bytes("key"); !bytes.Equal(key, []byte{0, 1, 0xfe, 0xff}) {
+		t.Errorf("key parsed to %x", key)
+	}
+	if _, ok := parsed.Steps[1].Options[Reverse].(bool); !ok {
+		t.Errorf("reverse parsed to %T", parsed.Steps[1].Options[Reverse])
+	}
+
+	want, _ := r.Run(sunscreen)
+	if got, err := parsed.Run(sunscreen); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("parsed recipe gave %x, %v", got, err)
+	}
+
+	for _, tt := range []struct {
+		name, data string
+	}{
+		{"unknown operation", `{"steps":[{"category":"hash","operation":"whirlpool"}]}`},
+		{"unknown option", `{"steps":[{"category":"encode","operation":"hex","options":{"mode":"CBC"}}]}`},
+		{"bad hex option", `{"steps":[{"category":"hash","operation":"hmac-sha256","options":{"key":"zz"}}]}`},
+		{"wrong option type", `{"steps":[{"category":"encode","operation":"hex","options":{"reverse":"yes"}}]}`},
+		{"not JSON", `steps`},
+	} {
+		if _, err := ParseRecipe([]byte(tt.data), recipeLookup); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+	if _, err := ParseRecipe([]byte(`{"steps":[{"category":"hash","operation":"whirlpool"}]}`), recipeLookup); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("unknown operation error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
+
+func TestRecipeEdit(t *testing.T) {
+	hexOp, b64Op, gzipOp := EncodeOperation("hex"), EncodeOperation("base64"), CompressOperation("gzip")
+	newRecipe := func() *Recipe {
+		return &Recipe{Steps: []*Step{{Op: hexOp}, {Op: b64Op}, {Op: gzipOp}}}
+	}
+	names := func(r *Recipe) string {
+		var s []string
+		for _, step := range r.Steps {
+			s = append(s, step.Op.Name)
+		}
+		return strings.Join(s, ",")
+	}
+
+	moves := []struct {
+		i, delta int
+		want     string
+	}{
+		{0, 1, "base64,hex,gzip"},
+		{2, -1, "hex,gzip,base64"},
+		{0, -1, "hex,base64,gzip"},
+		{2, 1, "hex,base64,gzip"},
+		{-1, 1, "hex,base64,gzip"},
+		{3, -1, "hex,base64,gzip"},
+	}
+	for _, tt := range moves {
+		r := newRecipe()
+		r.Move(tt.i, tt.delta)
+		if got := names(r); got != tt.want {
+			t.Errorf("Move(%d, %d) = %s, want %s", tt.i, tt.delta, got, tt.want)
+		}
+	}
+
+	removes := []struct {
+		i    int
+		want string
+	}{
+		{0, "base64,gzip"},
+		{2, "hex,base64"},
+		{-1, "hex,base64,gzip"},
+		{3, "hex,base64,gzip"},
+	}
+	for _, tt := range removes {
+		r := newRecipe()
+		r.Remove(tt.i)
+		if got := names(r); got != tt.want {
+			t.Errorf("Remove(%d) = %s, want %s", tt.i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package core
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// RSAScheme is an RSA encryption or signature scheme.
+type RSAScheme int
+
+const (
+	RSASchemeOAEP            RSAScheme = iota // encryption, RSAES-OAEP
+	RSASchemePKCS1v15Encrypt                  // encryption, RSAES-PKCS1-v1_5
+	RSASchemePSS                              // signature, RSASSA-PSS
+	RSASchemePKCS1v15Sign                     // signature, RSASSA-PKCS1-v1_5
+)
+
+var rsaSchemes = []string{"OAEP", "PKCS#1 v1.5", "PSS", "PKCS#1 v1.5 signature"}
+
+func (s RSAScheme) String() string {
+	return rsaSchemes[s]
+}
+
+func (s RSAScheme) Signature() bool {
+	return s == RSASchemePSS || s == RSASchemePKCS1v15Sign
+}
+
+func RSASchemes() []string {
+	return append([]string(nil), rsaSchemes...)
+}
+
+func ParseRSAScheme(s string) (RSAScheme, error) {
+	for i, name := range rsaSchemes {
+		if name == s {
+			return RSAScheme(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: RSA scheme %q", ErrUnknownAlgorithm, s)
+}
+
+// RSAKeySizes are the modulus sizes offered for new keys.
+var RSAKeySizes = []int{2048, 3072, 4096}
+
+// PEM encodings of RSA keys: PKCS #1 is "RSA PRIVATE KEY" / "RSA PUBLIC
+// KEY", PKCS #8 "PRIVATE KEY" and SPKI "PUBLIC KEY".
+var (
+	RSAPrivateKeyFormats = []string{"PKCS#8", "PKCS#1"}
+	RSAPublicKeyFormats  = []string{"SPKI", "PKCS#1"}
+)
+
+var ErrRSAKey = errors.New("not an RSA key")
+
+var cryptoHashes = map[string]crypto.Hash{
+	"sha1":       crypto.SHA1,
+	"sha224":     crypto.SHA224,
+	"sha256":     crypto.SHA256,
+	"sha3-224":   crypto.SHA3_224,
+	"sha3-256":   crypto.SHA3_256,
+	"sha3-384":   crypto.SHA3_384,
+	"sha3-512":   crypto.SHA3_512,
+	"sha512-224": crypto.SHA512_224,
+	"sha512-256": crypto.SHA512_256,
+	"sha384":     crypto.SHA384,
+	"sha512":     crypto.SHA512,
+}
+
+// cryptoHash maps a name of ShaAlgorithms to its crypto.Hash.
+func cryptoHash(name string) (crypto.Hash, error) {
+	h, ok := cryptoHashes[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+	}
+	return h, nil
+}
+
+func GenerateRSAKey(bits int) (*rsa.PrivateKey, error) {
+	return rsa.GenerateKey(rand.Reader, bits)
+}
+
+func EncodeRSAPrivateKey(key *rsa.PrivateKey, format string) ([]byte, error) {
+	switch format {
+	case "PKCS#1":
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), nil
+	case "PKCS#8":
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+	return nil, fmt.Errorf("%w: private key format %q", ErrUnknownAlgorithm, format)
+}
+
+func EncodeRSAPublicKey(key *rsa.PublicKey, format string) ([]byte, error) {
+	switch format {
+	case "PKCS#1":
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(key)}), nil
+	case "SPKI":
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+	}
+	return nil, fmt.Errorf("%w: public key format %q", ErrUnknownAlgorithm, format)
+}
+
+// ParseRSAPrivateKey reads a PKCS #1 or PKCS #8 key, PEM or DER.
+func ParseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRSAKey, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: PKCS #8 key is %T", ErrRSAKey, parsed)
+	}
+	return key, nil
+}
+
+// ParseRSAPublicKey reads an SPKI or PKCS #1 public key, PEM or DER. The
+// public half of a private key or a certificate is accepted as well.
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := cert.PublicKey.(*rsa.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("%w: certificate key is %T", ErrRSAKey, cert.PublicKey)
+			}
+			return key, nil
+		case "RSA PRIVATE KEY", "PRIVATE KEY":
+			key, err := ParseRSAPrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return &key.PublicKey, nil
+		}
+		data = block.Bytes
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(data); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRSAKey, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: public key is %T", ErrRSAKey, parsed)
+	}
+	return key, nil
+}
+
+// EncryptRSA encrypts with OAEP, whose hash also drives MGF1, or PKCS #1
+// v1.5, which ignores hash and label.
+func EncryptRSA(key *rsa.PublicKey, scheme RSAScheme, hash string, label, data []byte) ([]byte, error) {
+	switch scheme {
+	case RSASchemeOAEP:
+		newHash, err := HashConstructor(hash)
+		if err != nil {
+			return nil, err
+		}
+		return rsa.EncryptOAEP(newHash(), rand.Reader, key, data, label)
+	case RSASchemePKCS1v15Encrypt:
+		return rsa.EncryptPKCS1v15(rand.Reader, key, data)
+	}
+	return nil, fmt.Errorf("%w: %v is not an encryption scheme", ErrUnknownAlgorithm, scheme)
+}
+
+func DecryptRSA(key *rsa.PrivateKey, scheme RSAScheme, hash string, label, data []byte) ([]byte, error) {
+	switch scheme {
+	case RSASchemeOAEP:
+		newHash, err := HashConstructor(hash)
+		if err != nil {
+			return nil, err
+		}
+		return rsa.DecryptOAEP(newHash(), rand.Reader, key, data, label)
+	case RSASchemePKCS1v15Encrypt:
+		return rsa.DecryptPKCS1v15(rand.Reader, key, data)
+	}
+	return nil, fmt.Errorf("%w: %v is not an encryption scheme", ErrUnknownAlgorithm, scheme)
+}
+
+// SignRSA hashes data and signs the digest. PSS uses a salt as long as the hash.
+func SignRSA(key *rsa.PrivateKey, scheme RSAScheme, hash string, data []byte) ([]byte, error) {
+	h, digest, err := rsaDigest(hash, data)
+	if err != nil {
+		return nil, err
+	}
+	switch scheme {
+	case RSASchemePSS:
+		return rsa.SignPSS(rand.Reader, key, h, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case RSASchemePKCS1v15Sign:
+		return rsa.SignPKCS1v15(rand.Reader, key, h, digest)
+	}
+	return nil, fmt.Errorf("%w: %v is not a signature scheme", ErrUnknownAlgorithm, scheme)
+}
+
+// VerifyRSA reports whether signature is valid for data. PSS signatures
+// are accepted with any salt length.
+func VerifyRSA(key *rsa.PublicKey, scheme RSAScheme, hash string, data, signature []byte) (bool, error) {
+	h, digest, err := rsaDigest(hash, data)
+	if err != nil {
+		return false, err
+	}
+	switch scheme {
+	case RSASchemePSS:
+		return rsa.VerifyPSS(key, h, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) == nil, nil
+	case RSASchemePKCS1v15Sign:
+		return rsa.VerifyPKCS1v15(key, h, digest, signature) == nil, nil
+	}
+	return false, fmt.Errorf("%w: %v is not a signature scheme", ErrUnknownAlgorithm, scheme)
+}
+
+func rsaDigest(hash string, data []byte) (crypto.Hash, []byte, error) {
+	h, err := cryptoHash(hash)
+	if err != nil {
+		return 0, nil, err
+	}
+	digest, err := Hash(hash, data)
+	if err != nil {
+		return 0, nil, err
+	}
+	return h, digest, nil
+}
+
+func RSAOperation() *Operation {
+	return &Operation{
+		Name:     "rsa",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "scheme", Kind: KindString, Choices: rsaSchemes[:2], Default: "OAEP", Usage: "encryption scheme"},
+			{Name: "hash", Kind: KindString, Choices: ShaAlgorithms, Default: "sha256", Usage: "OAEP and MGF1 digest"},
+			{Name: "label", Kind: KindBytes, Usage: "OAEP label"},
+			{Name: "key", Kind: KindBytes, Usage: "PEM or DER key, public to encrypt, private to decrypt"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decrypt instead of encrypt"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			scheme, err := ParseRSAScheme(opts.String("scheme"))
+			if err != nil {
+				return nil, err
+			}
+			if opts.Bool(Reverse) {
+				key, err := ParseRSAPrivateKey(opts.Bytes("key"))
+				if err != nil {
+					return nil, err
+				}
+				return DecryptRSA(key, scheme, opts.String("hash"), opts.Bytes("label"), input)
+			}
+			key, err := ParseRSAPublicKey(opts.Bytes("key"))
+			if err != nil {
+				return nil, err
+			}
+			return EncryptRSA(key, scheme, opts.String("hash"), opts.Bytes("label"), input)
+		},
+	}
+}
+
+func RSASignOperation() *Operation {
+	return &Operation{
+		Name:     "rsa-sign",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "scheme", Kind: KindString, Choices: rsaSchemes[2:], Default: "PSS", Usage: "signature scheme"},
+			{Name: "hash", Kind: KindString, Choices: ShaAlgorithms, Default: "sha256", Usage: "message digest"},
+			{Name: "key", Kind: KindBytes, Usage: "PEM or DER key, private to sign, public to verify"},
+			{Name: "signature", Kind: KindBytes, Usage: "detached signature to verify instead of signing"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			scheme, err := ParseRSAScheme(opts.String("scheme"))
+			if err != nil {
+				return nil, err
+			}
+			if signature := opts.Bytes("signature"); signature != nil {
+				key, err := ParseRSAPublicKey(opts.Bytes("key"))
+				if err != nil {
+					return nil, err
+				}
+				valid, err := VerifyRSA(key, scheme, opts.String("hash"), input, signature)
+				if err != nil {
+					return nil, err
+				}
+				if !valid {
+					return nil, ErrInvalidSignature
+				}
+				return input, nil
+			}
+			key, err := ParseRSAPrivateKey(opts.Bytes("key"))
+			if err != nil {
+				return nil, err
+			}
+			return SignRSA(key, scheme, opts.String("hash"), input)
+		},
+	}
+}
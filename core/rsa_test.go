@@ -0,0 +1,267 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testdata/rsa holds a 2048 bit key written by OpenSSL 3.0 in every format
+// chify reads, a self-signed certificate for it, and vectors.json with
+// signatures and ciphertexts of the message made by OpenSSL:
+//
+//	openssl dgst -<hash> -sign pkcs8.pem [-sigopt rsa_padding_mode:pss -sigopt rsa_pss_saltlen:digest]
+//	openssl pkeyutl -encrypt -pubin -inkey spki.pem -pkeyopt rsa_padding_mode:oaep \
+//		-pkeyopt rsa_oaep_md:<hash> -pkeyopt rsa_mgf1_md:<hash> -pkeyopt rsa_oaep_label:<label hex>
+//	openssl pkeyutl -encrypt -pubin -inkey spki.pem -pkeyopt rsa_padding_mode:pkcs1
+type rsaVectors struct {
+	Message         string            `json:"message"`
+	Label           string            `json:"label"`
+	PKCS1v15        map[string]string `json:"pkcs1v15"`
+	PSS             map[string]string `json:"pss"`
+	OAEP            map[string]string `json:"oaep"`
+	PKCS1v15Encrypt string            `json:"pkcs1v15-encrypt"`
+}
+
+func readRSAFile(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/rsa/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func readRSAVectors(t *testing.T) *rsaVectors {
+	t.Helper()
+	var v rsaVectors
+	if err := json.Unmarshal(readRSAFile(t, "vectors.json"), &v); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestRSAKeyFormats(t *testing.T) {
+	private, err := ParseRSAPrivateKey(readRSAFile(t, "pkcs8.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"pkcs1.pem", "pkcs8.pem"} {
+		data := readRSAFile(t, name)
+		key, err := ParseRSAPrivateKey(data)
+		if err != nil || !key.Equal(private) {
+			t.Errorf("%s: parsed to another key, %v", name, err)
+		}
+		block, _ := pem.Decode(data)
+		if key, err := ParseRSAPrivateKey(block.Bytes); err != nil || !key.Equal(private) {
+			t.Errorf("%s DER: parsed to another key, %v", name, err)
+		}
+	}
+	for _, name := range []string{"spki.pem", "pkcs1-public.pem", "cert.pem", "pkcs1.pem", "pkcs8.pem"} {
+		data := readRSAFile(t, name)
+		key, err := ParseRSAPublicKey(data)
+		if err != nil || !key.Equal(&private.PublicKey) {
+			t.Errorf("%s: parsed to another public key, %v", name, err)
+		}
+		// DER is read for public key encodings only
+		if block, _ := pem.Decode(data); strings.HasSuffix(block.Type, "PUBLIC KEY") {
+			if key, err := ParseRSAPublicKey(block.Bytes); err != nil || !key.Equal(&private.PublicKey) {
+				t.Errorf("%s DER: parsed to another public key, %v", name, err)
+			}
+		}
+	}
+
+	// the encoders write what OpenSSL writes
+	for format, name := range map[string]string{"PKCS#1": "pkcs1.pem", "PKCS#8": "pkcs8.pem"} {
+		got, err := EncodeRSAPrivateKey(private, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := readRSAFile(t, name); !bytes.Equal(got, want) {
+			t.Errorf("%s private key:\n%s\nwant\n%s", format, got, want)
+		}
+	}
+	for format, name := range map[string]string{"PKCS#1": "pkcs1-public.pem", "SPKI": "spki.pem"} {
+		got, err := EncodeRSAPublicKey(&private.PublicKey, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := readRSAFile(t, name); !bytes.Equal(got, want) {
+			t.Errorf("%s public key:\n%s\nwant\n%s", format, got, want)
+		}
+	}
+	if _, err := EncodeRSAPrivateKey(private, "JWK"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("unknown private format error = %v", err)
+	}
+	if _, err := EncodeRSAPublicKey(&private.PublicKey, "JWK"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("unknown public format error = %v", err)
+	}
+
+	ecdsaKey, err := GenerateECDSAKey("P-256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaPEM, err := EncodeECDSAPrivateKey(ecdsaKey, "PEM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseRSAPrivateKey(ecdsaPEM); !errors.Is(err, ErrRSAKey) {
+		t.Errorf("ECDSA private key error = %v, want ErrRSAKey", err)
+	}
+	if _, err := ParseRSAPublicKey([]byte("not a key")); !errors.Is(err, ErrRSAKey) {
+		t.Errorf("garbage public key error = %v, want ErrRSAKey", err)
+	}
+}
+
+func TestRSASignatures(t *testing.T) {
+	v := readRSAVectors(t)
+	message := []byte(v.Message)
+	key, err := ParseRSAPrivateKey(readRSAFile(t, "pkcs1.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateRSAKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hash := range ShaAlgorithms {
+		// PKCS #1 v1.5 signatures are deterministic
+		want := fromHex(v.PKCS1v15[hash])
+		got, err := SignRSA(key, RSASchemePKCS1v15Sign, hash, message)
+		if err != nil {
+			t.Fatalf("%s: %v", hash, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: PKCS #1 v1.5 signature %x, want %x", hash, got, want)
+		}
+
+		for _, tt := range []struct {
+			scheme    RSAScheme
+			signature []byte
+		}{
+			{RSASchemePKCS1v15Sign, want},
+			{RSASchemePSS, fromHex(v.PSS[hash])},
+		} {
+			if ok, err := VerifyRSA(&key.PublicKey, tt.scheme, hash, message, tt.signature); err != nil || !ok {
+				t.Errorf("%s %v: OpenSSL signature did not verify: %v", hash, tt.scheme, err)
+			}
+			if ok, _ := VerifyRSA(&key.PublicKey, tt.scheme, hash, message[1:], tt.signature); ok {
+				t.Errorf("%s %v: signature verified for other data", hash, tt.scheme)
+			}
+			if ok, _ := VerifyRSA(&other.PublicKey, tt.scheme, hash, message, tt.signature); ok {
+				t.Errorf("%s %v: signature verified with the wrong key", hash, tt.scheme)
+			}
+		}
+
+		pss, err := SignRSA(key, RSASchemePSS, hash, message)
+		if err != nil {
+			t.Fatalf("%s PSS: %v", hash, err)
+		}
+		if ok, err := VerifyRSA(&key.PublicKey, RSASchemePSS, hash, message, pss); err != nil || !ok {
+			t.Errorf("%s: PSS round trip did not verify: %v", hash, err)
+		}
+	}
+
+	if _, err := SignRSA(key, RSASchemeOAEP, "sha256", message); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("signing with OAEP error = %v, want ErrUnknownAlgorithm", err)
+	}
+	if _, err := SignRSA(key, RSASchemePSS, "md5", message); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("MD5 error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
+
+func TestRSAEncryption(t *testing.T) {
+	v := readRSAVectors(t)
+	message, label := []byte(v.Message), []byte(v.Label)
+	key, err := ParseRSAPrivateKey(readRSAFile(t, "pkcs8.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateRSAKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hash := range ShaAlgorithms {
+		ciphertext := fromHex(v.OAEP[hash])
+		if got, err := DecryptRSA(key, RSASchemeOAEP, hash, label, ciphertext); err != nil || !bytes.Equal(got, message) {
+			t.Errorf("%s: OpenSSL OAEP ciphertext decrypted to %q, %v", hash, got, err)
+		}
+		if _, err := DecryptRSA(key, RSASchemeOAEP, hash, []byte("other"), ciphertext); err == nil {
+			t.Errorf("%s: decrypted with the wrong label", hash)
+		}
+		if _, err := DecryptRSA(other, RSASchemeOAEP, hash, label, ciphertext); err == nil {
+			t.Errorf("%s: decrypted with the wrong key", hash)
+		}
+
+		ciphertext, err := EncryptRSA(&key.PublicKey, RSASchemeOAEP, hash, label, message)
+		if err != nil {
+			t.Fatalf("%s: %v", hash, err)
+		}
+		if got, err := DecryptRSA(key, RSASchemeOAEP, hash, label, ciphertext); err != nil || !bytes.Equal(got, message) {
+			t.Errorf("%s: OAEP round trip gave %q, %v", hash, got, err)
+		}
+	}
+
+	ciphertext := fromHex(v.PKCS1v15Encrypt)
+	if got, err := DecryptRSA(key, RSASchemePKCS1v15Encrypt, "", nil, ciphertext); err != nil || !bytes.Equal(got, message) {
+		t.Errorf("OpenSSL PKCS #1 v1.5 ciphertext decrypted to %q, %v", got, err)
+	}
+	if _, err := DecryptRSA(other, RSASchemePKCS1v15Encrypt, "", nil, ciphertext); err == nil {
+		t.Error("PKCS #1 v1.5 ciphertext decrypted with the wrong key")
+	}
+	ciphertext, err = EncryptRSA(&key.PublicKey, RSASchemePKCS1v15Encrypt, "", nil, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DecryptRSA(key, RSASchemePKCS1v15Encrypt, "", nil, ciphertext); err != nil || !bytes.Equal(got, message) {
+		t.Errorf("PKCS #1 v1.5 round trip gave %q, %v", got, err)
+	}
+
+	if _, err := EncryptRSA(&key.PublicKey, RSASchemePSS, "sha256", nil, message); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("encrypting with PSS error = %v, want ErrUnknownAlgorithm", err)
+	}
+}
+
+func TestRSAOperations(t *testing.T) {
+	v := readRSAVectors(t)
+	message := []byte(v.Message)
+	private, public := readRSAFile(t, "pkcs1.pem"), readRSAFile(t, "cert.pem")
+
+	opts := Options{"scheme": "OAEP", "hash": "sha384", "label": []byte(v.Label), "key": public}
+	ciphertext, err := RSAOperation().Run(message, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts["key"], opts[Reverse] = private, true
+	if got, err := RSAOperation().Run(ciphertext, opts); err != nil || !bytes.Equal(got, message) {
+		t.Errorf("operation round trip gave %q, %v", got, err)
+	}
+
+	signOpts := Options{"scheme": "PKCS#1 v1.5 signature", "hash": "sha256", "key": private}
+	signature, err := RSASignOperation().Run(message, signOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fromHex(v.PKCS1v15["sha256"]); !bytes.Equal(signature, want) {
+		t.Errorf("operation signature %x, want %x", signature, want)
+	}
+
+	verifyOpts := Options{"scheme": "PKCS#1 v1.5 signature", "hash": "sha256", "key": public, "signature": signature}
+	if got, err := RSASignOperation().Run(message, verifyOpts); err != nil || !bytes.Equal(got, message) {
+		t.Errorf("verify gave %q, %v", got, err)
+	}
+	verifyOpts["scheme"], verifyOpts["signature"] = "PSS", fromHex(v.PSS["sha256"])
+	if _, err := RSASignOperation().Run(message, verifyOpts); err != nil {
+		t.Errorf("PSS verify: %v", err)
+	}
+	if _, err := RSASignOperation().Run(message[1:], verifyOpts); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("changed message error = %v, want ErrInvalidSignature", err)
+	}
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/salsa20"
+)
+
+type SalsaMode int
+
+const (
+	SalsaModeSalsa20   SalsaMode = iota // 8 byte nonce, unauthenticated
+	SalsaModeXSalsa20                   // 24 byte nonce, unauthenticated
+	SalsaModeSecretbox                  // NaCl secretbox, XSalsa20-Poly1305
+)
+
+var salsaModes = []string{"Salsa20", "XSalsa20", "secretbox"}
+
+func (m SalsaMode) String() string {
+	return salsaModes[m]
+}
+
+func SalsaModes() []string {
+	return append([]string(nil), salsaModes...)
+}
+
+func ParseSalsaMode(s string) (SalsaMode, error) {
+	for i, name := range salsaModes {
+		if name == s {
+			return SalsaMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: Salsa20 mode %q", ErrUnknownAlgorithm, s)
+}
+
+// NonceSize is the nonce size of the mode in bytes.
+func (m SalsaMode) NonceSize() int {
+	if m == SalsaModeSalsa20 {
+		return 8
+	}
+	return 24
+}
+
+// XORSalsa20 encrypts or decrypts data with Salsa20 (8 byte nonce) or
+// XSalsa20 (24 byte nonce) under a 32 byte key.
+func XORSalsa20(key, nonce, data []byte) ([]byte, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid Salsa20 key size %d, must be 32", len(key))
+	}
+	if len(nonce) != 8 && len(nonce) != 24 {
+		return nil, fmt.Errorf("invalid Salsa20 nonce size %d, must be 8 or 24", len(nonce))
+	}
+
+	result := make([]byte, len(data))
+	salsa20.XORKeyStream(result, data, nonce, (*[32]byte)(key))
+
+	return result, nil
+}
+
+// SealSecretbox encrypts and authenticates data like libsodium's
+// crypto_secretbox_easy: the result is the 16 byte MAC followed by the
+// ciphertext. If nonce is empty a random one is used and prepended, which
+// is the usual nonce | MAC | ciphertext layout of libsodium users.
+func SealSecretbox(key, nonce, data []byte) ([]byte, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid secretbox key size %d, must be 32", len(key))
+	}
+
+	var out []byte
+	if len(nonce) == 0 {
+		nonce = make([]byte, 24)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		out = nonce
+	}
+	if len(nonce) != 24 {
+		return nil, fmt.Errorf("invalid secretbox nonce size %d, must be 24", len(nonce))
+	}
+
+	return secretbox.Seal(out, data, (*[24]byte)(nonce), (*[32]byte)(key)), nil
+}
+
+// OpenSecretbox reverses SealSecretbox with the same nonce, or reads the
+// nonce from the front of data if it is empty. A wrong key or any change to
+// the data gives ErrAuthentication.
+func OpenSecretbox(key, nonce, data []byte) ([]byte, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid secretbox key size %d, must be 32", len(key))
+	}
+
+	if len(nonce) == 0 {
+		if len(data) < 24 {
+			return nil, ErrCiphertextTooShort
+		}
+		nonce, data = data[:24], data[24:]
+	}
+	if len(nonce) != 24 {
+		return nil, fmt.Errorf("invalid secretbox nonce size %d, must be 24", len(nonce))
+	}
+	if len(data) < secretbox.Overhead {
+		return nil, ErrCiphertextTooShort
+	}
+
+	plaintext, ok := secretbox.Open(nil, data, (*[24]byte)(nonce), (*[32]byte)(key))
+	if !ok {
+		return nil, ErrAuthentication
+	}
+
+	return plaintext, nil
+}
+
+func Salsa20Operation() *Operation {
+	return &Operation{
+		Name:     "salsa20",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "mode", Kind: KindString, Choices: SalsaModes(), Default: "secretbox", Usage: "stream cipher or NaCl secretbox"},
+			{Name: "key", Kind: KindBytes, Usage: "32 byte key"},
+			{Name: "nonce", Kind: KindBytes, Usage: "8 (Salsa20) or 24 byte nonce, random and prepended for secretbox if empty"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decrypt instead of encrypt"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			mode, err := ParseSalsaMode(opts.String("mode"))
+			if err != nil {
+				return nil, err
+			}
+
+			key, nonce := opts.Bytes("key"), opts.Bytes("nonce")
+			switch {
+			case mode != SalsaModeSecretbox:
+				if len(nonce) != mode.NonceSize() {
+					return nil, fmt.Errorf("%v needs a %d byte nonce", mode, mode.NonceSize())
+				}
+				return XORSalsa20(key, nonce, input)
+			case opts.Bool(Reverse):
+				return OpenSecretbox(key, nonce, input)
+			default:
+				return SealSecretbox(key, nonce, input)
+			}
+		},
+	}
+}
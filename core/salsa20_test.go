@@ -0,0 +1,140 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// the first crypto_secretbox vector of NaCl and libsodium (test/default/secretbox.c)
+var (
+	secretboxKey       = fromHex("1b27556473e985d462cd51197a9a46c76009549eac6474f206c4ee0844f68389")
+	secretboxNonce     = fromHex("69696ee955b62b73cd62bda875fc73d68219e0036b7a0b37")
+	secretboxPlaintext = fromHex(`be075fc53c81f2d5cf141316ebeb0c7b5228c52a4c62cbd44b66849b64244ffc
+		e5ecbaaf33bd751a1ac728d45e6c61296cdc3c01233561f41db66cce314adb31
+		0e3be8250c46f06dceea3a7fa1348057e2f6556ad6b1318a024a838f21af1fde
+		048977eb48f59ffd4924ca1c60902e52f0a089bc76897040e082f93776384864
+		5e0705`)
+	secretboxCiphertext = fromHex(`f3ffc7703f9400e52a7dfb4b3d3305d9
+		8e993b9f48681273c29650ba32fc76ce48332ea7164d96a4476fb8c531a1186a
+		c0dfc17c98dce87b4da7f011ec48c97271d2c20f9b928fe2270d6fb863d51738
+		b48eeee314a7cc8ab932164548e526ae90224368517acfeabd6bb3732bc0e9da
+		99832b61ca01b6de56244a9e88d5f9b37973f622a43d14a6599b1f654cb45a74
+		e355a5`)
+)
+
+func TestXORSalsa20(t *testing.T) {
+	tests := []struct {
+		name, key, nonce string
+		plaintext        []byte
+		want             string
+	}{
+		{
+			// ECRYPT Salsa20/20 256 bit key, set 1, vector 0, stream[0..63]
+			"Salsa20",
+			"80000000000000000000000000000000 00000000000000000000000000000000",
+			"0000000000000000",
+			make([]byte, 64),
+			`e3be8fdd8beca2e3ea8ef9475b29a6e7003951e1097a5c38d23b7a5fad9f6844
+			b22c97559e2723c7cbbd3fe4fc8d9a0744652a83e72a9c461876af4d7ef1a117`,
+		},
+		{
+			// golang.org/x/crypto/salsa20 XSalsa20 vector
+			"XSalsa20",
+			"746869732069732033322d6279746520 6b657920666f72207873616c73613230",
+			"32342d62797465206e6f6e636520666f 72207873616c7361",
+			[]byte("Hello world!"),
+			"002d4513843fc240c401e541",
+		},
+	}
+
+	for _, tt := range tests {
+		key, nonce, want := fromHex(tt.key), fromHex(tt.nonce), fromHex(tt.want)
+		got, err := XORSalsa20(key, nonce, tt.plaintext)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: %x, want %x", tt.name, got, want)
+		}
+
+		opts := Options{"mode": tt.name, "key": key, "nonce": nonce, Reverse: true}
+		if got, err := Salsa20Operation().Run(want, opts); err != nil || !bytes.Equal(got, tt.plaintext) {
+			t.Errorf("%s operation: %x, %v", tt.name, got, err)
+		}
+	}
+
+	key := make([]byte, 32)
+	for _, tt := range []struct {
+		mode  string
+		key   []byte
+		nonce []byte
+	}{
+		{"Salsa20", key[:16], make([]byte, 8)},
+		{"Salsa20", key, make([]byte, 24)},
+		{"XSalsa20", key, make([]byte, 8)},
+		{"XSalsa20", key, nil},
+	} {
+		if _, err := Salsa20Operation().Run(sunscreen, Options{"mode": tt.mode, "key": tt.key, "nonce": tt.nonce}); err == nil {
+			t.Errorf("%s with %d byte key and %d byte nonce was accepted", tt.mode, len(tt.key), len(tt.nonce))
+		}
+	}
+}
+
+func TestSecretbox(t *testing.T) {
+	// crypto_secretbox_easy layout: MAC | ciphertext, the nonce is not included
+	got, err := SealSecretbox(secretboxKey, secretboxNonce, secretboxPlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secretboxCiphertext) {
+		t.Errorf("sealed %x, want %x", got, secretboxCiphertext)
+	}
+	opened, err := OpenSecretbox(secretboxKey, secretboxNonce, secretboxCiphertext)
+	if err != nil || !bytes.Equal(opened, secretboxPlaintext) {
+		t.Errorf("opened %x, %v", opened, err)
+	}
+
+	// without a nonce it is random and prepended
+	sealed, err := SealSecretbox(secretboxKey, nil, secretboxPlaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sealed) != 24+len(secretboxCiphertext) {
+		t.Errorf("sealed %d bytes, want nonce, MAC and ciphertext", len(sealed))
+	}
+	withNonce := append(bytes.Clone(secretboxNonce), secretboxCiphertext...)
+	for _, data := range [][]byte{sealed, withNonce} {
+		opened, err := OpenSecretbox(secretboxKey, nil, data)
+		if err != nil || !bytes.Equal(opened, secretboxPlaintext) {
+			t.Errorf("opened with prepended nonce: %x, %v", opened, err)
+		}
+	}
+
+	for i := range secretboxCiphertext {
+		tampered := bytes.Clone(secretboxCiphertext)
+		tampered[i] ^= 0x20
+		if _, err := OpenSecretbox(secretboxKey, secretboxNonce, tampered); !errors.Is(err, ErrAuthentication) {
+			t.Fatalf("byte %d flipped: error = %v, want ErrAuthentication", i, err)
+		}
+	}
+	otherKey := bytes.Clone(secretboxKey)
+	otherKey[0] ^= 1
+	if _, err := OpenSecretbox(otherKey, secretboxNonce, secretboxCiphertext); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("wrong key error = %v, want ErrAuthentication", err)
+	}
+	if _, err := OpenSecretbox(secretboxKey, secretboxNonce[1:], secretboxCiphertext); err == nil {
+		t.Error("23 byte nonce was accepted")
+	}
+	if _, err := OpenSecretbox(secretboxKey, secretboxNonce, secretboxCiphertext[:15]); !errors.Is(err, ErrCiphertextTooShort) {
+		t.Errorf("short box error = %v, want ErrCiphertextTooShort", err)
+	}
+	if _, err := OpenSecretbox(secretboxKey, nil, secretboxNonce[:20]); !errors.Is(err, ErrCiphertextTooShort) {
+		t.Errorf("short nonce error = %v, want ErrCiphertextTooShort", err)
+	}
+
+	opts := Options{"mode": "secretbox", "key": secretboxKey, "nonce": secretboxNonce}
+	if got, err := Salsa20Operation().Run(secretboxPlaintext, opts); err != nil || !bytes.Equal(got, secretboxCiphertext) {
+		t.Errorf("operation sealed %x, %v", got, err)
+	}
+}
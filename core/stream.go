@@ -0,0 +1,296 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Streaming encryption splits the plaintext into chunks that are sealed
+// separately (the STREAM construction), so files of any size can be
+// processed in constant memory. Every chunk nonce is
+//
+//	prefix (7 bytes) || chunk counter (4 bytes, big endian) || last flag (1 byte)
+//
+// which makes reordering, dropping or truncating chunks fail authentication.
+// The chunk key is derived with HKDF-SHA256 from the user key and a random
+// per-file salt, and the header is authenticated as associated data.
+//
+// Header layout:
+//
+//	magic "CHFY" || version || algorithm || chunk size (uint32) || salt (16) || nonce prefix (7)
+
+type StreamAlgorithm byte
+
+const (
+	StreamAESGCM StreamAlgorithm = iota + 1
+	StreamChaCha20Poly1305
+)
+
+var streamAlgorithms = []string{"AES-GCM", "ChaCha20-Poly1305"}
+
+func (a StreamAlgorithm) String() string {
+	return streamAlgorithms[a-1]
+}
+
+func StreamAlgorithms() []string {
+	return append([]string(nil), streamAlgorithms...)
+}
+
+func ParseStreamAlgorithm(s string) (StreamAlgorithm, error) {
+	for i, name := range streamAlgorithms {
+		if name == s {
+			return StreamAlgorithm(i + 1), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: stream algorithm %q", ErrUnknownAlgorithm, s)
+}
+
+const (
+	streamMagic      = "CHFY"
+	streamVersion    = 1
+	streamSaltSize   = 16
+	streamPrefixSize = 7
+	streamHeaderSize = len(streamMagic) + 2 + 4 + streamSaltSize + streamPrefixSize
+
+	// DefaultStreamChunkSize is the plaintext size of every chunk but the last.
+	DefaultStreamChunkSize = 64 * 1024
+	maxStreamChunkSize     = 16 * 1024 * 1024
+)
+
+var (
+	ErrStreamHeader    = errors.New("not a chify encrypted stream")
+	ErrStreamTruncated = errors.New("encrypted stream is truncated")
+	ErrStreamTrailing  = errors.New("unexpected data after the last chunk")
+	ErrAuthentication  = errors.New("message authentication failed")
+)
+
+// EncryptStream reads plaintext from r until EOF and writes the encrypted
+// stream to w. progress, if set, receives the number of plaintext bytes
+// processed so far. Cancelling ctx stops between chunks.
+func EncryptStream(ctx context.Context, alg StreamAlgorithm, key []byte, r io.Reader, w io.Writer, progress func(int64)) error {
+	header := make([]byte, streamHeaderSize)
+	copy(header, streamMagic)
+	header[4] = streamVersion
+	header[5] = byte(alg)
+	binary.BigEndian.PutUint32(header[6:10], DefaultStreamChunkSize)
+	if _, err := io.ReadFull(rand.Reader, header[10:]); err != nil {
+		return err
+	}
+
+	aead, err := newStreamAEAD(alg, key, header)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, header[streamHeaderSize-streamPrefixSize:])
+
+	// Read one byte ahead so the last chunk is known before it is sealed
+	buf := make([]byte, DefaultStreamChunkSize+1)
+	out := make([]byte, 0, DefaultStreamChunkSize+aead.Overhead())
+	n, err := io.ReadFull(r, buf)
+	var done int64
+	for counter := uint32(0); ; counter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		last := false
+		switch {
+		case err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+			last = true
+		case err != nil:
+			return err
+		}
+
+		chunk := buf[:min(n, DefaultStreamChunkSize)]
+		setStreamNonce(nonce, counter, last)
+		out = aead.Seal(out[:0], nonce, chunk, header)
+		if _, err := w.Write(out); err != nil {
+			return err
+		}
+
+		done += int64(len(chunk))
+		if progress != nil {
+			progress(done)
+		}
+
+		if last {
+			return nil
+		}
+		if counter == 1<<32-1 {
+			return errors.New("stream too long")
+		}
+
+		// Keep the look-ahead byte as the start of the next chunk
+		buf[0] = buf[DefaultStreamChunkSize]
+		n, err = io.ReadFull(r, buf[1:])
+		n++
+	}
+}
+
+// DecryptStream reverses EncryptStream. progress, if set, receives the number
+// of encrypted bytes consumed so far. Nothing is written for a chunk that
+// fails authentication, but earlier chunks may already be written, so the
+// caller should discard the output on error.
+func DecryptStream(ctx context.Context, key []byte, r io.Reader, w io.Writer, progress func(int64)) error {
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return ErrStreamHeader
+	}
+	if !bytes.Equal(header[:4], []byte(streamMagic)) || header[4] != streamVersion {
+		return ErrStreamHeader
+	}
+
+	chunkSize := int(binary.BigEndian.Uint32(header[6:10]))
+	if chunkSize == 0 || chunkSize > maxStreamChunkSize {
+		return ErrStreamHeader
+	}
+
+	aead, err := newStreamAEAD(StreamAlgorithm(header[5]), key, header)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, header[streamHeaderSize-streamPrefixSize:])
+
+	sealedSize := chunkSize + aead.Overhead()
+	buf := make([]byte, sealedSize+1)
+	out := make([]byte, 0, chunkSize)
+	n, err := io.ReadFull(r, buf)
+	done := int64(streamHeaderSize)
+	for counter := uint32(0); ; counter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		last := false
+		switch {
+		case err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+			last = true
+		case err != nil:
+			return err
+		}
+
+		chunk := buf[:min(n, sealedSize)]
+		if len(chunk) < aead.Overhead() {
+			// Every chunk carries a tag, even the empty last one of an empty file
+			return ErrStreamTruncated
+		}
+		setStreamNonce(nonce, counter, last)
+		out, err = aead.Open(out[:0], nonce, chunk, header)
+		if err != nil {
+			// A full chunk sealed as last means data follows the end of the stream
+			setStreamNonce(nonce, counter, !last)
+			if _, altErr := aead.Open(out[:0], nonce, chunk, header); altErr == nil {
+				if last {
+					return ErrStreamTruncated
+				}
+				return ErrStreamTrailing
+			}
+			return ErrAuthentication
+		}
+		if _, err := w.Write(out); err != nil {
+			return err
+		}
+
+		done += int64(len(chunk))
+		if progress != nil {
+			progress(done)
+		}
+
+		if last {
+			return nil
+		}
+
+		buf[0] = buf[sealedSize]
+		n, err = io.ReadFull(r, buf[1:])
+		n++
+	}
+}
+
+func newStreamAEAD(alg StreamAlgorithm, key, header []byte) (cipher.AEAD, error) {
+	salt := header[10 : 10+streamSaltSize]
+
+	switch alg {
+	case StreamAESGCM:
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, err
+		}
+		subkey, err := hkdf.Key(sha256.New, key, salt, "chify stream AES-GCM", len(key))
+		if err != nil {
+			return nil, err
+		}
+		c, err := aes.NewCipher(subkey)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(c)
+	case StreamChaCha20Poly1305:
+		if len(key) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("chacha20poly1305: key must be %d bytes", chacha20poly1305.KeySize)
+		}
+		subkey, err := hkdf.Key(sha256.New, key, salt, "chify stream ChaCha20-Poly1305", chacha20poly1305.KeySize)
+		if err != nil {
+			return nil, err
+		}
+		return chacha20poly1305.New(subkey)
+	}
+
+	return nil, fmt.Errorf("%w: stream algorithm %d", ErrUnknownAlgorithm, alg)
+}
+
+func setStreamNonce(nonce []byte, counter uint32, last bool) {
+	binary.BigEndian.PutUint32(nonce[streamPrefixSize:], counter)
+	nonce[len(nonce)-1] = 0
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+}
+
+func StreamOperation() *Operation {
+	return &Operation{
+		Name:     "stream",
+		Category: "crypto",
+		Params: []Param{
+			{Name: "algorithm", Kind: KindString, Choices: StreamAlgorithms(), Default: "AES-GCM", Usage: "chunk AEAD"},
+			{Name: "key", Kind: KindBytes, Usage: "AES key (16, 24 or 32 bytes) or 32 byte ChaCha20 key"},
+			{Name: Reverse, Kind: KindBool, Default: false, Usage: "decrypt instead of encrypt"},
+		},
+		run: func(input []byte, opts Options) ([]byte, error) {
+			var out bytes.Buffer
+			if opts.Bool(Reverse) {
+				// chunks written before a failure are not authenticated as a whole
+				if err := DecryptStream(context.Background(), opts.Bytes("key"), bytes.NewReader(input), &out, nil); err != nil {
+					return nil, err
+				}
+				return out.Bytes(), nil
+			}
+
+			alg, err := ParseStreamAlgorithm(opts.String("algorithm"))
+			if err != nil {
+				return nil, err
+			}
+			if err := EncryptStream(context.Background(), alg, opts.Bytes("key"), bytes.NewReader(input), &out, nil); err != nil {
+				return nil, err
+			}
+			return out.Bytes(), nil
+		},
+	}
+}
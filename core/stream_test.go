@@ -0,0 +1,126 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func encryptTestStream(t *testing.T, alg StreamAlgorithm, key, data []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := EncryptStream(context.Background(), alg, key, bytes.NewReader(data), &out, nil); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func decryptTestStream(key, data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	err := DecryptStream(context.Background(), key, bytes.NewReader(data), &out, nil)
+	return out.Bytes(), err
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	const chunk = DefaultStreamChunkSize
+	key := bytes.Repeat([]byte{3}, 32)
+
+	for _, alg := range []StreamAlgorithm{StreamAESGCM, StreamChaCha20Poly1305} {
+		for _, size := range []int{0, 1, chunk - 1, chunk, chunk + 1, 2 * chunk, 2*chunk + 100} {
+			data := make([]byte, size)
+			for i := range data {
+				data[i] = byte(i * 7)
+			}
+
+			var progress int64
+			var encrypted bytes.Buffer
+			err := EncryptStream(context.Background(), alg, key, bytes.NewReader(data), &encrypted, func(n int64) { progress = n })
+			if err != nil {
+				t.Fatalf("%v/%d: %v", alg, size, err)
+			}
+			if progress != int64(size) {
+				t.Errorf("%v/%d: progress ended at %d", alg, size, progress)
+			}
+
+			// one chunk per started chunk size, and one for an empty file
+			chunks := max((size+chunk-1)/chunk, 1)
+			if want := streamHeaderSize + size + chunks*16; encrypted.Len() != want {
+				t.Errorf("%v/%d: stream is %d bytes, want %d", alg, size, encrypted.Len(), want)
+			}
+
+			decrypted, err := decryptTestStream(key, encrypted.Bytes())
+			if err != nil {
+				t.Fatalf("%v/%d decrypt: %v", alg, size, err)
+			}
+			if !bytes.Equal(decrypted, data) {
+				t.Errorf("%v/%d: round trip changed the data", alg, size)
+			}
+		}
+	}
+}
+
+func TestDecryptStreamErrors(t *testing.T) {
+	const sealed = DefaultStreamChunkSize + 16
+	key := bytes.Repeat([]byte{5}, 16)
+	oneChunk := encryptTestStream(t, StreamAESGCM, key, make([]byte, DefaultStreamChunkSize))
+	threeChunks := encryptTestStream(t, StreamAESGCM, key, make([]byte, 2*DefaultStreamChunkSize+100))
+
+	swapped := bytes.Clone(threeChunks)
+	first := swapped[streamHeaderSize : streamHeaderSize+sealed]
+	second := swapped[streamHeaderSize+sealed : streamHeaderSize+2*sealed]
+	swapped = append(append(append(swapped[:streamHeaderSize:streamHeaderSize], second...), first...), threeChunks[streamHeaderSize+2*sealed:]...)
+
+	flipped := bytes.Clone(threeChunks)
+	flipped[streamHeaderSize+sealed+10] ^= 1
+
+	badMagic := bytes.Clone(oneChunk)
+	badMagic[0] = 'X'
+
+	tests := []struct {
+		name string
+		key  []byte
+		data []byte
+		err  error
+	}{
+		{"empty input", key, nil, ErrStreamHeader},
+		{"short header", key, oneChunk[:streamHeaderSize-1], ErrStreamHeader},
+		{"bad magic", key, badMagic, ErrStreamHeader},
+		{"header only", key, oneChunk[:streamHeaderSize], ErrStreamTruncated},
+		{"cut inside the tag", key, oneChunk[:streamHeaderSize+10], ErrStreamTruncated},
+		{"last chunk dropped", key, threeChunks[:streamHeaderSize+2*sealed], ErrStreamTruncated},
+		{"data appended", key, append(bytes.Clone(oneChunk), 0), ErrStreamTrailing},
+		{"chunk appended", key, append(bytes.Clone(oneChunk), oneChunk[streamHeaderSize:]...), ErrStreamTrailing},
+		{"chunks swapped", key, swapped, ErrAuthentication},
+		{"bit flipped", key, flipped, ErrAuthentication},
+		{"wrong key", bytes.Repeat([]byte{6}, 16), oneChunk, ErrAuthentication},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decryptTestStream(tt.key, tt.data); !errors.Is(err, tt.err) {
+				t.Errorf("DecryptStream error = %v, want %v", err, tt.err)
+			}
+			// the operation must not hand out the chunks decrypted before the failure
+			out, err := StreamOperation().Run(tt.data, Options{"key": tt.key, Reverse: true})
+			if out != nil || !errors.Is(err, tt.err) {
+				t.Errorf("operation returned %d bytes, %v", len(out), err)
+			}
+		})
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	encrypted := encryptTestStream(t, StreamChaCha20Poly1305, key, []byte("data"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var out bytes.Buffer
+	if err := EncryptStream(ctx, StreamChaCha20Poly1305, key, bytes.NewReader([]byte("data")), &out, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("EncryptStream error = %v, want context.Canceled", err)
+	}
+	if err := DecryptStream(ctx, key, bytes.NewReader(encrypted), &out, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("DecryptStream error = %v, want context.Canceled", err)
+	}
+}
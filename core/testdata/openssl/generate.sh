@@ -0,0 +1,14 @@
+#!/bin/sh
+# Regenerates the openssl enc fixtures (OpenSSL 3.0). The salt is random, so
+# every run gives new files that must decrypt to plaintext.txt all the same.
+set -e
+cd "$(dirname "$0")"
+pass="pass:chify test password"
+openssl enc -aes-256-cbc -md md5 -salt -pass "$pass" -in plaintext.txt -out aes-256-cbc-md5.enc
+openssl enc -aes-256-cbc -md sha256 -salt -pass "$pass" -in plaintext.txt -out aes-256-cbc-sha256.enc
+openssl enc -aes-128-cbc -md sha1 -salt -pass "$pass" -in plaintext.txt -out aes-128-cbc-sha1.enc
+openssl enc -aes-256-cbc -pbkdf2 -salt -pass "$pass" -in plaintext.txt -out aes-256-cbc-pbkdf2.enc
+openssl enc -aes-192-cbc -pbkdf2 -iter 1000 -md sha512 -salt -pass "$pass" -in plaintext.txt -out aes-192-cbc-pbkdf2-sha512.enc
+openssl enc -aes-256-ctr -pbkdf2 -salt -pass "$pass" -in plaintext.txt -out aes-256-ctr-pbkdf2.enc
+openssl enc -aes-128-ctr -md md5 -salt -pass "$pass" -in plaintext.txt -out aes-128-ctr-md5.enc
+openssl enc -aes-256-cbc -pbkdf2 -salt -pass "$pass" -in /dev/null -out empty-aes-256-cbc-pbkdf2.enc
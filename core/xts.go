@@ -0,0 +1,59 @@
+package core
+
+import (
+	"crypto/aes"
+	"fmt"
+
+	"golang.org/x/crypto/xts"
+)
+
+// XTSParams place data on a disk: it is split into sectors, each encrypted
+// with its own sector number as the tweak. There is no IV in the output.
+type XTSParams struct {
+	Sector     uint64 // number of the first sector
+	SectorSize int    // 512 if 0, a multiple of 16
+}
+
+// EncryptAESXTS encrypts data with a 32 or 64 byte key (two AES-128 or
+// AES-256 keys). data must be a multiple of 16 bytes; XTS ciphertext
+// stealing is not supported.
+func EncryptAESXTS(key []byte, p XTSParams, data []byte) ([]byte, error) {
+	return cryptXTS(key, p, data, false)
+}
+
+// DecryptAESXTS reverses EncryptAESXTS with the same params.
+func DecryptAESXTS(key []byte, p XTSParams, data []byte) ([]byte, error) {
+	return cryptXTS(key, p, data, true)
+}
+
+func cryptXTS(key []byte, p XTSParams, data []byte, decrypt bool) ([]byte, error) {
+	if p.SectorSize == 0 {
+		p.SectorSize = 512
+	}
+	if p.SectorSize < aes.BlockSize || p.SectorSize%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid XTS sector size %d, must be a multiple of %d", p.SectorSize, aes.BlockSize)
+	}
+	if len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("XTS data is not a multiple of the %d byte block size", aes.BlockSize)
+	}
+	if len(key) != 32 && len(key) != 64 {
+		return nil, fmt.Errorf("invalid XTS key size %d, must be 32 or 64", len(key))
+	}
+
+	c, err := xts.NewCipher(aes.NewCipher, key)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, len(data))
+	for i, sector := 0, p.Sector; i < len(data); i, sector = i+p.SectorSize, sector+1 {
+		end := min(i+p.SectorSize, len(data))
+		if decrypt {
+			c.Decrypt(result[i:end], data[i:end], sector)
+		} else {
+			c.Encrypt(result[i:end], data[i:end], sector)
+		}
+	}
+
+	return result, nil
+}
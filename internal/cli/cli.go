@@ -0,0 +1,287 @@
+// Package cli runs chify operations without opening the GUI window:
+//
+//	chify encrypt aes --mode gcm --key-hex 00112233... < in > out
+//	chify hash sha3-256 file
+//	chify hash hmac-sha256 --key secret < body
+//	chify encode base64 -d
+package cli
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"pararti/chify/core"
+	"pararti/chify/internal/registry"
+	"strings"
+)
+
+// Run executes the command line in args (without the program name) and
+// returns the process exit code.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return 0
+	}
+
+	if len(args) < 2 {
+		fmt.Fprintf(stderr, "chify: missing operation for %q\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	op, ok := registry.FindOperation(args[0], args[1])
+	if !ok {
+		fmt.Fprintf(stderr, "chify: unknown operation %q %q\n", args[0], args[1])
+		printUsage(stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("chify "+args[0]+" "+op.Name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "write output to `file` instead of stdout")
+	collect := bindParams(fs, op)
+
+	if err := fs.Parse(args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	opts, err := collect()
+	if err != nil {
+		fmt.Fprintln(stderr, "chify:", err)
+		return 2
+	}
+
+	if *output == "" {
+		if err := run(op, opts, fs.Args(), stdin, stdout); err != nil {
+			fmt.Fprintln(stderr, "chify:", err)
+			return 1
+		}
+		return 0
+	}
+
+	if name, ok := sameFile(*output, fs.Args()); ok {
+		fmt.Fprintf(stderr, "chify: -o %s is also the input %s\n", *output, name)
+		return 2
+	}
+
+	// the output file is only written once every input was read and the
+	// operation succeeded, so a failure leaves an existing file alone
+	var out bytes.Buffer
+	if err := run(op, opts, fs.Args(), stdin, &out); err != nil {
+		fmt.Fprintln(stderr, "chify:", err)
+		return 1
+	}
+	if err := os.WriteFile(*output, out.Bytes(), 0o666); err != nil {
+		fmt.Fprintln(stderr, "chify:", err)
+		return 1
+	}
+
+	return 0
+}
+
+// sameFile reports the first of files that is the existing file output.
+func sameFile(output string, files []string) (string, bool) {
+	outInfo, err := os.Stat(output)
+	if err != nil {
+		return "", false
+	}
+	for _, name := range files {
+		if info, err := os.Stat(name); err == nil && os.SameFile(outInfo, info) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// run feeds stdin or every file argument through op and writes the raw result.
+func run(op *core.Operation, opts core.Options, files []string, stdin io.Reader, out io.Writer) error {
+	if op.Category == "hash" {
+		return runHash(op, opts, files, stdin, out)
+	}
+
+	if len(files) == 0 {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		return emit(op, opts, input, out)
+	}
+
+	for _, name := range files {
+		input, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := emit(op, opts, input, out); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func emit(op *core.Operation, opts core.Options, input []byte, out io.Writer) error {
+	result, err := op.Run(input, opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(result)
+	return err
+}
+
+// runHash streams the input instead of reading it into memory, so large
+// artifacts can be hashed. Digests are printed as hex like the desktop form,
+// with "digest  file" lines in the sha256sum layout for file arguments.
+func runHash(op *core.Operation, opts core.Options, files []string, stdin io.Reader, out io.Writer) error {
+	newHash := func() (hash.Hash, error) { return core.NewHash(op.Name) }
+	if name, ok := strings.CutPrefix(op.Name, core.HMACPrefix); ok {
+		key := opts.Bytes("key")
+		newHash = func() (hash.Hash, error) { return core.NewHMAC(name, key) }
+	}
+
+	if len(files) == 0 {
+		h, err := newHash()
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(h, stdin); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%x\n", h.Sum(nil))
+		return err
+	}
+
+	var firstErr error
+	for _, name := range files {
+		h, err := newHash()
+		if err != nil {
+			return err
+		}
+		if err := hashFile(h, name); err != nil {
+			firstErr = cmp.Or(firstErr, err)
+			continue
+		}
+		fmt.Fprintf(out, "%x  %s\n", h.Sum(nil), name)
+	}
+
+	return firstErr
+}
+
+func hashFile(h hash.Hash, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// bindParams registers one flag per operation param. Byte params also get
+// -name-hex and -name-b64 variants; the reverse param answers to -d.
+func bindParams(fs *flag.FlagSet, op *core.Operation) func() (core.Options, error) {
+	var collectors []func(core.Options) error
+
+	for _, p := range op.Params {
+		switch p.Kind {
+		case core.KindBytes:
+			text := fs.String(p.Name, "", p.Usage+" (text)")
+			hexText := fs.String(p.Name+"-hex", "", p.Usage+" (hex)")
+			b64Text := fs.String(p.Name+"-b64", "", p.Usage+" (base64)")
+			collectors = append(collectors, func(opts core.Options) error {
+				var err error
+				switch {
+				case *hexText != "":
+					opts[p.Name], err = hex.DecodeString(*hexText)
+				case *b64Text != "":
+					opts[p.Name], err = base64.StdEncoding.DecodeString(*b64Text)
+				case *text != "":
+					opts[p.Name] = []byte(*text)
+				}
+				if err != nil {
+					return fmt.Errorf("-%s: %w", p.Name, err)
+				}
+				return nil
+			})
+		case core.KindString:
+			def, _ := p.Default.(string)
+			usage := p.Usage
+			if len(p.Choices) > 0 {
+				usage += " (" + strings.Join(p.Choices, ", ") + ")"
+			}
+			value := fs.String(p.Name, def, usage)
+			collectors = append(collectors, func(opts core.Options) error {
+				opts[p.Name] = matchChoice(p.Choices, *value)
+				return nil
+			})
+		case core.KindInt:
+			def, _ := p.Default.(int)
+			value := fs.Int(p.Name, def, p.Usage)
+			collectors = append(collectors, func(opts core.Options) error {
+				opts[p.Name] = *value
+				return nil
+			})
+		case core.KindBool:
+			value := new(bool)
+			fs.BoolVar(value, p.Name, false, p.Usage)
+			if p.Name == core.Reverse {
+				fs.BoolVar(value, "d", false, p.Usage)
+			}
+			collectors = append(collectors, func(opts core.Options) error {
+				opts[p.Name] = *value
+				return nil
+			})
+		}
+	}
+
+	return func() (core.Options, error) {
+		opts := core.Options{}
+		for _, collect := range collectors {
+			if err := collect(opts); err != nil {
+				return nil, err
+			}
+		}
+		return opts, nil
+	}
+}
+
+// matchChoice lets users type choices in any case, e.g. "gcm" for "GCM".
+func matchChoice(choices []string, value string) string {
+	for _, choice := range choices {
+		if strings.EqualFold(choice, value) {
+			return choice
+		}
+	}
+	return value
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: chify <command> <operation> [flags] [file...]")
+	fmt.Fprintln(w, "Run without arguments to open the desktop app. Input is read from stdin when no file is given.")
+	fmt.Fprintln(w)
+	for _, menuEl := range registry.LeftServiceMenu {
+		var names []string
+		for _, subMenuEl := range menuEl.Elements {
+			for _, op := range subMenuEl.Operations {
+				names = append(names, op.Name)
+			}
+		}
+		if len(names) > 0 {
+			fmt.Fprintf(w, "  %-8s %s\n", menuEl.Command, strings.Join(names, ", "))
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Use \"chify <command> <operation> -h\" for the flags of an operation.")
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fox = "The quick brown fox jumps over the lazy dog"
+
+func runCLI(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = Run(args, strings.NewReader(stdin), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	hmacWant := "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8\n"
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+		want  string
+	}{
+		{"hash stdin", "abc", []string{"hash", "md5"}, "900150983cd24fb0d6963f7d28e17f72\n"},
+		{"hmac text key", fox, []string{"hash", "hmac-sha256", "-key", "key"}, hmacWant},
+		{"hmac hex key", fox, []string{"hash", "hmac-sha256", "-key-hex", "6b6579"}, hmacWant},
+		{"hmac base64 key", fox, []string{"hash", "hmac-sha256", "-key-b64", "a2V5"}, hmacWant},
+		{"encode", "chify", []string{"encode", "base64"}, "Y2hpZnk="},
+		{"decode", "Y2hpZnk=", []string{"encode", "base64", "-d"}, "chify"},
+		{"decode long flag", "6368696679", []string{"encode", "hex", "-reverse"}, "chify"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, tt.stdin, tt.args...)
+			if code != 0 || stdout != tt.want {
+				t.Errorf("exit %d, output %q, want %q (stderr %q)", code, stdout, tt.want, stderr)
+			}
+		})
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	a, b := writeFile(t, "a", "abc"), writeFile(t, "b", "")
+
+	code, stdout, _ := runCLI(t, "", "hash", "md5", a, b)
+	want := "900150983cd24fb0d6963f7d28e17f72  " + a + "\n" +
+		"d41d8cd98f00b204e9800998ecf8427e  " + b + "\n"
+	if code != 0 || stdout != want {
+		t.Errorf("exit %d, output %q, want %q", code, stdout, want)
+	}
+
+	code, stdout, _ = runCLI(t, "", "encode", "hex", a, a)
+	if code != 0 || stdout != "616263616263" {
+		t.Errorf("exit %d, output %q", code, stdout)
+	}
+
+	// a missing file fails the run but the other digests are still printed
+	code, stdout, _ = runCLI(t, "", "hash", "md5", filepath.Join(t.TempDir(), "gone"), a)
+	if code != 1 || !strings.Contains(stdout, "900150983cd24fb0d6963f7d28e17f72") {
+		t.Errorf("exit %d, output %q", code, stdout)
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"help", nil, 0},
+		{"flag help", []string{"encode", "hex", "-h"}, 0},
+		{"missing operation", []string{"hash"}, 2},
+		{"unknown operation", []string{"hash", "whirlpool"}, 2},
+		{"unknown command", []string{"frobnicate", "md5"}, 2},
+		{"unknown flag", []string{"encode", "hex", "-nope"}, 2},
+		{"bad hex flag", []string{"hash", "hmac-sha256", "-key-hex", "zz"}, 2},
+		{"operation error", []string{"encode", "hex", "-d"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _, stderr := runCLI(t, "xyz", tt.args...); code != tt.code {
+				t.Errorf("exit %d, want %d (stderr %q)", code, tt.code, stderr)
+			}
+		})
+	}
+}
+
+func TestRunOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out")
+
+	code, stdout, stderr := runCLI(t, "chify", "encode", "hex", "-o", output)
+	if code != 0 || stdout != "" {
+		t.Fatalf("exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "6368696679" {
+		t.Errorf("output file holds %q", data)
+	}
+
+	// a failing operation leaves the existing file alone
+	if code, _, _ := runCLI(t, "not hex", "encode", "hex", "-d", "-o", output); code != 1 {
+		t.Errorf("failing operation exit %d, want 1", code)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "6368696679" {
+		t.Errorf("failing operation changed the output file to %q", data)
+	}
+
+	// the output may not be one of the inputs
+	for _, name := range []string{output, filepath.Join(dir, ".", "out")} {
+		if code, _, stderr := runCLI(t, "", "encode", "hex", "-d", "-o", output, name); code != 2 {
+			t.Errorf("%s: exit %d, want 2 (stderr %q)", name, code, stderr)
+		}
+		if data, _ := os.ReadFile(output); string(data) != "6368696679" {
+			t.Errorf("%s: input was overwritten with %q", name, data)
+		}
+	}
+}
@@ -2,35 +2,45 @@ package common
 
 import (
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
-func GetInput() (*widget.Label, *widget.Entry, *widget.Button) {
+func GetInput() (*widget.Label, *widget.Entry, *widget.Button, *BytesFormat) {
 	inputLabel := widget.NewLabel(lang.L("Input"))
 	inputEntry := widget.NewMultiLineEntry()
 	inputEntry.SetMinRowsVisible(6)
 	inputEntry.Wrapping = fyne.TextWrapBreak
+	inputFormat := NewBytesFormat(inputEntry, InputFormats, FormatText)
 
 	resetButton := widget.NewButton(lang.L("Reset"), func() {
-		inputEntry.SetText("")
+		inputFormat.Reset()
 	})
 
-	return inputLabel, inputEntry, resetButton
+	return inputLabel, inputEntry, resetButton, inputFormat
 }
 
-func GetOutput() (*widget.Label, *widget.Entry, *widget.Button) {
+func GetOutput() (*widget.Label, *widget.Entry, *widget.Button, *BytesFormat) {
 	outputLabel := widget.NewLabel(lang.L("Output"))
 	outputEntry := widget.NewMultiLineEntry()
 	outputEntry.SetMinRowsVisible(6)
 	outputEntry.Wrapping = fyne.TextWrapBreak
+	outputFormat := newOutputFormat(outputEntry, FormatText)
 	copyButton := widget.NewButton(lang.L("Copy"), func() {
 		if outputEntry.Text != "" {
 			fyne.CurrentApp().Clipboard().SetContent(outputEntry.Text)
 		}
 	})
 
-	return outputLabel, outputEntry, copyButton
+	return outputLabel, outputEntry, copyButton, outputFormat
+}
+
+// GetBytesEntry returns a single line entry for keys and nonces with its format selector.
+func GetBytesEntry(format string) (*widget.Entry, *BytesFormat) {
+	entry := widget.NewEntry()
+	return entry, NewBytesFormat(entry, InputFormats, format)
 }
 
 func GetHeader(text string) *widget.Label {
@@ -40,3 +50,42 @@ func GetHeader(text string) *widget.Label {
 
 	return header
 }
+
+// GetWindow returns the main window, the parent for file dialogs.
+func GetWindow() fyne.Window {
+	windows := fyne.CurrentApp().Driver().AllWindows()
+	if len(windows) == 0 {
+		return nil
+	}
+
+	return windows[0]
+}
+
+// GetFilePicker returns an entry for a local file path and a button that
+// fills it from a file dialog. Files are opened by path so large ones can be
+// streamed instead of read into memory.
+func GetFilePicker(save bool) (*widget.Entry, *widget.Button) {
+	pathEntry := widget.NewEntry()
+	pickButton := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		if save {
+			dialog.ShowFileSave(func(w fyne.URIWriteCloser, err error) {
+				if err != nil || w == nil {
+					return
+				}
+				w.Close()
+				pathEntry.SetText(w.URI().Path())
+			}, GetWindow())
+			return
+		}
+
+		dialog.ShowFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil || rc == nil {
+				return
+			}
+			rc.Close()
+			pathEntry.SetText(rc.URI().Path())
+		}, GetWindow())
+	})
+
+	return pathEntry, pickButton
+}
@@ -0,0 +1,190 @@
+package common_encrypt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// GCMFields hold the AES-GCM options: associated data, an explicit nonce,
+// nonce and tag sizes, and whether nonce and tag are kept apart from the
+// ciphertext instead of being packed as nonce | ciphertext | tag.
+type GCMFields struct {
+	AADEntry       *widget.Entry
+	AADFormat      *common.BytesFormat
+	NonceEntry     *widget.Entry
+	NonceFormat    *common.BytesFormat
+	NonceSizeEntry *widget.Entry
+	TagSizeSelect  *widget.Select
+	SeparateCheck  *widget.Check
+	TagEntry       *widget.Entry
+	TagFormat      *common.BytesFormat
+	fields         *fyne.Container
+
+	// random nonce of the last message encrypted with separate output,
+	// shown read-only so that the nonce input stays empty
+	generatedNonceEntry  *widget.Entry
+	generatedNonceFormat *common.BytesFormat
+	generatedNonceRows   *fyne.Container
+
+	// key and nonce pairs used for encryption in this form, hashed
+	used map[[32]byte]bool
+}
+
+func GetGCMFields() *GCMFields {
+	g := &GCMFields{used: map[[32]byte]bool{}}
+	g.AADEntry, g.AADFormat = common.GetBytesEntry(common.FormatText)
+	g.NonceEntry, g.NonceFormat = common.GetBytesEntry(common.FormatHex)
+	g.NonceEntry.PlaceHolder = lang.L("RandomNonce")
+	g.TagEntry, g.TagFormat = common.GetBytesEntry(common.FormatHex)
+	g.generatedNonceEntry, g.generatedNonceFormat = common.GetBytesEntry(common.FormatHex)
+	g.generatedNonceEntry.Disable()
+
+	g.NonceSizeEntry = widget.NewEntry()
+	g.NonceSizeEntry.SetText("12")
+	g.NonceSizeEntry.Validator = func(s string) error {
+		if n, err := strconv.Atoi(s); err != nil || n < 1 {
+			return errors.New(lang.L("Incorrect"))
+		}
+		return nil
+	}
+	g.TagSizeSelect = widget.NewSelect([]string{"16", "15", "14", "13", "12"}, nil)
+	g.TagSizeSelect.SetSelected("16")
+
+	nonceWarning := widget.NewLabel(lang.L("NonceReuseWarning"))
+	nonceWarning.Wrapping = fyne.TextWrapWord
+	nonceWarning.Importance = widget.WarningImportance
+	nonceWarning.Hide()
+	g.NonceEntry.OnChanged = func(s string) {
+		if s == "" {
+			nonceWarning.Hide()
+		} else {
+			nonceWarning.Show()
+		}
+	}
+
+	generateNonceButton := widget.NewButton(lang.L("Generate"), func() {
+		nonce := make([]byte, max(entryNumber(g.NonceSizeEntry), 1))
+		if _, err := rand.Read(nonce); err != nil {
+			log.Println("Error generating random nonce:", err)
+			return
+		}
+		g.NonceFormat.SetFormat(common.FormatHex)
+		g.NonceFormat.SetBytes(nonce)
+	})
+
+	generatedNonceCopyButton := widget.NewButton(lang.L("Copy"), func() {
+		if g.generatedNonceEntry.Text != "" {
+			fyne.CurrentApp().Clipboard().SetContent(g.generatedNonceEntry.Text)
+		}
+	})
+	g.generatedNonceRows = container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("GeneratedNonce")), g.generatedNonceFormat),
+		container.NewBorder(nil, nil, nil, generatedNonceCopyButton, g.generatedNonceEntry),
+	)
+	g.generatedNonceRows.Hide()
+
+	tagRows := container.NewVBox(container.NewHBox(widget.NewLabel(lang.L("Tag")), g.TagFormat), g.TagEntry)
+	tagRows.Hide()
+	g.SeparateCheck = widget.NewCheck(lang.L("SeparateNonceTag"), func(checked bool) {
+		if checked {
+			tagRows.Show()
+		} else {
+			tagRows.Hide()
+			g.generatedNonceRows.Hide()
+		}
+	})
+
+	g.fields = container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("AssociatedData")), g.AADFormat),
+		g.AADEntry,
+		container.NewHBox(widget.NewLabel(lang.L("Nonce")), g.NonceFormat),
+		container.NewBorder(nil, nil, nil, generateNonceButton, g.NonceEntry),
+		nonceWarning,
+		container.NewBorder(nil, nil, widget.NewLabel(lang.L("NonceSize")), nil, g.NonceSizeEntry),
+		container.NewHBox(widget.NewLabel(lang.L("TagSize")), g.TagSizeSelect),
+		g.SeparateCheck,
+		g.generatedNonceRows,
+		tagRows,
+	)
+
+	return g
+}
+
+func (g *GCMFields) Container() *fyne.Container {
+	return g.fields
+}
+
+// Params reads the fields. Nonce is empty when a random one should be used.
+func (g *GCMFields) Params() (core.GCMParams, error) {
+	if err := g.NonceSizeEntry.Validate(); err != nil {
+		return core.GCMParams{}, err
+	}
+	aad, err := g.AADFormat.Bytes()
+	if err != nil {
+		return core.GCMParams{}, err
+	}
+	nonce, err := g.NonceFormat.Bytes()
+	if err != nil {
+		return core.GCMParams{}, err
+	}
+	tagSize, _ := strconv.Atoi(g.TagSizeSelect.Selected)
+
+	return core.GCMParams{
+		AAD:       aad,
+		Nonce:     nonce,
+		NonceSize: entryNumber(g.NonceSizeEntry),
+		TagSize:   tagSize,
+	}, nil
+}
+
+func (g *GCMFields) Separate() bool {
+	return g.SeparateCheck.Checked
+}
+
+// Reused reports whether this form already encrypted with key and nonce.
+func (g *GCMFields) Reused(key, nonce []byte) bool {
+	return len(nonce) > 0 && g.used[nonceID(key, nonce)]
+}
+
+func (g *GCMFields) Remember(key, nonce []byte) {
+	g.used[nonceID(key, nonce)] = true
+}
+
+// SetSeparate shows the tag of a message encrypted with separate output and,
+// if the nonce was random, that nonce next to the nonce input. The input is
+// left empty, so the next message gets a fresh random nonce again.
+func (g *GCMFields) SetSeparate(nonce, tag []byte, generated bool) {
+	if generated {
+		g.generatedNonceFormat.SetFormat(common.FormatHex)
+		g.generatedNonceFormat.SetBytes(nonce)
+		g.generatedNonceRows.Show()
+	} else {
+		g.generatedNonceEntry.SetText("")
+		g.generatedNonceRows.Hide()
+	}
+	g.TagFormat.SetFormat(common.FormatHex)
+	g.TagFormat.SetBytes(tag)
+}
+
+func nonceID(key, nonce []byte) [32]byte {
+	h := sha256.New()
+	h.Write([]byte{byte(len(key))})
+	h.Write(key)
+	h.Write(nonce)
+	return [32]byte(h.Sum(nil))
+}
+
+func entryNumber(entry *widget.Entry) int {
+	n, _ := strconv.Atoi(entry.Text)
+	return n
+}
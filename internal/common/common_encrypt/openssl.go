@@ -0,0 +1,103 @@
+package common_encrypt
+
+import (
+	"errors"
+	"pararti/chify/core"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// OpenSSLFields hold the options of openssl enc. None of them but the salt
+// is stored in the output, so they must match on both sides.
+type OpenSSLFields struct {
+	PasswordEntry   *widget.Entry
+	KDFSelect       *widget.Select
+	DigestSelect    *widget.Select
+	IterationsEntry *widget.Entry
+	KeySizeSelect   *widget.Select
+	fields          *fyne.Container
+}
+
+func GetOpenSSLFields() *OpenSSLFields {
+	o := &OpenSSLFields{
+		PasswordEntry:   widget.NewPasswordEntry(),
+		DigestSelect:    widget.NewSelect(core.HashAlgorithms, nil),
+		IterationsEntry: widget.NewEntry(),
+		KeySizeSelect:   widget.NewSelect([]string{"128", "192", "256"}, nil),
+	}
+	o.PasswordEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		return nil
+	}
+	o.DigestSelect.SetSelected("sha256")
+	o.KeySizeSelect.SetSelected("256")
+	o.IterationsEntry.SetText("10000")
+	o.IterationsEntry.Validator = func(s string) error {
+		if n, err := strconv.Atoi(s); err != nil || n < 1 {
+			return errors.New(lang.L("Incorrect"))
+		}
+		return nil
+	}
+
+	iterationsRow := container.NewBorder(nil, nil, widget.NewLabel(lang.L("Iterations")), nil, o.IterationsEntry)
+	o.KDFSelect = widget.NewSelect(core.OpenSSLKDFs, func(selected string) {
+		// EVP_BytesToKey always runs a single iteration
+		if selected == "pbkdf2" {
+			iterationsRow.Show()
+		} else {
+			iterationsRow.Hide()
+		}
+	})
+	o.KDFSelect.SetSelected("pbkdf2")
+
+	o.fields = container.NewVBox(
+		widget.NewLabel(lang.L("Password")),
+		o.PasswordEntry,
+		container.NewHBox(widget.NewLabel(lang.L("KDF")), o.KDFSelect),
+		container.NewHBox(widget.NewLabel(lang.L("Digest")), o.DigestSelect),
+		iterationsRow,
+		container.NewHBox(widget.NewLabel(lang.L("KeySize")), o.KeySizeSelect),
+	)
+
+	return o
+}
+
+func (o *OpenSSLFields) Container() *fyne.Container {
+	return o.fields
+}
+
+// Validate checks the password and, for PBKDF2, the iteration count.
+func (o *OpenSSLFields) Validate() error {
+	entries := []*widget.Entry{o.PasswordEntry}
+	if o.KDFSelect.Selected == "pbkdf2" {
+		entries = append(entries, o.IterationsEntry)
+	}
+	for _, entry := range entries {
+		if err := entry.Validate(); err != nil {
+			entry.SetValidationError(err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OpenSSLFields) Password() []byte {
+	return []byte(o.PasswordEntry.Text)
+}
+
+func (o *OpenSSLFields) Params(mode core.BlockMode) core.OpenSSLParams {
+	keyBits, _ := strconv.Atoi(o.KeySizeSelect.Selected)
+	return core.OpenSSLParams{
+		Mode:       mode,
+		KeySize:    keyBits / 8,
+		KDF:        o.KDFSelect.Selected,
+		Digest:     o.DigestSelect.Selected,
+		Iterations: entryNumber(o.IterationsEntry),
+	}
+}
@@ -0,0 +1,91 @@
+package common_encrypt
+
+import (
+	"errors"
+	"pararti/chify/core"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// PassphraseFields let a cipher form derive its key from a passphrase. The
+// KDF, its parameters and the salt travel in the ciphertext header, so only
+// the passphrase is needed to decrypt.
+type PassphraseFields struct {
+	Check     *widget.Check
+	Entry     *widget.Entry
+	KDFSelect *widget.Select
+	OnChanged func(enabled bool) // called after the fields are shown or hidden
+	fields    *fyne.Container
+}
+
+// GetPassphraseFields returns the passphrase toggle and fields. keyFields are
+// the raw key rows of the form, hidden while a passphrase is used.
+func GetPassphraseFields(keyFields ...fyne.CanvasObject) *PassphraseFields {
+	p := &PassphraseFields{
+		Entry:     widget.NewPasswordEntry(),
+		KDFSelect: widget.NewSelect(core.PassphraseKDFs(), nil),
+	}
+	p.KDFSelect.SetSelected(core.KDFArgon2id.String())
+	p.Entry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		return nil
+	}
+
+	description := widget.NewLabel(lang.L("PassphraseDescription"))
+	description.Wrapping = fyne.TextWrapWord
+	description.TextStyle.Italic = true
+
+	p.fields = container.NewVBox(
+		widget.NewLabel(lang.L("Passphrase")),
+		p.Entry,
+		container.NewHBox(widget.NewLabel(lang.L("KDF")), p.KDFSelect),
+		description,
+	)
+	p.fields.Hide()
+
+	p.Check = widget.NewCheck(lang.L("UsePassphrase"), func(checked bool) {
+		if checked {
+			p.fields.Show()
+		} else {
+			p.fields.Hide()
+		}
+		for _, obj := range keyFields {
+			if checked {
+				obj.Hide()
+			} else {
+				obj.Show()
+			}
+		}
+		if p.OnChanged != nil {
+			p.OnChanged(checked)
+		}
+	})
+
+	return p
+}
+
+func (p *PassphraseFields) Container() *fyne.Container {
+	return container.NewVBox(p.Check, p.fields)
+}
+
+func (p *PassphraseFields) Enabled() bool {
+	return p.Check.Checked
+}
+
+func (p *PassphraseFields) Passphrase() []byte {
+	return []byte(p.Entry.Text)
+}
+
+// Params returns the default costs of the selected KDF, used when encrypting.
+func (p *PassphraseFields) Params() (core.KDFParams, error) {
+	kdf, err := core.ParseKDF(p.KDFSelect.Selected)
+	if err != nil {
+		return core.KDFParams{}, err
+	}
+	return core.DefaultKDFParams(kdf), nil
+}
@@ -0,0 +1,224 @@
+package common_hash
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// GetFileHasher returns the section of a hash form that streams files or
+// whole folders through the selected algorithms in one pass.
+func GetFileHasher(algorithms []string, selected []string) *fyne.Container {
+	filesLabel := widget.NewLabel(lang.L("FileOrFolder"))
+	pathEntry, fileButton := common.GetFilePicker(false)
+	folderButton := widget.NewButtonWithIcon("", theme.FolderIcon(), func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			pathEntry.SetText(uri.Path())
+		}, common.GetWindow())
+	})
+
+	algorithmGroup := widget.NewCheckGroup(algorithms, nil)
+	algorithmGroup.Horizontal = true
+	algorithmGroup.SetSelected(selected)
+
+	formatLabel := widget.NewLabel(lang.L("ManifestFormat"))
+	formatSelect := widget.NewSelect(core.ManifestFormats(), nil)
+	formatSelect.SetSelected(core.ManifestBSD.String())
+
+	resultsLabel := widget.NewLabel(lang.L("Output"))
+	resultsEntry := widget.NewMultiLineEntry()
+	resultsEntry.SetMinRowsVisible(6)
+	resultsEntry.Wrapping = fyne.TextWrapBreak
+	copyButton := widget.NewButton(lang.L("Copy"), func() {
+		if resultsEntry.Text != "" {
+			fyne.CurrentApp().Clipboard().SetContent(resultsEntry.Text)
+		}
+	})
+
+	task := common.GetTask()
+	hashButton := widget.NewButton(lang.L("HashFiles"), nil)
+	verifyButton := widget.NewButton(lang.L("VerifyManifest"), nil)
+
+	// entries of the last completed run, written out by the save button
+	var manifest []core.ManifestEntry
+	var manifestAlgorithms []string
+	saveButton := widget.NewButton(lang.L("SaveManifest"), func() {
+		if len(manifest) > 0 {
+			saveManifest(manifest, manifestAlgorithms, formatSelect.Selected)
+		}
+	})
+	saveButton.Disable()
+
+	hashButton.OnTapped = func() {
+		root := pathEntry.Text
+		algs := orderedSelection(algorithms, algorithmGroup.Selected)
+		if root == "" || len(algs) == 0 || task.Running() {
+			return
+		}
+
+		format, _ := core.ParseManifestFormat(formatSelect.Selected)
+		if format == core.ManifestGNU && len(algs) > 1 {
+			resultsEntry.SetText("Error: " + lang.L("GNUOneAlgorithm"))
+			return
+		}
+
+		resultsEntry.SetText("")
+		manifest = nil
+		saveButton.Disable()
+		hashButton.Disable()
+		verifyButton.Disable()
+
+		// Results are collected on the job goroutine and shown in batches,
+		// so folders with many small files do not flood the UI
+		var results strings.Builder
+		var lastFlush time.Time
+		task.Start(0, func(ctx context.Context, progress func(int64)) error {
+			base, files, total, err := core.ListFiles(ctx, root)
+			if err != nil {
+				return err
+			}
+			task.SetTotal(total)
+
+			var entries []core.ManifestEntry
+			err = core.HashFiles(ctx, algs, base, files, progress, func(d core.FileDigest) {
+				results.WriteString(formatDigest(format, algs, d))
+				if d.Err == nil {
+					for i, alg := range algs {
+						entries = append(entries, core.ManifestEntry{Algorithm: alg, Path: d.Path, Digest: d.Digests[i]})
+					}
+				}
+				if time.Since(lastFlush) > 200*time.Millisecond {
+					lastFlush = time.Now()
+					text := results.String()
+					fyne.Do(func() {
+						resultsEntry.SetText(text)
+					})
+				}
+			})
+			if err == nil {
+				manifest, manifestAlgorithms = entries, algs
+			}
+			return err
+		}, func(err error) {
+			resultsEntry.SetText(results.String())
+			hashButton.Enable()
+			verifyButton.Enable()
+			if err == nil && len(manifest) > 0 {
+				saveButton.Enable()
+			}
+		})
+	}
+
+	manifestLabel := widget.NewLabel(lang.L("Manifest"))
+	manifestEntry, manifestButton := common.GetFilePicker(false)
+	verifyButton.OnTapped = func() {
+		path := manifestEntry.Text
+		if path == "" || task.Running() {
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			resultsEntry.SetText("Error: " + err.Error())
+			return
+		}
+		// GNU lines name no algorithm, assume the first ticked one
+		entries, err := core.ParseManifest(data, firstOr(orderedSelection(algorithms, algorithmGroup.Selected), algorithms[0]))
+		if err != nil {
+			resultsEntry.SetText("Error: " + err.Error())
+			return
+		}
+
+		resultsEntry.SetText("")
+		hashButton.Disable()
+		verifyButton.Disable()
+
+		base := filepath.Dir(path)
+		var report verifyReport
+		var lastFlush time.Time
+		task.Start(core.ManifestSize(base, entries), func(ctx context.Context, progress func(int64)) error {
+			return core.VerifyManifest(ctx, base, entries, progress, func(r core.VerifyResult) {
+				report.add(r)
+				if time.Since(lastFlush) > 200*time.Millisecond {
+					lastFlush = time.Now()
+					text := report.String()
+					fyne.Do(func() {
+						resultsEntry.SetText(text)
+					})
+				}
+			})
+		}, func(error) {
+			resultsEntry.SetText(report.String())
+			hashButton.Enable()
+			verifyButton.Enable()
+		})
+	}
+
+	algorithmsRow := fyne.CanvasObject(algorithmGroup)
+	if len(algorithms) == 1 {
+		algorithmsRow = container.NewVBox()
+	}
+
+	return container.NewVBox(
+		widget.NewSeparator(),
+		filesLabel,
+		container.NewBorder(nil, nil, nil, container.NewHBox(fileButton, folderButton), pathEntry),
+		algorithmsRow,
+		container.NewHBox(formatLabel, formatSelect),
+		container.NewGridWithColumns(2, hashButton, saveButton),
+		manifestLabel,
+		container.NewBorder(nil, nil, nil, manifestButton, manifestEntry),
+		verifyButton,
+		task.Container(),
+		resultsLabel,
+		container.NewBorder(nil, nil, nil, copyButton, resultsEntry),
+	)
+}
+
+// formatDigest renders one file as manifest lines, one per algorithm.
+func formatDigest(format core.ManifestFormat, algorithms []string, d core.FileDigest) string {
+	if d.Err != nil {
+		return fmt.Sprintf("%s: %v\n", d.Path, d.Err)
+	}
+
+	var b strings.Builder
+	for i, alg := range algorithms {
+		b.WriteString(core.FormatManifestLine(format, core.ManifestEntry{Algorithm: alg, Path: d.Path, Digest: d.Digests[i]}))
+	}
+	return b.String()
+}
+
+// orderedSelection keeps the selected algorithms in menu order, since the
+// check group reports them in the order they were ticked.
+func orderedSelection(all, selected []string) []string {
+	var ordered []string
+	for _, name := range all {
+		if slices.Contains(selected, name) {
+			ordered = append(ordered, name)
+		}
+	}
+	return ordered
+}
+
+func firstOr(list []string, fallback string) string {
+	if len(list) > 0 {
+		return list[0]
+	}
+	return fallback
+}
@@ -0,0 +1,103 @@
+package common_hash
+
+import (
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// HMACFields adds an optional key to a hash form. With HMAC ticked the form
+// computes a keyed tag and can check it against an expected one, e.g. a
+// webhook signature.
+type HMACFields struct {
+	Check     *widget.Check
+	KeyEntry  *widget.Entry
+	KeyFormat *common.BytesFormat
+	TagEntry  *widget.Entry
+	TagFormat *common.BytesFormat
+	Result    *widget.Label
+	fields    *fyne.Container
+}
+
+// GetHMACFields builds the key and verify fields. input and algorithm give
+// the data and digest of the surrounding form at the time of verifying.
+func GetHMACFields(input *common.BytesFormat, algorithm func() string) *HMACFields {
+	f := &HMACFields{Result: widget.NewLabel("")}
+	f.KeyEntry, f.KeyFormat = common.GetBytesEntry(common.FormatText)
+	f.TagEntry, f.TagFormat = common.GetBytesEntry(common.FormatHex)
+
+	verifyButton := widget.NewButton(lang.L("Verify"), func() {
+		ok, err := f.verify(input, algorithm())
+		switch {
+		case err != nil:
+			log.Println("HMAC verify error:", err)
+			f.Result.SetText("Error: " + err.Error())
+		case ok:
+			f.Result.SetText(lang.L("TagMatches"))
+		default:
+			f.Result.SetText(lang.L("TagMismatch"))
+		}
+	})
+
+	f.fields = container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("Key")), f.KeyFormat),
+		f.KeyEntry,
+		container.NewHBox(widget.NewLabel(lang.L("ExpectedTag")), f.TagFormat),
+		container.NewBorder(nil, nil, nil, verifyButton, f.TagEntry),
+		f.Result,
+	)
+	f.fields.Hide()
+
+	f.Check = widget.NewCheck("HMAC", func(checked bool) {
+		if checked {
+			f.fields.Show()
+		} else {
+			f.fields.Hide()
+		}
+	})
+
+	return f
+}
+
+func (f *HMACFields) Container() *fyne.Container {
+	return container.NewVBox(f.Check, f.fields)
+}
+
+// Sum returns the plain digest of data, or its HMAC when the form is keyed.
+func (f *HMACFields) Sum(algorithm string, data []byte) ([]byte, error) {
+	if !f.Check.Checked {
+		return core.Hash(algorithm, data)
+	}
+
+	key, err := f.KeyFormat.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return core.HMAC(algorithm, key, data)
+}
+
+func (f *HMACFields) verify(input *common.BytesFormat, algorithm string) (bool, error) {
+	data, err := input.Bytes()
+	if err != nil {
+		return false, err
+	}
+	key, err := f.KeyFormat.Bytes()
+	if err != nil {
+		return false, err
+	}
+	tag, err := f.TagFormat.Bytes()
+	if err != nil {
+		return false, err
+	}
+	if len(tag) == 0 {
+		return false, errors.New(lang.L("Required"))
+	}
+
+	return core.VerifyHMAC(algorithm, key, data, tag)
+}
@@ -0,0 +1,74 @@
+package common_hash
+
+import (
+	"fmt"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+)
+
+// saveManifest writes the entries of the last run to a file chosen by the
+// user, named SHA256SUMS and the like when there is a single algorithm.
+func saveManifest(entries []core.ManifestEntry, algorithms []string, formatName string) {
+	format, err := core.ParseManifestFormat(formatName)
+	if err != nil {
+		return
+	}
+	if format == core.ManifestGNU && len(algorithms) > 1 {
+		dialog.ShowError(fmt.Errorf("%s", lang.L("GNUOneAlgorithm")), common.GetWindow())
+		return
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(core.FormatManifestLine(format, e))
+	}
+	data := []byte(b.String())
+
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil || w == nil {
+			return
+		}
+		defer w.Close()
+
+		if _, err := w.Write(data); err != nil {
+			log.Println("File write error:", err)
+			dialog.ShowError(err, common.GetWindow())
+		}
+	}, common.GetWindow())
+
+	name := "CHECKSUMS"
+	if len(algorithms) == 1 {
+		name = core.ManifestFileName(algorithms[0])
+	}
+	save.SetFileName(name)
+	save.Show()
+}
+
+// verifyReport collects results in the "path: OK" layout of sha256sum -c,
+// followed by a count per status.
+type verifyReport struct {
+	lines  strings.Builder
+	counts [3]int
+}
+
+func (r *verifyReport) add(result core.VerifyResult) {
+	r.counts[result.Status]++
+	if result.Err != nil {
+		fmt.Fprintf(&r.lines, "%s: %s (%v)\n", result.Path, result.Status, result.Err)
+		return
+	}
+	fmt.Fprintf(&r.lines, "%s: %s\n", result.Path, result.Status)
+}
+
+func (r *verifyReport) String() string {
+	return fmt.Sprintf("%s\n%s: %d, %s: %d, %s: %d\n", r.lines.String(),
+		core.VerifyOK, r.counts[core.VerifyOK],
+		core.VerifyFailed, r.counts[core.VerifyFailed],
+		core.VerifyMissing, r.counts[core.VerifyMissing])
+}
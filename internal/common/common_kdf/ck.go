@@ -0,0 +1,125 @@
+package common_kdf
+
+import (
+	"crypto/rand"
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+func GetActionButton() *widget.Button {
+	return widget.NewButton(lang.L("Derive"), nil)
+}
+
+// GetNumberEntry returns an entry for a non-negative cost parameter.
+func GetNumberEntry(value int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.Itoa(value))
+	entry.Validator = func(s string) error {
+		if _, err := strconv.ParseUint(s, 10, 32); err != nil {
+			return errors.New(lang.L("Incorrect"))
+		}
+		return nil
+	}
+	return entry
+}
+
+func Number(entry *widget.Entry) int {
+	n, _ := strconv.Atoi(entry.Text)
+	return n
+}
+
+// ValidateAll marks the first invalid entry and reports whether all are valid.
+func ValidateAll(entries ...*widget.Entry) bool {
+	for _, entry := range entries {
+		if err := entry.Validate(); err != nil {
+			entry.SetValidationError(err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetSaltEntry returns the salt field with a button that fills in 16 random bytes.
+func GetSaltEntry() (*widget.Label, *widget.Entry, *common.BytesFormat, *widget.Button) {
+	saltLabel := widget.NewLabel(lang.L("Salt"))
+	saltEntry, saltFormat := common.GetBytesEntry(common.FormatHex)
+	generateButton := widget.NewButton(lang.L("Generate"), func() {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			log.Println("Error generating random salt:", err)
+			return
+		}
+		saltFormat.SetFormat(common.FormatHex)
+		saltFormat.SetBytes(salt)
+	})
+
+	return saltLabel, saltEntry, saltFormat, generateButton
+}
+
+// Derive runs derive off the UI goroutine, since a well tuned KDF takes a
+// noticeable time, and shows the result in the output field.
+func Derive(actionButton *widget.Button, outputEntry *widget.Entry, outputFormat *common.BytesFormat, derive func() ([]byte, error)) {
+	actionButton.Disable()
+	go func() {
+		key, err := derive()
+		fyne.Do(func() {
+			defer actionButton.Enable()
+			if err != nil {
+				log.Println("Key derivation error:", err)
+				outputEntry.SetText("Error: " + err.Error())
+				return
+			}
+			outputFormat.SetBytes(key)
+		})
+	}()
+}
+
+// GetCalibrator returns a target time field and a button that tunes the time
+// cost of the form to it. params reads the form, apply writes the result back.
+func GetCalibrator(params func() (core.KDFParams, error), apply func(core.KDFParams)) *fyne.Container {
+	targetLabel := widget.NewLabel(lang.L("TargetTime"))
+	targetEntry := GetNumberEntry(500)
+	status := widget.NewLabel("")
+
+	var calibrateButton *widget.Button
+	calibrateButton = widget.NewButton(lang.L("Calibrate"), func() {
+		if !ValidateAll(targetEntry) {
+			return
+		}
+		p, err := params()
+		if err != nil {
+			status.SetText("Error: " + err.Error())
+			return
+		}
+		target := time.Duration(Number(targetEntry)) * time.Millisecond
+
+		calibrateButton.Disable()
+		status.SetText(lang.L("Calibrating"))
+		go func() {
+			p, err := core.Calibrate(p, target)
+			fyne.Do(func() {
+				calibrateButton.Enable()
+				if err != nil {
+					status.SetText("Error: " + err.Error())
+					return
+				}
+				apply(p)
+				status.SetText(lang.L("Done"))
+			})
+		}()
+	})
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, targetLabel, calibrateButton, targetEntry),
+		status,
+	)
+}
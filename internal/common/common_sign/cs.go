@@ -0,0 +1,47 @@
+package common_sign
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+func GetActionButton() (*widget.Check, *widget.Button) {
+	modeToggle := widget.NewCheck(lang.L("Verify"), func(checked bool) {})
+	modeToggle.SetChecked(false)
+
+	actionButton := widget.NewButton(lang.L("Sign"), func() {
+	})
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Verify"))
+		} else {
+			actionButton.SetText(lang.L("Sign"))
+		}
+	}
+
+	return modeToggle, actionButton
+}
+
+// GetResultLabel returns the label showing the outcome of a verification.
+func GetResultLabel() *widget.Label {
+	label := widget.NewLabel("")
+	label.TextStyle = fyne.TextStyle{Bold: true}
+	return label
+}
+
+// SetResult shows whether a signature is valid, in green or red.
+func SetResult(label *widget.Label, valid bool, err error) {
+	switch {
+	case err != nil:
+		label.Importance = widget.DangerImportance
+		label.SetText("Error: " + err.Error())
+	case valid:
+		label.Importance = widget.SuccessImportance
+		label.SetText(lang.L("SignatureValid"))
+	default:
+		label.Importance = widget.DangerImportance
+		label.SetText(lang.L("SignatureInvalid"))
+	}
+}
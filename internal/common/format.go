@@ -0,0 +1,236 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	FormatText      = "text"
+	FormatHex       = "hex"
+	FormatHexdump   = "hexdump"
+	FormatBase64    = "base64"
+	FormatBase64URL = "base64url"
+	FormatFile      = "file"
+)
+
+var InputFormats = []string{FormatText, FormatHex, FormatBase64, FormatBase64URL, FormatFile}
+var OutputFormats = []string{FormatText, FormatHex, FormatHexdump, FormatBase64, FormatBase64URL, FormatFile}
+
+// BytesFormat is the format selector of a byte field. It converts between
+// the entry text and raw bytes, so binary data survives the round trip.
+type BytesFormat struct {
+	*widget.Select
+	entry    *widget.Entry
+	current  string
+	data     []byte // file contents for input fields, last result for output fields
+	isOutput bool
+	rendered string // output text last written from data
+}
+
+func NewBytesFormat(entry *widget.Entry, formats []string, selected string) *BytesFormat {
+	f := &BytesFormat{entry: entry, current: selected}
+	f.Select = widget.NewSelect(formats, nil)
+	f.Select.SetSelected(selected)
+	f.Select.OnChanged = f.changed
+	return f
+}
+
+func newOutputFormat(entry *widget.Entry, selected string) *BytesFormat {
+	f := NewBytesFormat(entry, OutputFormats, selected)
+	f.isOutput = true
+	return f
+}
+
+// Bytes returns the field value decoded from the selected format.
+func (f *BytesFormat) Bytes() ([]byte, error) {
+	if f.current == FormatFile || (f.isOutput && f.fresh()) {
+		return f.data, nil
+	}
+	return decodeFormat(f.current, f.entry.Text)
+}
+
+// SetBytes shows data in the selected format. Data that is not valid UTF-8
+// switches a text field to hexdump (output) or hex (input).
+func (f *BytesFormat) SetBytes(data []byte) {
+	if f.isOutput {
+		f.data = data
+		if f.current == FormatText && !utf8.Valid(data) {
+			f.SetFormat(FormatHexdump)
+			return
+		}
+		f.render()
+		return
+	}
+
+	if f.current == FormatFile || (f.current == FormatText && !utf8.Valid(data)) {
+		f.SetFormat(FormatHex)
+	}
+	f.entry.SetText(encodeFormat(f.current, data))
+}
+
+func (f *BytesFormat) SetFormat(format string) {
+	f.Select.SetSelected(format)
+}
+
+func (f *BytesFormat) Reset() {
+	f.data = nil
+	if f.current == FormatFile {
+		f.SetFormat(FormatText)
+	}
+	f.entry.SetText("")
+}
+
+func (f *BytesFormat) changed(format string) {
+	previous := f.current
+	if format == previous {
+		return
+	}
+	f.current = format
+
+	if f.isOutput {
+		if !f.fresh() {
+			f.data = nil
+		}
+		f.render()
+		return
+	}
+
+	if format == FormatFile {
+		f.openFile(previous)
+		return
+	}
+
+	f.entry.Enable()
+	if previous == FormatFile {
+		f.entry.SetText(encodeFormat(format, f.data))
+		f.data = nil
+		return
+	}
+
+	// Carry the value over, so switching the format never loses it
+	if data, err := decodeFormat(previous, f.entry.Text); err == nil {
+		f.entry.SetText(encodeFormat(format, data))
+	}
+}
+
+func (f *BytesFormat) render() {
+	if f.data == nil {
+		return
+	}
+
+	if f.current == FormatFile {
+		f.saveFile()
+		return
+	}
+
+	f.setRendered(encodeFormat(f.current, f.data))
+}
+
+func (f *BytesFormat) openFile(previous string) {
+	dialog.ShowFileOpen(func(rc fyne.URIReadCloser, err error) {
+		if err != nil || rc == nil {
+			f.SetFormat(previous)
+			return
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			log.Println("File read error:", err)
+			dialog.ShowError(err, GetWindow())
+			f.SetFormat(previous)
+			return
+		}
+
+		f.data = data
+		f.entry.SetText(rc.URI().Name())
+		f.entry.Disable()
+	}, GetWindow())
+}
+
+func (f *BytesFormat) saveFile() {
+	data := f.data
+	dialog.ShowFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil || w == nil {
+			return
+		}
+		defer w.Close()
+
+		if _, err := w.Write(data); err != nil {
+			log.Println("File write error:", err)
+			dialog.ShowError(err, GetWindow())
+			return
+		}
+
+		f.setRendered(lang.L("SavedTo") + " " + w.URI().Path())
+	}, GetWindow())
+}
+
+// fresh reports whether the output entry still shows data, i.e. it was not
+// overwritten by an error message or edited by hand since.
+func (f *BytesFormat) fresh() bool {
+	return f.data != nil && f.entry.Text == f.rendered
+}
+
+func (f *BytesFormat) setRendered(text string) {
+	f.rendered = text
+	f.entry.SetText(text)
+}
+
+func decodeFormat(format, text string) ([]byte, error) {
+	switch format {
+	case FormatText:
+		return []byte(text), nil
+	case FormatHex:
+		return hex.DecodeString(strings.Join(strings.Fields(text), ""))
+	case FormatBase64:
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	case FormatBase64URL:
+		text = strings.TrimRight(strings.Join(strings.Fields(text), ""), "=")
+		return base64.RawURLEncoding.DecodeString(text)
+	}
+
+	return nil, errors.New(lang.L("Incorrect") + " " + format)
+}
+
+func encodeFormat(format string, data []byte) string {
+	switch format {
+	case FormatHex:
+		return hex.EncodeToString(data)
+	case FormatHexdump:
+		return hex.Dump(data)
+	case FormatBase64:
+		return base64.StdEncoding.EncodeToString(data)
+	case FormatBase64URL:
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	return string(data)
+}
+
+// SwapFormats exchanges the formats of an input and an output field when a
+// form toggles direction, e.g. text→base64 for encryption becomes base64→text.
+// File formats are left alone so toggling never pops up a file dialog.
+func SwapFormats(input, output *BytesFormat) {
+	in, out := input.current, output.current
+	if in == FormatFile || out == FormatFile {
+		return
+	}
+	if out == FormatHexdump {
+		out = FormatHex
+	}
+
+	input.SetFormat(out)
+	output.SetFormat(in)
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// GetKeyFileButtons returns buttons that load a key file into entry and save
+// the text of entry to a file. Text files such as PEM are shown as they are,
+// binary ones (DER, raw keys) as base64.
+func GetKeyFileButtons(entry *widget.Entry, loadLabel, saveLabel, fileName string) (load, save *widget.Button) {
+	load = widget.NewButton(loadLabel, func() {
+		dialog.ShowFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil || rc == nil {
+				return
+			}
+			defer rc.Close()
+
+			data, err := io.ReadAll(rc)
+			if err != nil {
+				log.Println("Key load error:", err)
+				dialog.ShowError(err, GetWindow())
+				return
+			}
+			if utf8.Valid(data) {
+				entry.SetText(string(data))
+			} else {
+				entry.SetText(base64.StdEncoding.EncodeToString(data))
+			}
+		}, GetWindow())
+	})
+
+	save = widget.NewButton(saveLabel, func() {
+		if entry.Text == "" {
+			return
+		}
+		data := []byte(entry.Text)
+		saveDialog := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil || w == nil {
+				return
+			}
+			defer w.Close()
+			if _, err := w.Write(data); err != nil {
+				log.Println("Key save error:", err)
+				dialog.ShowError(err, GetWindow())
+			}
+		}, GetWindow())
+		saveDialog.SetFileName(fileName)
+		saveDialog.Show()
+	})
+
+	return load, save
+}
+
+func IsPEM(s string) bool {
+	return strings.HasPrefix(strings.TrimSpace(s), "-----BEGIN")
+}
+
+// DecodeKeyText reads a key pasted as hex or base64.
+func DecodeKeyText(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	if key, err := hex.DecodeString(s); err == nil {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return key, nil
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,136 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Task runs one long job (file encryption, hashing) off the UI goroutine
+// with a progress bar, throughput and a cancel button.
+type Task struct {
+	Bar          *widget.ProgressBar
+	CancelButton *widget.Button
+	Status       *widget.Label
+	cancel       context.CancelFunc
+	total        atomic.Int64
+}
+
+func GetTask() *Task {
+	t := &Task{
+		Bar:    widget.NewProgressBar(),
+		Status: widget.NewLabel(""),
+	}
+	t.CancelButton = widget.NewButton(lang.L("Cancel"), func() {
+		if t.cancel != nil {
+			t.cancel()
+		}
+	})
+	t.CancelButton.Disable()
+
+	return t
+}
+
+func (t *Task) Container() *fyne.Container {
+	return container.NewVBox(
+		container.NewBorder(nil, nil, nil, t.CancelButton, t.Bar),
+		t.Status,
+	)
+}
+
+func (t *Task) Running() bool {
+	return t.cancel != nil
+}
+
+// Start runs job in the background. total is the expected number of bytes
+// reported through progress (0 if unknown). done is called on the UI
+// goroutine with the job result.
+func (t *Task) Start(total int64, job func(ctx context.Context, progress func(int64)) error, done func(error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.total.Store(total)
+	t.Bar.Max = float64(max(total, 1))
+	t.Bar.SetValue(0)
+	t.Status.SetText("")
+	t.CancelButton.Enable()
+
+	start := time.Now()
+	var lastUpdate time.Time
+	progress := func(n int64) {
+		if time.Since(lastUpdate) < 100*time.Millisecond {
+			return
+		}
+		lastUpdate = time.Now()
+		status := throughput(n, t.total.Load(), time.Since(start))
+		fyne.Do(func() {
+			t.Bar.SetValue(float64(n))
+			t.Status.SetText(status)
+		})
+	}
+
+	go func() {
+		err := job(ctx, progress)
+		elapsed := time.Since(start)
+		fyne.Do(func() {
+			cancel()
+			t.cancel = nil
+			t.CancelButton.Disable()
+
+			switch {
+			case errors.Is(err, context.Canceled):
+				t.Status.SetText(lang.L("Cancelled"))
+			case err != nil:
+				log.Println("Task error:", err)
+				t.Status.SetText("Error: " + err.Error())
+			default:
+				t.Bar.SetValue(t.Bar.Max)
+				total := t.total.Load()
+				t.Status.SetText(lang.L("Done") + " " + throughput(total, total, elapsed))
+			}
+
+			if done != nil {
+				done(err)
+			}
+		})
+	}()
+}
+
+// SetTotal updates the expected byte count once a job has measured its input.
+// It may be called from the job goroutine.
+func (t *Task) SetTotal(total int64) {
+	t.total.Store(total)
+	fyne.Do(func() {
+		t.Bar.Max = float64(max(total, 1))
+		t.Bar.Refresh()
+	})
+}
+
+func throughput(n, total int64, elapsed time.Duration) string {
+	rate := float64(n) / max(elapsed.Seconds(), 0.001)
+	if total > 0 {
+		return fmt.Sprintf("%s / %s (%s/s)", FormatSize(n), FormatSize(total), FormatSize(int64(rate)))
+	}
+	return fmt.Sprintf("%s (%s/s)", FormatSize(n), FormatSize(int64(rate)))
+}
+
+// FormatSize renders a byte count with a binary unit, e.g. "1.5 MiB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -1,19 +1,26 @@
 package registry
 
 import (
+	"pararti/chify/core"
 	"pararti/chify/internal/service"
+	"pararti/chify/internal/service/compress"
 	encoding2 "pararti/chify/internal/service/encode"
 	encrypt2 "pararti/chify/internal/service/encrypt"
 	hash2 "pararti/chify/internal/service/hash"
+	"pararti/chify/internal/service/kdf"
+	"pararti/chify/internal/service/recipe"
+	"pararti/chify/internal/service/sign"
 )
 
 type SubMenuElement struct {
-	Name    string
-	Service service.FormBuilder
+	Name       string
+	Service    service.FormBuilder
+	Operations []*core.Operation // headless operations behind the form, used by the command line
 }
 
 type MenuElement struct {
 	Category string
+	Command  string // command line verb, e.g. "encrypt" for chify encrypt aes
 	Elements []*SubMenuElement
 }
 
@@ -22,49 +29,214 @@ var DefaultService = encrypt2.NewAES()
 var LeftServiceMenu = []*MenuElement{
 	{
 		Category: "crypto",
+		Command:  "encrypt",
 		Elements: []*SubMenuElement{
 			{
-				Name:    "aes",
-				Service: encrypt2.NewAES(),
+				Name:       "aes",
+				Service:    encrypt2.NewAES(),
+				Operations: []*core.Operation{core.AESOperation(), core.OpenSSLOperation()},
 			},
 			{
-				Name:    "chacha20",
-				Service: encrypt2.NewChaCha20(),
+				Name:       "chacha20",
+				Service:    encrypt2.NewChaCha20(),
+				Operations: []*core.Operation{core.ChaCha20Operation()},
 			},
 			{
-				Name:    "ml-kem",
-				Service: encrypt2.NewMLKEM(),
+				Name:       "salsa20",
+				Service:    encrypt2.NewSalsa20(),
+				Operations: []*core.Operation{core.Salsa20Operation()},
+			},
+			{
+				Name:       "block",
+				Service:    encrypt2.NewBlockCipher(),
+				Operations: []*core.Operation{core.BlockCipherOperation()},
+			},
+			{
+				Name:       "file",
+				Service:    encrypt2.NewStream(),
+				Operations: []*core.Operation{core.StreamOperation()},
+			},
+			{
+				Name:       "box",
+				Service:    encrypt2.NewBox(),
+				Operations: []*core.Operation{core.BoxOperation()},
+			},
+			{
+				Name:       "age",
+				Service:    encrypt2.NewAge(),
+				Operations: []*core.Operation{core.AgeOperation()},
+			},
+			{
+				Name:       "hpke",
+				Service:    encrypt2.NewHPKE(),
+				Operations: []*core.Operation{core.HPKEOperation()},
+			},
+			{
+				Name:       "ecdh",
+				Service:    encrypt2.NewECDH(),
+				Operations: []*core.Operation{core.ECDHOperation()},
+			},
+			{
+				Name:       "rsa",
+				Service:    encrypt2.NewRSA(),
+				Operations: []*core.Operation{core.RSAOperation(), core.RSASignOperation()},
+			},
+			{
+				Name:       "ml-kem",
+				Service:    encrypt2.NewMLKEM(),
+				Operations: []*core.Operation{core.MLKEMOperation()},
+			},
+		},
+	},
+	{
+		Category: "sign",
+		Command:  "sign",
+		Elements: []*SubMenuElement{
+			{
+				Name:       "ed25519",
+				Service:    sign.NewEd25519(),
+				Operations: []*core.Operation{core.Ed25519Operation()},
+			},
+			{
+				Name:       "ecdsa",
+				Service:    sign.NewECDSA(),
+				Operations: []*core.Operation{core.ECDSAOperation()},
 			},
 		},
 	},
 	{
 		Category: "encode",
+		Command:  "encode",
 		Elements: []*SubMenuElement{
 			{
-				Name:    "ascii85",
-				Service: encoding2.NewAscii85(),
+				Name:       "ascii85",
+				Service:    encoding2.NewAscii85(),
+				Operations: []*core.Operation{core.EncodeOperation("ascii85")},
 			},
 			{
-				Name:    "base",
-				Service: encoding2.NewBase(),
+				Name:       "base",
+				Service:    encoding2.NewBase(),
+				Operations: []*core.Operation{core.EncodeOperation("base32"), core.EncodeOperation("base64")},
 			},
 			{
-				Name:    "hex",
-				Service: encoding2.NewHex(),
+				Name:       "hex",
+				Service:    encoding2.NewHex(),
+				Operations: []*core.Operation{core.EncodeOperation("hex")},
 			},
 		},
 	},
 	{
 		Category: "hash",
+		Command:  "hash",
+		Elements: []*SubMenuElement{
+			{
+				Name:       "md5",
+				Service:    hash2.NewMd5(),
+				Operations: []*core.Operation{core.HashOperation("md5"), core.HMACOperation("md5")},
+			},
+			{
+				Name:       "sha",
+				Service:    hash2.NewSha(),
+				Operations: hashOperations(core.ShaAlgorithms),
+			},
+		},
+	},
+	{
+		Category: "kdf",
+		Command:  "kdf",
 		Elements: []*SubMenuElement{
 			{
-				Name:    "md5",
-				Service: hash2.NewMd5(),
+				Name:       "hkdf",
+				Service:    kdf.NewHKDF(),
+				Operations: []*core.Operation{core.HKDFOperation()},
+			},
+			{
+				Name:       "pbkdf2",
+				Service:    kdf.NewPBKDF2(),
+				Operations: []*core.Operation{core.KDFOperation(core.KDFPBKDF2)},
 			},
 			{
-				Name:    "sha",
-				Service: hash2.NewSha(),
+				Name:       "scrypt",
+				Service:    kdf.NewScrypt(),
+				Operations: []*core.Operation{core.KDFOperation(core.KDFScrypt)},
+			},
+			{
+				Name:    "argon2",
+				Service: kdf.NewArgon2(),
+				Operations: []*core.Operation{
+					core.KDFOperation(core.KDFArgon2id),
+					core.KDFOperation(core.KDFArgon2i),
+				},
 			},
 		},
 	},
+	{
+		Category: "compress",
+		Command:  "compress",
+		Elements: []*SubMenuElement{
+			{
+				Name:    "gzip/zlib",
+				Service: compress.NewCompress(),
+				Operations: []*core.Operation{
+					core.CompressOperation("gzip"),
+					core.CompressOperation("zlib"),
+					core.CompressOperation("deflate"),
+				},
+			},
+		},
+	},
+}
+
+// The recipe form chains the operations of every other element, so it is
+// registered once the menu above is initialized.
+func init() {
+	LeftServiceMenu = append(LeftServiceMenu, &MenuElement{
+		Category: "recipe",
+		Elements: []*SubMenuElement{
+			{
+				Name:    "recipe",
+				Service: recipe.NewRecipe(Operations, FindOperation),
+			},
+		},
+	})
+}
+
+// Operations returns every headless operation in menu order.
+func Operations() []*core.Operation {
+	var ops []*core.Operation
+	for _, menuEl := range LeftServiceMenu {
+		for _, subMenuEl := range menuEl.Elements {
+			ops = append(ops, subMenuEl.Operations...)
+		}
+	}
+	return ops
+}
+
+// hashOperations returns the plain digest operations followed by their HMAC variants.
+func hashOperations(names []string) []*core.Operation {
+	ops := make([]*core.Operation, 0, 2*len(names))
+	for _, name := range names {
+		ops = append(ops, core.HashOperation(name))
+	}
+	for _, name := range names {
+		ops = append(ops, core.HMACOperation(name))
+	}
+	return ops
+}
+
+// FindOperation looks up an operation by command line verb (or category) and name.
+func FindOperation(command, name string) (*core.Operation, bool) {
+	for _, menuEl := range LeftServiceMenu {
+		if menuEl.Command != command && menuEl.Category != command {
+			continue
+		}
+		for _, subMenuEl := range menuEl.Elements {
+			for _, op := range subMenuEl.Operations {
+				if op.Name == name {
+					return op, true
+				}
+			}
+		}
+	}
+	return nil, false
 }
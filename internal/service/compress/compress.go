@@ -0,0 +1,92 @@
+package compress
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_encoding"
+)
+
+type Compress struct {
+	Name string
+}
+
+func NewCompress() *Compress {
+	return &Compress{Name: "Compress(gzip, zlib, deflate)"}
+}
+
+func (c *Compress) BuildForm() *fyne.Container {
+	header := common.GetHeader(c.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+	modeToggle, actionButton := common_encoding.GetActionButton()
+
+	actionButton.Text = lang.L("Compress")
+	modeToggle.Text = lang.L("Decompress")
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Decompress"))
+		} else {
+			actionButton.SetText(lang.L("Compress"))
+		}
+		common.SwapFormats(inputFormat, outputFormat)
+	}
+
+	// Compression selector
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelector := widget.NewSelect(core.Compressions, nil)
+	modeSelector.SetSelected("gzip")
+	var currentCompression = "gzip"
+
+	modeSelector.OnChanged = func(selected string) {
+		currentCompression = selected
+	}
+
+	actionButton.OnTapped = func() {
+		if inputEntry.Text == "" {
+			return
+		}
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				var result []byte
+				if modeToggle.Checked {
+					result, err = core.Decompress(currentCompression, data)
+				} else {
+					result, err = core.Compress(currentCompression, data)
+				}
+
+				if err != nil {
+					log.Println("Compression error: ", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				outputFormat.SetBytes(result)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(modeLabel, modeSelector),
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewVBox(modeToggle, actionButton),
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
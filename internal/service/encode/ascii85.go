@@ -1,11 +1,10 @@
 package encoding
 
 import (
-	"encoding/ascii85"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"log"
-	"math"
+	"pararti/chify/core"
 	"pararti/chify/internal/common"
 	"pararti/chify/internal/common/common_encoding"
 )
@@ -20,10 +19,10 @@ func NewAscii85() *Ascii85 {
 
 func (a *Ascii85) BuildForm() *fyne.Container {
 	header := common.GetHeader(a.Name)
-	inputLabel, inputEntry, resetButton := common.GetInput()
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
 	modeToggle, actionButton := common_encoding.GetActionButton()
 
-	outputLabel, outputEntry, copyButton := common.GetOutput()
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
 	outputLabel.SetText(outputLabel.Text)
 
 	actionButton.OnTapped = func() {
@@ -36,19 +35,20 @@ func (a *Ascii85) BuildForm() *fyne.Container {
 					actionButton.Disable()
 					defer actionButton.Enable()
 
-					dstSize := int(math.Ceil(float64(len([]byte(inputEntry.Text))) * 0.8))
-					println(dstSize)
-
-					dst := make([]byte, dstSize)
-					_, _, err := ascii85.Decode(dst, []byte(inputEntry.Text), true)
+					data, err := inputFormat.Bytes()
+					if err != nil {
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
 
+					result, err := core.Decode("ascii85", data)
 					if err != nil {
 						log.Println("Decoding error: ", err)
 						outputEntry.SetText("Error: " + err.Error())
 						return
 					}
 
-					outputEntry.SetText(string(dst))
+					outputFormat.SetBytes(result)
 				})
 			}()
 		} else {
@@ -57,12 +57,20 @@ func (a *Ascii85) BuildForm() *fyne.Container {
 					actionButton.Disable()
 					defer actionButton.Enable()
 
-					dstSize := int(math.Ceil(float64(len([]byte(inputEntry.Text))) * 1.25))
-					dstSize += (5 - dstSize%5) % 5 //the size must be a multiple of 5
-					dst := make([]byte, dstSize)
-					ascii85.Encode(dst, []byte(inputEntry.Text))
+					data, err := inputFormat.Bytes()
+					if err != nil {
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+
+					result, err := core.Encode("ascii85", data)
+					if err != nil {
+						log.Println("Encoding error: ", err)
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
 
-					outputEntry.SetText(string(dst))
+					outputFormat.SetBytes(result)
 				})
 			}()
 		}
@@ -70,10 +78,10 @@ func (a *Ascii85) BuildForm() *fyne.Container {
 
 	return container.NewVBox(
 		header,
-		inputLabel,
+		container.NewHBox(inputLabel, inputFormat),
 		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
 		container.NewVBox(modeToggle, actionButton),
-		outputLabel,
+		container.NewHBox(outputLabel, outputFormat),
 		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
 	)
 }
@@ -1,13 +1,12 @@
 package encoding
 
 import (
-	"encoding/base32"
-	"encoding/base64"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/widget"
 	"log"
+	"pararti/chify/core"
 	"pararti/chify/internal/common"
 	"pararti/chify/internal/common/common_encoding"
 )
@@ -33,7 +32,7 @@ func NewBase() *Base {
 
 func (b *Base) BuildForm() *fyne.Container {
 	header := common.GetHeader(b.Name)
-	inputLabel, inputEntry, resetButton := common.GetInput()
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
 	modeToggle, actionButton := common_encoding.GetActionButton()
 
 	// Coding selector
@@ -51,7 +50,7 @@ func (b *Base) BuildForm() *fyne.Container {
 		}
 	}
 
-	outputLabel, outputEntry, copyButton := common.GetOutput()
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
 	outputLabel.SetText(outputLabel.Text)
 
 	actionButton.OnTapped = func() {
@@ -64,22 +63,20 @@ func (b *Base) BuildForm() *fyne.Container {
 					actionButton.Disable()
 					defer actionButton.Enable()
 
-					var result []byte
-					var err error
-					switch currentBase {
-					case BASE32:
-						result, err = base32.StdEncoding.DecodeString(inputEntry.Text)
-					case BASE64:
-						result, err = base64.StdEncoding.DecodeString(inputEntry.Text)
+					data, err := inputFormat.Bytes()
+					if err != nil {
+						outputEntry.SetText("Error: " + err.Error())
+						return
 					}
 
+					result, err := core.Decode(currentBase.String(), data)
 					if err != nil {
 						log.Println("Decoding error: ", err)
 						outputEntry.SetText("Error: " + err.Error())
 						return
 					}
 
-					outputEntry.SetText(string(result))
+					outputFormat.SetBytes(result)
 				})
 			}()
 		} else {
@@ -88,15 +85,20 @@ func (b *Base) BuildForm() *fyne.Container {
 					actionButton.Disable()
 					defer actionButton.Enable()
 
-					result := ""
-					switch currentBase {
-					case BASE32:
-						result = base32.StdEncoding.EncodeToString([]byte(inputEntry.Text))
-					case BASE64:
-						result = base64.StdEncoding.EncodeToString([]byte(inputEntry.Text))
+					data, err := inputFormat.Bytes()
+					if err != nil {
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+
+					result, err := core.Encode(currentBase.String(), data)
+					if err != nil {
+						log.Println("Encoding error: ", err)
+						outputEntry.SetText("Error: " + err.Error())
+						return
 					}
 
-					outputEntry.SetText(result)
+					outputFormat.SetBytes(result)
 				})
 			}()
 		}
@@ -105,10 +107,10 @@ func (b *Base) BuildForm() *fyne.Container {
 	return container.NewVBox(
 		header,
 		container.NewHBox(baseModeLabel, baseModeSelector),
-		inputLabel,
+		container.NewHBox(inputLabel, inputFormat),
 		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
 		container.NewVBox(modeToggle, actionButton),
-		outputLabel,
+		container.NewHBox(outputLabel, outputFormat),
 		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
 	)
 }
@@ -1,10 +1,10 @@
 package encoding
 
 import (
-	"encoding/hex"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"log"
+	"pararti/chify/core"
 	"pararti/chify/internal/common"
 	"pararti/chify/internal/common/common_encoding"
 )
@@ -19,10 +19,10 @@ func NewHex() *Hex {
 
 func (h *Hex) BuildForm() *fyne.Container {
 	header := common.GetHeader(h.Name)
-	inputLabel, inputEntry, resetButton := common.GetInput()
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
 	modeToggle, actionButton := common_encoding.GetActionButton()
 
-	outputLabel, outputEntry, copyButton := common.GetOutput()
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
 	outputLabel.SetText(outputLabel.Text)
 
 	actionButton.OnTapped = func() {
@@ -35,16 +35,20 @@ func (h *Hex) BuildForm() *fyne.Container {
 					actionButton.Disable()
 					defer actionButton.Enable()
 
-					result := make([]byte, len([]byte(inputEntry.Text)))
-					_, err := hex.Decode(result, []byte(inputEntry.Text))
+					data, err := inputFormat.Bytes()
+					if err != nil {
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
 
+					result, err := core.Decode("hex", data)
 					if err != nil {
 						log.Println("Decoding error: ", err)
 						outputEntry.SetText("Error: " + err.Error())
 						return
 					}
 
-					outputEntry.SetText(string(result))
+					outputFormat.SetBytes(result)
 				})
 			}()
 		} else {
@@ -53,9 +57,20 @@ func (h *Hex) BuildForm() *fyne.Container {
 					actionButton.Disable()
 					defer actionButton.Enable()
 
-					result := hex.EncodeToString([]byte(inputEntry.Text))
+					data, err := inputFormat.Bytes()
+					if err != nil {
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+
+					result, err := core.Encode("hex", data)
+					if err != nil {
+						log.Println("Encoding error: ", err)
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
 
-					outputEntry.SetText(result)
+					outputFormat.SetBytes(result)
 				})
 			}()
 		}
@@ -63,10 +78,10 @@ func (h *Hex) BuildForm() *fyne.Container {
 
 	return container.NewVBox(
 		header,
-		inputLabel,
+		container.NewHBox(inputLabel, inputFormat),
 		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
 		container.NewVBox(modeToggle, actionButton),
-		outputLabel,
+		container.NewHBox(outputLabel, outputFormat),
 		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
 	)
 }
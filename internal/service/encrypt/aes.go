@@ -1,39 +1,41 @@
 package encrypt
 
 import (
-	"bytes"
-	"crypto/aes"
-	"crypto/cipher"
 	"crypto/rand"
-	"encoding/base64"
-	"encoding/hex"
 	"errors"
-	"io"
 	"log"
+	"pararti/chify/core"
 	"pararti/chify/internal/common"
 	"pararti/chify/internal/common/common_encrypt"
 	"strconv"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/widget"
 )
 
-type AES struct {
-	Name string
-}
-
-type encryptionMode int
-
+// Output profiles of the AES form: the chify layout with the IV or nonce in
+// front, or the Salted__ format of openssl enc.
 const (
-	modeCBC encryptionMode = iota
-	modeGCM
-	modeCTR
+	profileChify   = "chify"
+	profileOpenSSL = "OpenSSL enc"
 )
 
-func (m encryptionMode) String() string {
-	return [...]string{"CBC", "GCM", "CTR"}[m]
+var aesModeDescriptions = map[core.BlockMode]string{
+	core.BlockModeCBC:    "CBC - Cipher Block Chaining",
+	core.BlockModeGCM:    "GCM - Galois/Counter Mode (Authenticated)",
+	core.BlockModeCTR:    "CTR - Counter Mode",
+	core.BlockModeCFB:    "CFB - Cipher Feedback",
+	core.BlockModeOFB:    "OFB - Output Feedback",
+	core.BlockModeECB:    "ECB - Electronic Codebook (no IV, legacy data only)",
+	core.BlockModeXTS:    "XTS - XEX Tweaked Codebook (disk sectors, 32 or 64 byte key)",
+	core.BlockModeGCMSIV: "GCM-SIV - Nonce Misuse Resistant GCM (Authenticated, RFC 8452)",
+}
+
+type AES struct {
+	Name string
 }
 
 func NewAES() *AES {
@@ -42,54 +44,115 @@ func NewAES() *AES {
 
 func (a *AES) BuildForm() *fyne.Container {
 	header := common.GetHeader(a.Name)
-	inputLabel, inputEntry, resetButton := common.GetInput()
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
 	modeToggle, actionButton := common_encrypt.GetActionButton()
 
 	modeLabel := widget.NewLabel(lang.L("Mode"))
-	modeSelect := widget.NewSelect([]string{"CBC", "GCM", "CTR"}, nil)
+	modeSelect := widget.NewSelect(core.AESModes(), nil)
 	modeSelect.SetSelected("CBC")
-	var currentMode encryptionMode = modeCBC
+	var currentMode = core.BlockModeCBC
 
-	modeDescription := widget.NewLabel("CBC - Cipher Block Chaining")
+	modeDescription := widget.NewLabel(aesModeDescriptions[currentMode])
 	modeDescription.TextStyle.Italic = true
 
-	modeSelect.OnChanged = func(selected string) {
-		switch selected {
-		case "CBC":
-			currentMode = modeCBC
-			modeDescription.SetText("CBC - Cipher Block Chaining")
-		case "GCM":
-			currentMode = modeGCM
-			modeDescription.SetText("GCM - Galois/Counter Mode (Authenticated)")
-		case "CTR":
-			currentMode = modeCTR
-			modeDescription.SetText("CTR - Counter Mode")
+	ecbWarning := widget.NewLabel(lang.L("InsecureECB"))
+	ecbWarning.Wrapping = fyne.TextWrapWord
+	ecbWarning.Importance = widget.WarningImportance
+	ecbWarning.Hide()
+
+	sectorEntry := widget.NewEntry()
+	sectorEntry.SetText("0")
+	sectorSizeEntry := widget.NewEntry()
+	sectorSizeEntry.SetText("512")
+	for _, entry := range []*widget.Entry{sectorEntry, sectorSizeEntry} {
+		entry.Validator = func(s string) error {
+			if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+				return errors.New(lang.L("Incorrect"))
+			}
+			return nil
 		}
 	}
+	xtsRows := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel(lang.L("Sector")), nil, sectorEntry),
+		container.NewBorder(nil, nil, widget.NewLabel(lang.L("SectorSize")), nil, sectorSizeEntry),
+	)
+	xtsRows.Hide()
+
+	paddingSelect := widget.NewSelect(core.Paddings(), nil)
+	paddingSelect.SetSelected(core.PaddingPKCS7.String())
+	paddingRow := container.NewHBox(widget.NewLabel(lang.L("Padding")), paddingSelect)
+
+	profileLabel := widget.NewLabel(lang.L("Profile"))
+	profileSelect := widget.NewSelect([]string{profileChify, profileOpenSSL}, nil)
+	profileSelect.SetSelected(profileChify)
+
+	gcmFields := common_encrypt.GetGCMFields()
+	gcmFields.Container().Hide()
+	opensslFields := common_encrypt.GetOpenSSLFields()
+	opensslFields.Container().Hide()
+
+	// set below, once the key and passphrase rows exist
+	updateFields := func() {}
+
+	profileSelect.OnChanged = func(string) {
+		updateFields()
+	}
 
 	keyLabel := widget.NewLabel(lang.L("Key"))
-	keyEntry := widget.NewEntry()
+	keyEntry, keyFormat := common.GetBytesEntry(common.FormatText)
 	keyEntry.PlaceHolder = lang.L("KeyAesError")
-	keyEntry.OnChanged = func(key string) {
-		kLen := len([]byte(key))
-		switch kLen {
-		case 16:
-			keyLabel.SetText(lang.L("Key") + " aes128")
-		case 24:
-			keyLabel.SetText(lang.L("Key") + " aes192")
-		case 32:
-			keyLabel.SetText(lang.L("Key") + " aes256")
-		default:
+	keyEntry.OnChanged = func(string) {
+		key, err := keyFormat.Bytes()
+		if err != nil {
+			keyLabel.SetText(lang.L("Key") + " " + lang.L("Incorrect") + " " + keyFormat.Selected)
+			return
+		}
+		kLen := len(key)
+		if keyValidator(currentMode, key) != nil {
 			keyLabel.SetText(lang.L("Key") + " " + lang.L("IncorrectKeyCount") + " " + strconv.Itoa(kLen))
+			return
+		}
+		if currentMode == core.BlockModeXTS {
+			// two keys, one for the data and one for the tweak
+			kLen /= 2
 		}
+		keyLabel.SetText(lang.L("Key") + " aes" + strconv.Itoa(kLen*8))
+	}
+	keyEntry.Validator = func(string) error {
+		key, err := keyFormat.Bytes()
+		if err != nil {
+			return err
+		}
+		return keyValidator(currentMode, key)
 	}
-	keyEntry.Validator = keyValidator
 
-	outputLabel, outputEntry, copyButton := common.GetOutput()
-	outputLabel.SetText(outputLabel.Text + " (base64)")
+	modeSelect.OnChanged = func(selected string) {
+		mode, err := core.ParseAESMode(selected)
+		if err != nil {
+			return
+		}
+		currentMode = mode
+		modeDescription.SetText(aesModeDescriptions[mode])
+		keyEntry.OnChanged(keyEntry.Text)
+		updateFields()
+	}
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Decrypt"))
+		} else {
+			actionButton.SetText(lang.L("Encrypt"))
+		}
+		common.SwapFormats(inputFormat, outputFormat)
+	}
 
 	generateKeyButton := widget.NewButton(lang.L("Generate"), func() {
 		key := make([]byte, 32)
+		if currentMode == core.BlockModeXTS {
+			key = make([]byte, 64)
+		}
 		_, err := rand.Read(key)
 		if err != nil {
 			log.Println("Error generating random key:", err)
@@ -97,68 +160,181 @@ func (a *AES) BuildForm() *fyne.Container {
 			return
 		}
 
-		keyEntry.SetText(hex.EncodeToString(key)[:32])
+		keyFormat.SetFormat(common.FormatHex)
+		keyFormat.SetBytes(key)
 	})
 
+	keyRow := container.NewHBox(keyLabel, keyFormat)
+	keyEntryRow := container.NewBorder(nil, nil, nil, generateKeyButton, keyEntry)
+	passphrase := common_encrypt.GetPassphraseFields()
+	passphraseRows := passphrase.Container()
+
+	updateFields = func() {
+		openssl := profileSelect.Selected == profileOpenSSL
+		if openssl {
+			// openssl enc has no AEAD modes
+			modeSelect.Options = []string{"CBC", "CTR"}
+			if currentMode != core.BlockModeCBC && currentMode != core.BlockModeCTR {
+				modeSelect.SetSelected("CBC")
+				return
+			}
+		} else {
+			modeSelect.Options = core.AESModes()
+		}
+		modeSelect.Refresh()
+
+		setVisible(opensslFields.Container(), openssl)
+		setVisible(passphraseRows, !openssl)
+		setVisible(keyRow, !openssl && !passphrase.Enabled())
+		setVisible(keyEntryRow, !openssl && !passphrase.Enabled())
+		setVisible(gcmFields.Container(), !openssl && currentMode == core.BlockModeGCM)
+		setVisible(xtsRows, !openssl && currentMode == core.BlockModeXTS)
+		setVisible(paddingRow, !openssl && (currentMode == core.BlockModeCBC || currentMode == core.BlockModeECB))
+		setVisible(ecbWarning, currentMode == core.BlockModeECB)
+	}
+	passphrase.OnChanged = func(bool) {
+		updateFields()
+	}
+
 	actionButton.OnTapped = func() {
-		err := keyEntry.Validate()
-		if err != nil {
-			keyEntry.SetValidationError(err)
+		if profileSelect.Selected == profileOpenSSL {
+			if opensslFields.Validate() != nil || inputEntry.Text == "" {
+				return
+			}
+			go func() {
+				fyne.Do(func() {
+					actionButton.Disable()
+					defer actionButton.Enable()
+
+					data, err := inputFormat.Bytes()
+					if err != nil {
+						log.Println("Input decode error:", err)
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+
+					var result []byte
+					params := opensslFields.Params(currentMode)
+					if modeToggle.Checked {
+						result, err = core.DecryptOpenSSL(params, opensslFields.Password(), data)
+					} else {
+						result, err = core.EncryptOpenSSL(params, opensslFields.Password(), data)
+					}
+					if err != nil {
+						log.Println("Encryption error:", err)
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+					outputFormat.SetBytes(result)
+				})
+			}()
 			return
 		}
-		c, err := createCipher([]byte(keyEntry.Text))
+
+		validated := keyEntry
+		if passphrase.Enabled() {
+			validated = passphrase.Entry
+		}
+		err := validated.Validate()
 		if err != nil {
-			log.Println(err)
-			keyEntry.SetValidationError(err)
+			validated.SetValidationError(err)
 			return
 		}
-
 		if inputEntry.Text == "" {
 			return
 		}
 
-		go func() {
-			fyne.Do(func() {
-				actionButton.Disable()
-				defer actionButton.Enable()
-				output := ""
+		key, _ := keyFormat.Bytes()
+		gcmParams, err := gcmFields.Params()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		var xtsParams core.XTSParams
+		if currentMode == core.BlockModeXTS {
+			for _, entry := range []*widget.Entry{sectorEntry, sectorSizeEntry} {
+				if err := entry.Validate(); err != nil {
+					entry.SetValidationError(err)
+					return
+				}
+			}
+			sector, _ := strconv.ParseUint(sectorEntry.Text, 10, 64)
+			sectorSize, _ := strconv.Atoi(sectorSizeEntry.Text)
+			xtsParams = core.XTSParams{Sector: sector, SectorSize: sectorSize}
+		}
 
-				if modeToggle.Checked {
-					decodedInput, err := base64.StdEncoding.DecodeString(inputEntry.Text)
+		run := func() {
+			go func() {
+				fyne.Do(func() {
+					actionButton.Disable()
+					defer actionButton.Enable()
+
+					data, err := inputFormat.Bytes()
 					if err != nil {
-						log.Println("Base64 decode error:", err)
-						outputEntry.SetText("Error: Invalid Base64 input")
+						log.Println("Input decode error:", err)
+						outputEntry.SetText("Error: " + err.Error())
 						return
 					}
 
-					var decryptedData []byte
-					switch currentMode {
-					case modeCBC:
-						decryptedData = decryptCBC(c, decodedInput)
-					case modeGCM:
-						decryptedData, err = decryptGCM(c, decodedInput)
-					case modeCTR:
-						decryptedData, err = decryptCTR(c, decodedInput)
-					}
-
+					padding, err := core.ParsePadding(paddingSelect.Selected)
 					if err != nil {
-						log.Println("Decryption error:", err)
 						outputEntry.SetText("Error: " + err.Error())
 						return
 					}
 
-					output = string(decryptedData)
-				} else {
-					var encryptedData []byte
-					var err error
-
-					switch currentMode {
-					case modeCBC:
-						encryptedData = encryptCBC(c, []byte(inputEntry.Text))
-					case modeGCM:
-						encryptedData, err = encryptGCM(c, []byte(inputEntry.Text))
-					case modeCTR:
-						encryptedData, err = encryptCTR(c, []byte(inputEntry.Text))
+					var nonce, tag []byte
+					encrypt := func(key []byte) ([]byte, error) {
+						if currentMode == core.BlockModeXTS {
+							return core.EncryptAESXTS(key, xtsParams, data)
+						}
+						if currentMode != core.BlockModeGCM {
+							return core.EncryptAESWithPadding(currentMode, padding, key, data)
+						}
+						if !gcmFields.Separate() {
+							return core.EncryptAESGCM(key, gcmParams, data)
+						}
+						var ciphertext []byte
+						var err error
+						nonce, ciphertext, tag, err = core.SealAESGCM(key, gcmParams, data)
+						return ciphertext, err
+					}
+					decrypt := func(key, data []byte) ([]byte, error) {
+						if currentMode == core.BlockModeXTS {
+							return core.DecryptAESXTS(key, xtsParams, data)
+						}
+						if currentMode != core.BlockModeGCM {
+							return core.DecryptAESWithPadding(currentMode, padding, key, data)
+						}
+						if !gcmFields.Separate() {
+							return core.DecryptAESGCM(key, gcmParams, data)
+						}
+						tag, err := gcmFields.TagFormat.Bytes()
+						if err != nil {
+							return nil, err
+						}
+						return core.OpenAESGCM(key, gcmParams, data, tag)
+					}
+
+					var result []byte
+					switch {
+					case passphrase.Enabled() && modeToggle.Checked:
+						result, err = core.OpenWithPassphrase(passphrase.Passphrase(), data, decrypt)
+					case passphrase.Enabled():
+						var params core.KDFParams
+						if params, err = passphrase.Params(); err == nil {
+							result, err = core.SealWithPassphrase(passphrase.Passphrase(), params, encrypt)
+						}
+					case modeToggle.Checked:
+						result, err = decrypt(key, data)
+					default:
+						result, err = encrypt(key)
+						if err == nil && currentMode == core.BlockModeGCM {
+							used := gcmParams.Nonce
+							if nonce != nil {
+								used = nonce
+							}
+							gcmFields.Remember(key, used)
+						}
 					}
 
 					if err != nil {
@@ -167,156 +343,63 @@ func (a *AES) BuildForm() *fyne.Container {
 						return
 					}
 
-					output = base64.StdEncoding.EncodeToString(encryptedData)
-				}
+					if tag != nil {
+						gcmFields.SetSeparate(nonce, tag, len(gcmParams.Nonce) == 0)
+					}
+					outputFormat.SetBytes(result)
+				})
+			}()
+		}
 
-				outputEntry.SetText(output)
-			})
-		}()
+		// An explicit nonce this form already used with the same key would
+		// expose both plaintexts and the authentication key
+		encrypting := !modeToggle.Checked && !passphrase.Enabled() && currentMode == core.BlockModeGCM
+		if encrypting && gcmFields.Reused(key, gcmParams.Nonce) {
+			dialog.ShowConfirm(lang.L("NonceReused"), lang.L("NonceReuseWarning"), func(ok bool) {
+				if ok {
+					run()
+				}
+			}, common.GetWindow())
+			return
+		}
+		run()
 	}
 
 	return container.NewVBox(
 		header,
+		container.NewHBox(profileLabel, profileSelect),
 		container.NewHBox(modeLabel, modeSelect),
 		modeDescription,
-		inputLabel,
+		ecbWarning,
+		paddingRow,
+		container.NewHBox(inputLabel, inputFormat),
 		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
-		keyLabel,
-		container.NewBorder(nil, nil, nil, generateKeyButton, keyEntry),
+		passphraseRows,
+		keyRow,
+		keyEntryRow,
+		gcmFields.Container(),
+		xtsRows,
+		opensslFields.Container(),
 		container.NewVBox(modeToggle, actionButton),
-		outputLabel,
+		container.NewHBox(outputLabel, outputFormat),
 		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
 	)
 }
 
-func encryptCBC(c cipher.Block, data []byte) []byte {
-	data = pKCS7Padding(data, c.BlockSize())
-	iv := make([]byte, c.BlockSize())
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
-		log.Println("Error generating IV:", err)
-		return []byte{}
-	}
-
-	mode := cipher.NewCBCEncrypter(c, iv)
-	result := make([]byte, len(data))
-	mode.CryptBlocks(result, data)
-
-	result = append(iv, result...)
-
-	return result
-}
-
-func decryptCBC(c cipher.Block, data []byte) []byte {
-	blockSize := c.BlockSize()
-
-	if len(data) < blockSize {
-		log.Println("Data too short for decryption")
-		return []byte("Error: data too short for decryption")
-	}
-
-	iv := data[:blockSize]
-	data = data[blockSize:]
-
-	mode := cipher.NewCBCDecrypter(c, iv)
-	result := make([]byte, len(data))
-	mode.CryptBlocks(result, data)
-
-	result = pKCS7UnPadding(result)
-
-	return result
-}
-
-func encryptGCM(c cipher.Block, data []byte) ([]byte, error) {
-	gcm, err := cipher.NewGCM(c)
-	if err != nil {
-		return nil, err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
-
-	result := gcm.Seal(nonce, nonce, data, nil)
-	return result, nil
-}
-
-func decryptGCM(c cipher.Block, data []byte) ([]byte, error) {
-	gcm, err := cipher.NewGCM(c)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(data) < gcm.NonceSize() {
-		return nil, errors.New("ciphertext too short")
-	}
-
-	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
-
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return plaintext, nil
-}
-
-func encryptCTR(c cipher.Block, data []byte) ([]byte, error) {
-	iv := make([]byte, c.BlockSize())
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
-		return nil, err
-	}
-
-	ctr := cipher.NewCTR(c, iv)
-
-	ciphertext := make([]byte, len(data))
-	ctr.XORKeyStream(ciphertext, data)
-
-	result := append(iv, ciphertext...)
-
-	return result, nil
-}
-
-func decryptCTR(c cipher.Block, data []byte) ([]byte, error) {
-	blockSize := c.BlockSize()
-
-	if len(data) < blockSize {
-		return nil, errors.New("ciphertext too short")
-	}
-
-	iv := data[:blockSize]
-	ciphertext := data[blockSize:]
-
-	ctr := cipher.NewCTR(c, iv)
-
-	plaintext := make([]byte, len(ciphertext))
-	ctr.XORKeyStream(plaintext, ciphertext)
-
-	return plaintext, nil
-}
-
-func createCipher(key []byte) (cipher.Block, error) {
-	return aes.NewCipher(key)
-}
-
-func keyValidator(key string) error {
-	keyLength := len([]byte(key))
-	switch keyLength {
-	case 16, 24, 32:
-		return nil
+func keyValidator(mode core.BlockMode, key []byte) error {
+	switch mode {
+	case core.BlockModeXTS:
+		if len(key) != 32 && len(key) != 64 {
+			return errors.New(lang.L("KeyXTSError"))
+		}
+	case core.BlockModeGCMSIV:
+		if len(key) != 16 && len(key) != 32 {
+			return errors.New(lang.L("KeyGCMSIVError"))
+		}
 	default:
-		return errors.New(lang.L("KeyAesError"))
+		if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+			return errors.New(lang.L("KeyAesError"))
+		}
 	}
-}
-
-func pKCS7Padding(data []byte, blockSize int) []byte {
-	padding := blockSize - len(data)%blockSize
-	padText := bytes.Repeat([]byte{byte(padding)}, padding)
-	return append(data, padText...)
-}
-
-func pKCS7UnPadding(data []byte) []byte {
-	length := len(data)
-	unpadding := int(data[length-1])
-	return data[:(length - unpadding)]
+	return nil
 }
@@ -0,0 +1,223 @@
+package encrypt
+
+import (
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_encrypt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	ageModeRecipients = "X25519"
+	ageModePassphrase = "scrypt"
+)
+
+var ageModeDescriptions = map[string]string{
+	ageModeRecipients: "X25519 - encrypt to one or more age1... recipients, decrypt with an AGE-SECRET-KEY-1... identity",
+	ageModePassphrase: "scrypt - encrypt and decrypt with a passphrase",
+}
+
+// Age reads and writes age v1 files.
+type Age struct {
+	Name string
+}
+
+func NewAge() *Age {
+	return &Age{Name: "age"}
+}
+
+func (a *Age) BuildForm() *fyne.Container {
+	header := common.GetHeader(a.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	modeToggle, actionButton := common_encrypt.GetActionButton()
+
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelect := widget.NewSelect([]string{ageModeRecipients, ageModePassphrase}, nil)
+	modeSelect.SetSelected(ageModeRecipients)
+
+	modeDescription := widget.NewLabel(ageModeDescriptions[ageModeRecipients])
+	modeDescription.Wrapping = fyne.TextWrapWord
+	modeDescription.TextStyle.Italic = true
+
+	// one key per line, # comments allowed as in age key files
+	recipientsEntry := widget.NewMultiLineEntry()
+	recipientsEntry.Wrapping = fyne.TextWrapBreak
+	recipientsEntry.SetMinRowsVisible(3)
+	recipientsEntry.PlaceHolder = "age1..."
+	identitiesEntry := widget.NewMultiLineEntry()
+	identitiesEntry.Wrapping = fyne.TextWrapBreak
+	identitiesEntry.SetMinRowsVisible(3)
+	identitiesEntry.PlaceHolder = "AGE-SECRET-KEY-1..."
+
+	keysRequired := func(s string) error {
+		if len(core.ParseAgeKeys(s)) == 0 {
+			return errors.New(lang.L("Required"))
+		}
+		return nil
+	}
+	recipientsEntry.Validator = keysRequired
+	identitiesEntry.Validator = keysRequired
+
+	passphraseEntry := widget.NewPasswordEntry()
+	passphraseEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		return nil
+	}
+	workFactorEntry := widget.NewEntry()
+	workFactorEntry.SetText(strconv.Itoa(core.AgeWorkFactor))
+	workFactorEntry.Validator = func(s string) error {
+		if n, err := strconv.Atoi(s); err != nil || n < 1 || n > 22 {
+			return errors.New(lang.L("Incorrect"))
+		}
+		return nil
+	}
+
+	armorCheck := widget.NewCheck(lang.L("Armor"), nil)
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+
+	armorCheck.OnChanged = func(checked bool) {
+		if checked && !modeToggle.Checked {
+			outputFormat.SetFormat(common.FormatText)
+		}
+	}
+
+	generateButton := widget.NewButton(lang.L("GenerateKeys"), func() {
+		identity, recipient, err := core.GenerateAgeIdentity()
+		if err != nil {
+			log.Println("Error generating age identity:", err)
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		appendLine(identitiesEntry, identity)
+		appendLine(recipientsEntry, recipient)
+	})
+
+	recipientsRows := container.NewVBox(widget.NewLabel(lang.L("Recipients")), recipientsEntry)
+	identitiesRows := container.NewVBox(widget.NewLabel(lang.L("Identities")), identitiesEntry)
+	workFactorRow := container.NewBorder(nil, nil, widget.NewLabel(lang.L("ScryptCost")), nil, workFactorEntry)
+	passphraseRows := container.NewVBox(widget.NewLabel(lang.L("Passphrase")), passphraseEntry, workFactorRow)
+
+	updateFields := func() {
+		passphrase := modeSelect.Selected == ageModePassphrase
+		setVisible(recipientsRows, !passphrase && !modeToggle.Checked)
+		setVisible(identitiesRows, !passphrase && modeToggle.Checked)
+		setVisible(passphraseRows, passphrase)
+		setVisible(workFactorRow, !modeToggle.Checked)
+		setVisible(armorCheck, !modeToggle.Checked)
+		setVisible(generateButton, !passphrase)
+	}
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Decrypt"))
+		} else {
+			actionButton.SetText(lang.L("Encrypt"))
+		}
+		common.SwapFormats(inputFormat, outputFormat)
+		updateFields()
+	}
+	modeSelect.OnChanged = func(selected string) {
+		modeDescription.SetText(ageModeDescriptions[selected])
+		updateFields()
+	}
+	updateFields()
+
+	actionButton.OnTapped = func() {
+		passphrase := modeSelect.Selected == ageModePassphrase
+		var validated []*widget.Entry
+		switch {
+		case passphrase && !modeToggle.Checked:
+			validated = append(validated, passphraseEntry, workFactorEntry)
+		case passphrase:
+			validated = append(validated, passphraseEntry)
+		case modeToggle.Checked:
+			validated = append(validated, identitiesEntry)
+		default:
+			validated = append(validated, recipientsEntry)
+		}
+		for _, entry := range validated {
+			if err := entry.Validate(); err != nil {
+				entry.SetValidationError(err)
+				return
+			}
+		}
+
+		if inputEntry.Text == "" {
+			return
+		}
+
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					log.Println("Input decode error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				var result []byte
+				switch {
+				case modeToggle.Checked && passphrase:
+					result, err = core.DecryptAge(nil, []byte(passphraseEntry.Text), data)
+				case modeToggle.Checked:
+					result, err = core.DecryptAge(core.ParseAgeKeys(identitiesEntry.Text), nil, data)
+				default:
+					params := core.AgeParams{Armor: armorCheck.Checked}
+					if passphrase {
+						params.Passphrase = []byte(passphraseEntry.Text)
+						params.WorkFactor, _ = strconv.Atoi(workFactorEntry.Text)
+					} else {
+						params.Recipients = core.ParseAgeKeys(recipientsEntry.Text)
+					}
+					result, err = core.EncryptAge(params, data)
+				}
+				if err != nil {
+					log.Println("age error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				outputFormat.SetBytes(result)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(modeLabel, modeSelect),
+		modeDescription,
+		generateButton,
+		recipientsRows,
+		identitiesRows,
+		passphraseRows,
+		armorCheck,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewVBox(modeToggle, actionButton),
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
+
+func appendLine(entry *widget.Entry, line string) {
+	text := strings.TrimRight(entry.Text, "\n")
+	if text != "" {
+		text += "\n"
+	}
+	entry.SetText(text + line)
+}
@@ -0,0 +1,180 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_encrypt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+var blockCipherDescriptions = map[string]string{
+	"3DES":     "3DES - Triple DES, EDE with a 16 or 24 byte key",
+	"DES":      "DES - 56 bit key, broken by brute force",
+	"Blowfish": "Blowfish - 1 to 56 byte key",
+	"Twofish":  "Twofish - AES finalist, 128 bit block, 16, 24 or 32 byte key",
+	"CAST5":    "CAST5 (CAST-128) - 16 byte key, as used by OpenPGP",
+	"TEA":      "TEA - Tiny Encryption Algorithm, 16 byte key",
+	"XTEA":     "XTEA - extended TEA, 16 byte key",
+}
+
+// BlockCipher is the form for the legacy block ciphers, with the generic
+// modes of the AES form.
+type BlockCipher struct {
+	Name string
+}
+
+func NewBlockCipher() *BlockCipher {
+	return &BlockCipher{Name: "Block Cipher"}
+}
+
+func (b *BlockCipher) BuildForm() *fyne.Container {
+	header := common.GetHeader(b.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	modeToggle, actionButton := common_encrypt.GetActionButton()
+
+	cipherDescription := widget.NewLabel(blockCipherDescriptions["3DES"])
+	cipherDescription.TextStyle.Italic = true
+
+	modeDescription := widget.NewLabel(aesModeDescriptions[core.BlockModeCBC])
+	modeDescription.TextStyle.Italic = true
+
+	ecbWarning := widget.NewLabel(lang.L("InsecureECB"))
+	ecbWarning.Wrapping = fyne.TextWrapWord
+	ecbWarning.Importance = widget.WarningImportance
+	ecbWarning.Hide()
+
+	paddingSelect := widget.NewSelect(core.Paddings(), nil)
+	paddingSelect.SetSelected(core.PaddingPKCS7.String())
+	paddingRow := container.NewHBox(widget.NewLabel(lang.L("Padding")), paddingSelect)
+
+	cipherSelect := widget.NewSelect(core.BlockCiphers(), nil)
+	cipherSelect.SetSelected("3DES")
+	modeSelect := widget.NewSelect(core.BlockModes(), nil)
+	modeSelect.SetSelected("CBC")
+	var currentMode = core.BlockModeCBC
+
+	keyLabel := widget.NewLabel(lang.L("Key"))
+	keyEntry, keyFormat := common.GetBytesEntry(common.FormatText)
+	keyEntry.Validator = func(string) error {
+		key, err := keyFormat.Bytes()
+		if err != nil {
+			return err
+		}
+		return core.CheckBlockCipherKey(cipherSelect.Selected, len(key))
+	}
+
+	cipherSelect.OnChanged = func(selected string) {
+		cipherDescription.SetText(blockCipherDescriptions[selected])
+		if keyEntry.Text != "" {
+			keyEntry.Validate()
+		}
+	}
+	modeSelect.OnChanged = func(selected string) {
+		mode, err := core.ParseBlockMode(selected)
+		if err != nil {
+			return
+		}
+		currentMode = mode
+		modeDescription.SetText(aesModeDescriptions[mode])
+		setVisible(ecbWarning, mode == core.BlockModeECB)
+		setVisible(paddingRow, mode == core.BlockModeCBC || mode == core.BlockModeECB)
+	}
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Decrypt"))
+		} else {
+			actionButton.SetText(lang.L("Encrypt"))
+		}
+		common.SwapFormats(inputFormat, outputFormat)
+	}
+
+	generateKeyButton := widget.NewButton(lang.L("Generate"), func() {
+		// the largest key the cipher takes
+		size := 32
+		for core.CheckBlockCipherKey(cipherSelect.Selected, size) != nil {
+			size -= 8
+		}
+		key := make([]byte, size)
+		_, err := rand.Read(key)
+		if err != nil {
+			log.Println("Error generating random key:", err)
+			outputEntry.SetText("Error generating random key: " + err.Error())
+			return
+		}
+
+		keyFormat.SetFormat(common.FormatHex)
+		keyFormat.SetBytes(key)
+	})
+
+	actionButton.OnTapped = func() {
+		err := keyEntry.Validate()
+		if err != nil {
+			keyEntry.SetValidationError(err)
+			return
+		}
+		if inputEntry.Text == "" {
+			return
+		}
+
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					log.Println("Input decode error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				key, _ := keyFormat.Bytes()
+				padding, err := core.ParsePadding(paddingSelect.Selected)
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				var result []byte
+				if modeToggle.Checked {
+					result, err = core.DecryptBlockCipher(cipherSelect.Selected, currentMode, padding, key, data)
+				} else {
+					result, err = core.EncryptBlockCipher(cipherSelect.Selected, currentMode, padding, key, data)
+				}
+				if err != nil {
+					log.Println("Encryption error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				outputFormat.SetBytes(result)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(widget.NewLabel(lang.L("Cipher")), cipherSelect),
+		cipherDescription,
+		container.NewHBox(widget.NewLabel(lang.L("Mode")), modeSelect),
+		modeDescription,
+		ecbWarning,
+		paddingRow,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewHBox(keyLabel, keyFormat),
+		container.NewBorder(nil, nil, nil, generateKeyButton, keyEntry),
+		container.NewVBox(modeToggle, actionButton),
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
@@ -0,0 +1,207 @@
+package encrypt
+
+import (
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_encrypt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+var boxModeDescriptions = map[core.BoxMode]string{
+	core.BoxModeBox:    "box - the recipient also learns who sent it (crypto_box_easy, nonce in front)",
+	core.BoxModeSealed: "sealed - anonymous sender, only the recipient public key is needed (crypto_box_seal)",
+}
+
+// Box encrypts to X25519 public keys with NaCl box. The own key pair signs
+// box messages and decrypts; the peer key is the other side's public key.
+type Box struct {
+	Name string
+}
+
+func NewBox() *Box {
+	return &Box{Name: "NaCl Box (X25519)"}
+}
+
+func (b *Box) BuildForm() *fyne.Container {
+	header := common.GetHeader(b.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	modeToggle, actionButton := common_encrypt.GetActionButton()
+
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelect := widget.NewSelect(core.BoxModes(), nil)
+	modeSelect.SetSelected(core.BoxModeSealed.String())
+	var currentMode = core.BoxModeSealed
+
+	modeDescription := widget.NewLabel(boxModeDescriptions[currentMode])
+	modeDescription.TextStyle.Italic = true
+
+	privateKeyEntry, privateKeyFormat := common.GetBytesEntry(common.FormatBase64)
+	publicKeyEntry, publicKeyFormat := common.GetBytesEntry(common.FormatBase64)
+	publicKeyEntry.Disable()
+	peerKeyEntry, peerKeyFormat := common.GetBytesEntry(common.FormatBase64)
+
+	keyValidator := func(format *common.BytesFormat) func(string) error {
+		return func(s string) error {
+			if s == "" {
+				return errors.New(lang.L("Required"))
+			}
+			key, err := format.Bytes()
+			if err != nil {
+				return err
+			}
+			if len(key) != 32 {
+				return errors.New(lang.L("KeyMustBe32Bytes"))
+			}
+			return nil
+		}
+	}
+	privateKeyEntry.Validator = keyValidator(privateKeyFormat)
+	peerKeyEntry.Validator = keyValidator(peerKeyFormat)
+
+	// the public key always follows the private key
+	privateKeyEntry.OnChanged = func(string) {
+		private, err := privateKeyFormat.Bytes()
+		if err != nil || len(private) != 32 {
+			publicKeyEntry.SetText("")
+			return
+		}
+		public, err := core.X25519PublicKey(private)
+		if err != nil {
+			publicKeyEntry.SetText("")
+			return
+		}
+		publicKeyFormat.SetBytes(public)
+	}
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+
+	generateKeyButton := widget.NewButton(lang.L("GenerateKeys"), func() {
+		private, _, err := core.GenerateX25519Key()
+		if err != nil {
+			log.Println("Error generating X25519 key:", err)
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		privateKeyFormat.SetBytes(private)
+	})
+	publicKeyCopyButton := widget.NewButton(lang.L("Copy"), func() {
+		if publicKeyEntry.Text != "" {
+			fyne.CurrentApp().Clipboard().SetContent(publicKeyEntry.Text)
+		}
+	})
+
+	privateKeyRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("PrivateKey")), privateKeyFormat),
+		privateKeyEntry,
+		container.NewHBox(widget.NewLabel(lang.L("PublicKey")), publicKeyFormat),
+		container.NewBorder(nil, nil, nil, publicKeyCopyButton, publicKeyEntry),
+	)
+	peerKeyRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("PeerPublicKey")), peerKeyFormat),
+		peerKeyEntry,
+	)
+
+	// a sealed box is encrypted with the peer key alone and opened with the
+	// private key alone
+	updateFields := func() {
+		setVisible(privateKeyRows, currentMode == core.BoxModeBox || modeToggle.Checked)
+		setVisible(peerKeyRows, currentMode == core.BoxModeBox || !modeToggle.Checked)
+	}
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Decrypt"))
+		} else {
+			actionButton.SetText(lang.L("Encrypt"))
+		}
+		common.SwapFormats(inputFormat, outputFormat)
+		updateFields()
+	}
+	modeSelect.OnChanged = func(selected string) {
+		mode, err := core.ParseBoxMode(selected)
+		if err != nil {
+			return
+		}
+		currentMode = mode
+		modeDescription.SetText(boxModeDescriptions[mode])
+		updateFields()
+	}
+	updateFields()
+
+	actionButton.OnTapped = func() {
+		var validated []*widget.Entry
+		if privateKeyRows.Visible() {
+			validated = append(validated, privateKeyEntry)
+		}
+		if peerKeyRows.Visible() {
+			validated = append(validated, peerKeyEntry)
+		}
+		for _, entry := range validated {
+			if err := entry.Validate(); err != nil {
+				entry.SetValidationError(err)
+				return
+			}
+		}
+
+		if inputEntry.Text == "" {
+			return
+		}
+
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				private, _ := privateKeyFormat.Bytes()
+				peer, _ := peerKeyFormat.Bytes()
+
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					log.Println("Input decode error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				var result []byte
+				switch {
+				case currentMode == core.BoxModeSealed && modeToggle.Checked:
+					result, err = core.OpenAnonymous(private, data)
+				case currentMode == core.BoxModeSealed:
+					result, err = core.SealAnonymous(peer, data)
+				case modeToggle.Checked:
+					result, err = core.OpenBox(peer, private, data)
+				default:
+					result, err = core.SealBox(peer, private, data)
+				}
+				if err != nil {
+					log.Println("Box error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				outputFormat.SetBytes(result)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(modeLabel, modeSelect),
+		modeDescription,
+		generateKeyButton,
+		privateKeyRows,
+		peerKeyRows,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewVBox(modeToggle, actionButton),
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
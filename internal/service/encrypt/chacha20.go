@@ -2,16 +2,13 @@ package encrypt
 
 import (
 	"crypto/rand"
-	"encoding/base64"
-	"encoding/hex"
 	"errors"
 	"log"
+	"pararti/chify/core"
 	"pararti/chify/internal/common"
 	"pararti/chify/internal/common/common_encrypt"
 	"strconv"
 
-	"golang.org/x/crypto/chacha20"
-
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/lang"
@@ -28,22 +25,43 @@ func NewChaCha20() *ChaCha20 {
 
 func (c *ChaCha20) BuildForm() *fyne.Container {
 	header := common.GetHeader(c.Name)
-	inputLabel, inputEntry, resetButton := common.GetInput()
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
 	modeToggle, actionButton := common_encrypt.GetActionButton()
 
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelect := widget.NewSelect(core.ChaChaModes(), nil)
+	modeSelect.SetSelected("ChaCha20")
+	var currentMode = core.ChaChaModeRaw
+
+	modeDescription := widget.NewLabel("ChaCha20 - stream cipher without authentication")
+	modeDescription.TextStyle.Italic = true
+
+	aadLabel := widget.NewLabel(lang.L("AssociatedData"))
+	aadEntry, aadFormat := common.GetBytesEntry(common.FormatText)
+
 	keyLabel := widget.NewLabel(lang.L("Key"))
-	keyEntry := widget.NewEntry()
+	keyEntry, keyFormat := common.GetBytesEntry(common.FormatText)
 	keyEntry.PlaceHolder = lang.L("KeyMustBe32Bytes")
 
 	nonceLabel := widget.NewLabel(lang.L("Nonce"))
-	nonceEntry := widget.NewEntry()
+	nonceEntry, nonceFormat := common.GetBytesEntry(common.FormatText)
 	nonceEntry.PlaceHolder = lang.L("NonceMustBe12Bytes")
 
 	counterLabel := widget.NewLabel(lang.L("Counter"))
 	counterEntry := widget.NewEntry()
 	counterEntry.Text = "1"
 
-	outputLabel, outputEntry, copyButton := common.GetOutput()
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Decrypt"))
+		} else {
+			actionButton.SetText(lang.L("Encrypt"))
+		}
+		common.SwapFormats(inputFormat, outputFormat)
+	}
 
 	// Generate random key and nonce buttons
 	generateKeyButton := widget.NewButton(lang.L("Generate"), func() {
@@ -55,7 +73,8 @@ func (c *ChaCha20) BuildForm() *fyne.Container {
 			return
 		}
 
-		keyEntry.SetText(hex.EncodeToString(key)[:32])
+		keyFormat.SetFormat(common.FormatHex)
+		keyFormat.SetBytes(key)
 	})
 
 	generateNonceButton := widget.NewButton(lang.L("Generate"), func() {
@@ -67,7 +86,8 @@ func (c *ChaCha20) BuildForm() *fyne.Container {
 			return
 		}
 
-		nonceEntry.SetText(hex.EncodeToString(nonce)[:12])
+		nonceFormat.SetFormat(common.FormatHex)
+		nonceFormat.SetBytes(nonce)
 	})
 
 	keyEntry.Validator = func(s string) error {
@@ -75,7 +95,12 @@ func (c *ChaCha20) BuildForm() *fyne.Container {
 			return errors.New(lang.L("Required"))
 		}
 
-		if len([]byte(keyEntry.Text)) != 32 {
+		key, err := keyFormat.Bytes()
+		if err != nil {
+			return err
+		}
+
+		if len(key) != 32 {
 			return errors.New(lang.L("KeyMustBe32Bytes"))
 		}
 
@@ -87,7 +112,12 @@ func (c *ChaCha20) BuildForm() *fyne.Container {
 			return errors.New(lang.L("Required"))
 		}
 
-		if len([]byte(nonceEntry.Text)) != 12 {
+		nonce, err := nonceFormat.Bytes()
+		if err != nil {
+			return err
+		}
+
+		if len(nonce) != 12 {
 			return errors.New(lang.L("NonceMustBe12Bytes"))
 		}
 
@@ -99,27 +129,70 @@ func (c *ChaCha20) BuildForm() *fyne.Container {
 			return errors.New("Error: " + lang.L("Counter") + " " + lang.L("Required"))
 		}
 
+		if _, err := strconv.ParseUint(s, 10, 32); err != nil {
+			return errors.New(lang.L("CounterMustBeUint32"))
+		}
+
 		return nil
 	}
 
+	// A passphrase replaces the key. The nonce is only entered for plain
+	// ChaCha20 without a passphrase, otherwise it is random and stored with
+	// the ciphertext; the counter only applies to plain ChaCha20.
+	keyRows := container.NewVBox(
+		container.NewHBox(keyLabel, keyFormat),
+		container.NewBorder(nil, nil, nil, generateKeyButton, keyEntry),
+	)
+	nonceRows := container.NewVBox(
+		container.NewHBox(nonceLabel, nonceFormat),
+		container.NewBorder(nil, nil, nil, generateNonceButton, nonceEntry),
+	)
+	counterRows := container.NewVBox(counterLabel, counterEntry)
+	aadRows := container.NewVBox(container.NewHBox(aadLabel, aadFormat), aadEntry)
+	passphrase := common_encrypt.GetPassphraseFields(keyRows)
+
+	updateFields := func() {
+		setVisible(nonceRows, currentMode == core.ChaChaModeRaw && !passphrase.Enabled())
+		setVisible(counterRows, currentMode == core.ChaChaModeRaw)
+		setVisible(aadRows, currentMode != core.ChaChaModeRaw)
+	}
+	passphrase.OnChanged = func(bool) { updateFields() }
+
+	modeSelect.OnChanged = func(selected string) {
+		switch selected {
+		case "ChaCha20":
+			currentMode = core.ChaChaModeRaw
+			modeDescription.SetText("ChaCha20 - stream cipher without authentication")
+		case "ChaCha20-Poly1305":
+			currentMode = core.ChaChaModePoly1305
+			modeDescription.SetText("ChaCha20-Poly1305 - authenticated, 12 byte nonce stored with the ciphertext")
+		case "XChaCha20-Poly1305":
+			currentMode = core.ChaChaModeXPoly1305
+			modeDescription.SetText("XChaCha20-Poly1305 - authenticated, random 24 byte nonce stored with the ciphertext")
+		}
+		updateFields()
+	}
+	updateFields()
+
 	actionButton.OnTapped = func() {
 		// Validate inputs
-		err := keyEntry.Validate()
-		if err != nil {
-			keyEntry.SetValidationError(err)
-			return
+		var validated []*widget.Entry
+		switch {
+		case passphrase.Enabled():
+			validated = append(validated, passphrase.Entry)
+		case currentMode == core.ChaChaModeRaw:
+			validated = append(validated, keyEntry, nonceEntry)
+		default:
+			validated = append(validated, keyEntry)
 		}
-
-		err = nonceEntry.Validate()
-		if err != nil {
-			nonceEntry.SetValidationError(err)
-			return
+		if currentMode == core.ChaChaModeRaw {
+			validated = append(validated, counterEntry)
 		}
-
-		err = counterEntry.Validate()
-		if err != nil {
-			counterEntry.SetValidationError(err)
-			return
+		for _, entry := range validated {
+			if err := entry.Validate(); err != nil {
+				entry.SetValidationError(err)
+				return
+			}
 		}
 
 		if inputEntry.Text == "" {
@@ -132,67 +205,70 @@ func (c *ChaCha20) BuildForm() *fyne.Container {
 				actionButton.Disable()
 				defer actionButton.Enable()
 
-				keyBytes := []byte(keyEntry.Text)
-				nonceBytes := []byte(nonceEntry.Text)
-
-				var counter uint32 = 1
-				if counterVal, err := strconv.ParseUint(counterEntry.Text, 10, 32); err == nil {
-					counter = uint32(counterVal)
-				} else {
-					log.Println("Invalid counter value, using 1:", err)
-				}
-
-				if modeToggle.Checked {
-					ciphertext, err := base64.StdEncoding.DecodeString(inputEntry.Text)
-					if err != nil {
-						outputEntry.SetText("Error: Invalid Base64 input")
-						return
-					}
-
-					cipher, err := chacha20.NewUnauthenticatedCipher(keyBytes, nonceBytes)
-					if err != nil {
-						outputEntry.SetText("Error: " + err.Error())
-						return
-					}
-
-					cipher.SetCounter(counter)
+				keyBytes, _ := keyFormat.Bytes()
+				nonceBytes, _ := nonceFormat.Bytes()
 
-					plaintext := make([]byte, len(ciphertext))
-					cipher.XORKeyStream(plaintext, ciphertext)
+				// validated above whenever the mode uses it
+				counter, _ := strconv.ParseUint(counterEntry.Text, 10, 32)
 
-					outputEntry.SetText(string(plaintext))
-				} else {
-					plaintext := []byte(inputEntry.Text)
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				aad, err := aadFormat.Bytes()
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
 
-					cipher, err := chacha20.NewUnauthenticatedCipher(keyBytes, nonceBytes)
-					if err != nil {
-						outputEntry.SetText("Error: " + err.Error())
-						return
+				var result []byte
+				switch {
+				case passphrase.Enabled() && modeToggle.Checked:
+					result, err = core.DecryptChaCha20Passphrase(currentMode, passphrase.Passphrase(), uint32(counter), aad, data)
+				case passphrase.Enabled():
+					var params core.KDFParams
+					if params, err = passphrase.Params(); err == nil {
+						result, err = core.EncryptChaCha20Passphrase(currentMode, passphrase.Passphrase(), params, uint32(counter), aad, data)
 					}
-
-					cipher.SetCounter(counter)
-
-					ciphertext := make([]byte, len(plaintext))
-					cipher.XORKeyStream(ciphertext, plaintext)
-
-					outputEntry.SetText(base64.StdEncoding.EncodeToString(ciphertext))
+				case currentMode == core.ChaChaModeRaw:
+					result, err = core.XORChaCha20(keyBytes, nonceBytes, uint32(counter), data)
+				case modeToggle.Checked:
+					result, err = core.OpenChaCha20(currentMode, keyBytes, aad, data)
+				default:
+					result, err = core.SealChaCha20(currentMode, keyBytes, aad, data)
+				}
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
 				}
+
+				outputFormat.SetBytes(result)
 			})
 		}()
 	}
 
 	return container.NewVBox(
 		header,
-		inputLabel,
+		container.NewHBox(modeLabel, modeSelect),
+		modeDescription,
+		container.NewHBox(inputLabel, inputFormat),
 		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
-		keyLabel,
-		container.NewBorder(nil, nil, nil, generateKeyButton, keyEntry),
-		nonceLabel,
-		container.NewBorder(nil, nil, nil, generateNonceButton, nonceEntry),
-		counterLabel,
-		counterEntry,
+		passphrase.Container(),
+		keyRows,
+		nonceRows,
+		counterRows,
+		aadRows,
 		container.NewVBox(modeToggle, actionButton),
-		outputLabel,
+		container.NewHBox(outputLabel, outputFormat),
 		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
 	)
 }
+
+func setVisible(obj fyne.CanvasObject, visible bool) {
+	if visible {
+		obj.Show()
+	} else {
+		obj.Hide()
+	}
+}
@@ -0,0 +1,237 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ecdhKeySizes are the HKDF output sizes offered for the derived key.
+var ecdhKeySizes = map[string]int{
+	"AES-128":  16,
+	"AES-192":  24,
+	"AES-256":  32,
+	"ChaCha20": 32,
+}
+
+var ecdhKeyTargets = []string{"AES-128", "AES-192", "AES-256", "ChaCha20"}
+
+// ECDH computes the shared secret of a local private key and a peer public
+// key, optionally stretched with HKDF into a symmetric key. Peer keys may be
+// pasted raw (hex or base64), as SPKI PEM or as a JWK.
+type ECDH struct {
+	Name string
+}
+
+func NewECDH() *ECDH {
+	return &ECDH{Name: "ECDH"}
+}
+
+func (e *ECDH) BuildForm() *fyne.Container {
+	header := common.GetHeader(e.Name)
+
+	curveSelect := widget.NewSelect(core.ECDHCurves, nil)
+	curveSelect.SetSelected(core.ECDHCurves[0])
+
+	privateKeyEntry, privateKeyFormat := common.GetBytesEntry(common.FormatBase64)
+	publicFormatSelect := widget.NewSelect(core.ECDHPublicKeyFormats, nil)
+	publicFormatSelect.SetSelected(core.ECDHPublicKeyFormats[0])
+	publicKeyEntry := widget.NewMultiLineEntry()
+	publicKeyEntry.Wrapping = fyne.TextWrapBreak
+	publicKeyEntry.Disable()
+	publicKeyCopyButton := widget.NewButton(lang.L("Copy"), func() {
+		if publicKeyEntry.Text != "" {
+			fyne.CurrentApp().Clipboard().SetContent(publicKeyEntry.Text)
+		}
+	})
+	_, savePublicKeyButton := common.GetKeyFileButtons(publicKeyEntry, "", lang.L("SavePublicKey"), "ecdh.pub")
+
+	peerKeyEntry := widget.NewMultiLineEntry()
+	peerKeyEntry.Wrapping = fyne.TextWrapBreak
+	peerKeyEntry.SetMinRowsVisible(3)
+	loadPeerKeyButton, _ := common.GetKeyFileButtons(peerKeyEntry, lang.L("LoadPublicKey"), "", "")
+
+	hkdfCheck := widget.NewCheck("HKDF", nil)
+	hashSelect := widget.NewSelect(core.ShaAlgorithms, nil)
+	hashSelect.SetSelected("sha256")
+	keyTargetSelect := widget.NewSelect(ecdhKeyTargets, nil)
+	keyTargetSelect.SetSelected("AES-256")
+	saltEntry, saltFormat := common.GetBytesEntry(common.FormatHex)
+	infoEntry, infoFormat := common.GetBytesEntry(common.FormatText)
+
+	actionButton := widget.NewButton(lang.L("ComputeSharedKey"), nil)
+	sharedKeyEntry, sharedKeyFormat := common.GetBytesEntry(common.FormatHex)
+	derivedKeyEntry, derivedKeyFormat := common.GetBytesEntry(common.FormatHex)
+
+	// raw public keys are shown as hex, SPKI and JWK as text
+	showPublicKey := func() {
+		private, err := privateKeyFormat.Bytes()
+		if err != nil {
+			publicKeyEntry.SetText("")
+			return
+		}
+		public, err := core.ECDHPublicKey(curveSelect.Selected, private)
+		if err != nil {
+			publicKeyEntry.SetText("")
+			return
+		}
+		encoded, err := core.EncodeECDHPublicKey(curveSelect.Selected, public, publicFormatSelect.Selected)
+		if err != nil {
+			log.Println("ECDH public key error:", err)
+			return
+		}
+		if publicFormatSelect.Selected == "raw" {
+			publicKeyEntry.SetText(hex.EncodeToString(encoded))
+		} else {
+			publicKeyEntry.SetText(string(encoded))
+		}
+	}
+
+	privateKeyEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		private, err := privateKeyFormat.Bytes()
+		if err != nil {
+			return err
+		}
+		_, err = core.ECDHPublicKey(curveSelect.Selected, private)
+		return err
+	}
+	peerKeyEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := parseECDHPeerKey(curveSelect.Selected, s)
+		return err
+	}
+
+	// the public key always follows the private key
+	privateKeyEntry.OnChanged = func(string) { showPublicKey() }
+	publicFormatSelect.OnChanged = func(string) { showPublicKey() }
+	curveSelect.OnChanged = func(string) {
+		privateKeyEntry.SetText("")
+		publicKeyEntry.SetText("")
+		sharedKeyEntry.SetText("")
+		derivedKeyEntry.SetText("")
+		if peerKeyEntry.Text != "" {
+			peerKeyEntry.Validate()
+		}
+	}
+
+	generateKeyButton := widget.NewButton(lang.L("GenerateKeys"), func() {
+		private, _, err := core.GenerateECDHKey(curveSelect.Selected)
+		if err != nil {
+			log.Println("Error generating ECDH key:", err)
+			sharedKeyEntry.SetText("Error: " + err.Error())
+			return
+		}
+		privateKeyFormat.SetBytes(private)
+	})
+
+	hkdfRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("HashName")), hashSelect, widget.NewLabel(lang.L("KeySize")), keyTargetSelect),
+		container.NewHBox(widget.NewLabel(lang.L("Salt")), saltFormat),
+		saltEntry,
+		container.NewHBox(widget.NewLabel(lang.L("Info")), infoFormat),
+		infoEntry,
+	)
+	derivedKeyRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("DerivedKey")), derivedKeyFormat),
+		derivedKeyEntry,
+	)
+	hkdfCheck.OnChanged = func(checked bool) {
+		setVisible(hkdfRows, checked)
+		setVisible(derivedKeyRows, checked)
+	}
+	hkdfCheck.OnChanged(false)
+
+	actionButton.OnTapped = func() {
+		for _, entry := range []*widget.Entry{privateKeyEntry, peerKeyEntry} {
+			if err := entry.Validate(); err != nil {
+				entry.SetValidationError(err)
+				return
+			}
+		}
+
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				curve := curveSelect.Selected
+				private, _ := privateKeyFormat.Bytes()
+				peer, _ := parseECDHPeerKey(curve, peerKeyEntry.Text)
+				shared, err := core.ECDH(curve, private, peer)
+				if err != nil {
+					log.Println("ECDH error:", err)
+					sharedKeyEntry.SetText("Error: " + err.Error())
+					return
+				}
+				sharedKeyFormat.SetBytes(shared)
+
+				if !hkdfCheck.Checked {
+					return
+				}
+				salt, err := saltFormat.Bytes()
+				if err != nil {
+					derivedKeyEntry.SetText("Error: " + err.Error())
+					return
+				}
+				info, err := infoFormat.Bytes()
+				if err != nil {
+					derivedKeyEntry.SetText("Error: " + err.Error())
+					return
+				}
+				key, err := core.HKDF(hashSelect.Selected, shared, salt, info, ecdhKeySizes[keyTargetSelect.Selected])
+				if err != nil {
+					log.Println("HKDF error:", err)
+					derivedKeyEntry.SetText("Error: " + err.Error())
+					return
+				}
+				derivedKeyFormat.SetBytes(key)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(widget.NewLabel(lang.L("Curve")), curveSelect, generateKeyButton),
+		container.NewHBox(widget.NewLabel(lang.L("PrivateKey")), privateKeyFormat),
+		privateKeyEntry,
+		container.NewHBox(widget.NewLabel(lang.L("PublicKey")), publicFormatSelect),
+		container.NewBorder(nil, nil, nil, publicKeyCopyButton, publicKeyEntry),
+		savePublicKeyButton,
+		widget.NewLabel(lang.L("PeerPublicKey")),
+		peerKeyEntry,
+		loadPeerKeyButton,
+		hkdfCheck,
+		hkdfRows,
+		actionButton,
+		container.NewHBox(widget.NewLabel(lang.L("SharedKey")), sharedKeyFormat),
+		sharedKeyEntry,
+		derivedKeyRows,
+	)
+}
+
+// parseECDHPeerKey reads a peer key given as PEM, JWK, or raw or DER bytes
+// in hex or base64.
+func parseECDHPeerKey(curve, s string) ([]byte, error) {
+	text := strings.TrimSpace(s)
+	if common.IsPEM(text) || strings.HasPrefix(text, "{") {
+		return core.ParseECDHPublicKey(curve, []byte(text))
+	}
+	data, err := common.DecodeKeyText(text)
+	if err != nil {
+		return nil, err
+	}
+	return core.ParseECDHPublicKey(curve, data)
+}
@@ -0,0 +1,318 @@
+package encrypt
+
+import (
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_encrypt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+var hpkeModeDescriptions = map[core.HPKEMode]string{
+	core.HPKEModeBase: "Base - anyone with the recipient public key can encrypt",
+	core.HPKEModePSK:  "PSK - the sender must also know a pre-shared key, which authenticates it",
+}
+
+// HPKE encrypts to a recipient public key as in RFC 9180. Encrypting
+// produces enc, the encapsulated key, next to the ciphertext; both are
+// needed to decrypt. Each message uses a fresh context (single-shot API).
+type HPKE struct {
+	Name string
+}
+
+func NewHPKE() *HPKE {
+	return &HPKE{Name: "HPKE (RFC 9180)"}
+}
+
+func (h *HPKE) BuildForm() *fyne.Container {
+	header := common.GetHeader(h.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	modeToggle, actionButton := common_encrypt.GetActionButton()
+
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelect := widget.NewSelect(core.HPKEModes(), nil)
+	modeSelect.SetSelected(core.HPKEModeBase.String())
+	var currentMode = core.HPKEModeBase
+
+	modeDescription := widget.NewLabel(hpkeModeDescriptions[currentMode])
+	modeDescription.TextStyle.Italic = true
+
+	kemSelect := widget.NewSelect(core.HPKEKEMs(), nil)
+	kemSelect.SetSelected(core.HPKEKEMX25519.String())
+	kdfSelect := widget.NewSelect(core.HPKEKDFs(), nil)
+	kdfSelect.SetSelected(core.HPKEKDFSHA256.String())
+	aeadSelect := widget.NewSelect(core.HPKEAEADs(), nil)
+	aeadSelect.SetSelected(core.HPKEAEADAES128GCM.String())
+
+	currentKEM := func() core.HPKEKEM {
+		kem, _ := core.ParseHPKEKEM(kemSelect.Selected)
+		return kem
+	}
+	exportOnly := func() bool {
+		return aeadSelect.Selected == core.HPKEAEADExportOnly.String()
+	}
+
+	privateKeyEntry, privateKeyFormat := common.GetBytesEntry(common.FormatBase64)
+	publicKeyEntry, publicKeyFormat := common.GetBytesEntry(common.FormatBase64)
+	publicKeyEntry.Disable()
+	peerKeyEntry, peerKeyFormat := common.GetBytesEntry(common.FormatBase64)
+	encEntry, encFormat := common.GetBytesEntry(common.FormatBase64)
+	infoEntry, infoFormat := common.GetBytesEntry(common.FormatText)
+	aadEntry, aadFormat := common.GetBytesEntry(common.FormatText)
+	pskEntry, pskFormat := common.GetBytesEntry(common.FormatText)
+	pskIDEntry, pskIDFormat := common.GetBytesEntry(common.FormatText)
+	exporterContextEntry, exporterContextFormat := common.GetBytesEntry(common.FormatText)
+	exportLengthEntry := widget.NewEntry()
+	exportLengthEntry.SetText("32")
+	exportedEntry, exportedFormat := common.GetBytesEntry(common.FormatHex)
+
+	required := func(format *common.BytesFormat) func(string) error {
+		return func(s string) error {
+			if s == "" {
+				return errors.New(lang.L("Required"))
+			}
+			_, err := format.Bytes()
+			return err
+		}
+	}
+	privateKeyEntry.Validator = func(s string) error {
+		if err := required(privateKeyFormat)(s); err != nil {
+			return err
+		}
+		private, _ := privateKeyFormat.Bytes()
+		_, err := core.HPKEPublicKey(currentKEM(), private)
+		return err
+	}
+	peerKeyEntry.Validator = required(peerKeyFormat)
+	encEntry.Validator = required(encFormat)
+	pskEntry.Validator = required(pskFormat)
+	pskIDEntry.Validator = required(pskIDFormat)
+	exportLengthEntry.Validator = func(s string) error {
+		if n, err := strconv.Atoi(s); err != nil || n < 0 {
+			return errors.New(lang.L("Incorrect"))
+		}
+		return nil
+	}
+
+	// the public key always follows the private key
+	privateKeyEntry.OnChanged = func(string) {
+		private, err := privateKeyFormat.Bytes()
+		if err != nil {
+			publicKeyEntry.SetText("")
+			return
+		}
+		public, err := core.HPKEPublicKey(currentKEM(), private)
+		if err != nil {
+			publicKeyEntry.SetText("")
+			return
+		}
+		publicKeyFormat.SetBytes(public)
+	}
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+
+	generateKeyButton := widget.NewButton(lang.L("GenerateKeys"), func() {
+		private, _, err := core.GenerateHPKEKey(currentKEM())
+		if err != nil {
+			log.Println("Error generating HPKE key:", err)
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		privateKeyFormat.SetBytes(private)
+	})
+	publicKeyCopyButton := widget.NewButton(lang.L("Copy"), func() {
+		if publicKeyEntry.Text != "" {
+			fyne.CurrentApp().Clipboard().SetContent(publicKeyEntry.Text)
+		}
+	})
+
+	privateKeyRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("PrivateKey")), privateKeyFormat),
+		privateKeyEntry,
+		container.NewHBox(widget.NewLabel(lang.L("PublicKey")), publicKeyFormat),
+		container.NewBorder(nil, nil, nil, publicKeyCopyButton, publicKeyEntry),
+	)
+	peerKeyRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("PeerPublicKey")), peerKeyFormat),
+		peerKeyEntry,
+	)
+	pskRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("PSK")), pskFormat),
+		pskEntry,
+		container.NewHBox(widget.NewLabel(lang.L("PSKID")), pskIDFormat),
+		pskIDEntry,
+	)
+	messageRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("AssociatedData")), aadFormat),
+		aadEntry,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+	)
+
+	// The sender needs the recipient public key, the recipient its own key
+	// pair; an Export-only suite has no message, only exported secrets.
+	updateFields := func() {
+		setVisible(privateKeyRows, modeToggle.Checked)
+		setVisible(peerKeyRows, !modeToggle.Checked)
+		setVisible(pskRows, currentMode == core.HPKEModePSK)
+		setVisible(messageRows, !exportOnly())
+	}
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Decrypt"))
+		} else {
+			actionButton.SetText(lang.L("Encrypt"))
+		}
+		common.SwapFormats(inputFormat, outputFormat)
+		updateFields()
+	}
+	modeSelect.OnChanged = func(selected string) {
+		mode, err := core.ParseHPKEMode(selected)
+		if err != nil {
+			return
+		}
+		currentMode = mode
+		modeDescription.SetText(hpkeModeDescriptions[mode])
+		updateFields()
+	}
+	kemSelect.OnChanged = func(string) {
+		privateKeyEntry.OnChanged(privateKeyEntry.Text)
+	}
+	aeadSelect.OnChanged = func(string) { updateFields() }
+	updateFields()
+
+	actionButton.OnTapped = func() {
+		validated := []*widget.Entry{exportLengthEntry}
+		if modeToggle.Checked {
+			validated = append(validated, privateKeyEntry, encEntry)
+		} else {
+			validated = append(validated, peerKeyEntry)
+		}
+		if currentMode == core.HPKEModePSK {
+			validated = append(validated, pskEntry, pskIDEntry)
+		}
+		for _, entry := range validated {
+			if err := entry.Validate(); err != nil {
+				entry.SetValidationError(err)
+				return
+			}
+		}
+
+		if inputEntry.Text == "" && !exportOnly() {
+			return
+		}
+
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				p := core.HPKEParams{Mode: currentMode, KEM: currentKEM()}
+				p.KDF, _ = core.ParseHPKEKDF(kdfSelect.Selected)
+				p.AEAD, _ = core.ParseHPKEAEAD(aeadSelect.Selected)
+				var err error
+				if p.Info, err = infoFormat.Bytes(); err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				if currentMode == core.HPKEModePSK {
+					p.PSK, _ = pskFormat.Bytes()
+					p.PSKID, _ = pskIDFormat.Bytes()
+				}
+				aad, err := aadFormat.Bytes()
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				exporterContext, err := exporterContextFormat.Bytes()
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				exportLength, _ := strconv.Atoi(exportLengthEntry.Text)
+
+				var ctx *core.HPKEContext
+				if modeToggle.Checked {
+					private, _ := privateKeyFormat.Bytes()
+					enc, _ := encFormat.Bytes()
+					ctx, err = core.NewHPKERecipient(p, private, enc)
+				} else {
+					peer, _ := peerKeyFormat.Bytes()
+					var enc []byte
+					if enc, ctx, err = core.NewHPKESender(p, peer); err == nil {
+						encFormat.SetBytes(enc)
+					}
+				}
+				if err != nil {
+					log.Println("HPKE setup error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				if !exportOnly() {
+					data, err := inputFormat.Bytes()
+					if err != nil {
+						log.Println("Input decode error:", err)
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+					var result []byte
+					if modeToggle.Checked {
+						result, err = ctx.Open(aad, data)
+					} else {
+						result, err = ctx.Seal(aad, data)
+					}
+					if err != nil {
+						log.Println("HPKE error:", err)
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+					outputFormat.SetBytes(result)
+				}
+
+				if exportLength > 0 {
+					secret, err := ctx.Export(exporterContext, exportLength)
+					if err != nil {
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+					exportedFormat.SetBytes(secret)
+				}
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(modeLabel, modeSelect),
+		modeDescription,
+		container.NewHBox(widget.NewLabel(lang.L("KEM")), kemSelect),
+		container.NewHBox(widget.NewLabel(lang.L("KDF")), kdfSelect),
+		container.NewHBox(widget.NewLabel(lang.L("AEAD")), aeadSelect),
+		generateKeyButton,
+		privateKeyRows,
+		peerKeyRows,
+		pskRows,
+		container.NewHBox(widget.NewLabel(lang.L("Info")), infoFormat),
+		infoEntry,
+		container.NewHBox(widget.NewLabel(lang.L("EncapsulatedKey")), encFormat),
+		encEntry,
+		messageRows,
+		container.NewVBox(modeToggle, actionButton),
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+		container.NewHBox(widget.NewLabel(lang.L("ExporterContext")), exporterContextFormat),
+		exporterContextEntry,
+		container.NewBorder(nil, nil, widget.NewLabel(lang.L("ExportLength")), nil, exportLengthEntry),
+		container.NewHBox(widget.NewLabel(lang.L("ExportedSecret")), exportedFormat),
+		exportedEntry,
+	)
+}
@@ -1,33 +1,48 @@
 package encrypt
 
 import (
-	"crypto/mlkem"
 	"encoding/base64"
-	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"log"
+	"pararti/chify/core"
 	"pararti/chify/internal/common"
 	"pararti/chify/internal/common/common_encrypt"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/widget"
 )
 
-type MLKEM struct {
-	Name string
-}
-
-type keySizeMode int
+// Encodings of saved ML-KEM key files
+const (
+	keyFileRaw = "Raw"
+	keyFilePEM = "PEM"
+)
 
+// Modes of the form: exchange a key, or seal a message with it
 const (
-	mode768 keySizeMode = iota
-	mode1024
+	mlkemModeKEM    = "KEM"
+	mlkemModeKEMDEM = "KEM-DEM"
 )
 
-func (m keySizeMode) String() string {
-	return [...]string{"ML-KEM-768", "ML-KEM-1024"}[m]
+var mlkemModeDescriptions = map[string]string{
+	mlkemModeKEM:    "KEM - encapsulate a shared key to a public key, only the private key recovers it",
+	mlkemModeKEMDEM: "KEM-DEM - encrypt the input to a public key with AES-256-GCM under an encapsulated key",
+}
+
+var mlkemDescriptions = map[core.MLKEMParameterSet]string{
+	core.MLKEM768:       "ML-KEM-768 - Recommended security level (NIST Level 3)",
+	core.MLKEM1024:      "ML-KEM-1024 - Higher security level (NIST Level 5)",
+	core.X25519MLKEM768: "X25519MLKEM768 - ML-KEM-768 and X25519 as in TLS, secure while either one holds",
+}
+
+type MLKEM struct {
+	Name string
 }
 
 func NewMLKEM() *MLKEM {
@@ -36,39 +51,27 @@ func NewMLKEM() *MLKEM {
 
 func (m *MLKEM) BuildForm() *fyne.Container {
 	header := common.GetHeader(m.Name)
-	inputLabel, inputEntry, resetButton := common.GetInput()
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
 	modeToggle, actionButton := common_encrypt.GetActionButton()
 
 	actionButton.Text = lang.L("Encapsulate")
 	modeToggle.Text = lang.L("Decapsulate")
-	modeToggle.OnChanged = func(checked bool) {
-		if checked {
-			actionButton.SetText(lang.L("Decapsulate"))
-		} else {
-			actionButton.SetText(lang.L("Encapsulate"))
-		}
-	}
+
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelect := widget.NewSelect([]string{mlkemModeKEM, mlkemModeKEMDEM}, nil)
+	modeSelect.SetSelected(mlkemModeKEM)
+	modeDescription := widget.NewLabel(mlkemModeDescriptions[mlkemModeKEM])
+	modeDescription.TextStyle.Italic = true
 
 	// KeySize selector
 	keySizeLabel := widget.NewLabel(lang.L("KeySize"))
-	keySizeSelect := widget.NewSelect([]string{"ML-KEM-768", "ML-KEM-1024"}, nil)
-	keySizeSelect.SetSelected("ML-KEM-768")
-	var currentKeySize keySizeMode = mode768
+	keySizeSelect := widget.NewSelect(core.MLKEMParameterSets(), nil)
+	keySizeSelect.SetSelected(core.MLKEM768.String())
+	var currentKeySize = core.MLKEM768
 
-	keySizeDescription := widget.NewLabel("ML-KEM-768 - Recommended security level (NIST Level 3)")
+	keySizeDescription := widget.NewLabel(mlkemDescriptions[core.MLKEM768])
 	keySizeDescription.TextStyle.Italic = true
 
-	keySizeSelect.OnChanged = func(selected string) {
-		switch selected {
-		case "ML-KEM-768":
-			currentKeySize = mode768
-			keySizeDescription.SetText("ML-KEM-768 - Recommended security level (NIST Level 3)")
-		case "ML-KEM-1024":
-			currentKeySize = mode1024
-			keySizeDescription.SetText("ML-KEM-1024 - Higher security level (NIST Level 5)")
-		}
-	}
-
 	publicKeyLabel := widget.NewLabel(lang.L("PublicKey"))
 	publicKeyEntry := widget.NewMultiLineEntry()
 	publicKeyEntry.Wrapping = fyne.TextWrapBreak
@@ -91,60 +94,302 @@ func (m *MLKEM) BuildForm() *fyne.Container {
 
 	generateKeyButton := widget.NewButton(lang.L("GenerateKeys"), func() {})
 
+	keyFileLabel := widget.NewLabel(lang.L("KeyFileFormat"))
+	keyFileSelect := widget.NewSelect([]string{keyFileRaw, keyFilePEM}, nil)
+	keyFileSelect.SetSelected(keyFilePEM)
+
 	// Output display
-	sharedKeyLabel := widget.NewLabel(lang.L("SharedKey") + "(hex)")
-	sharedKeyEntry := widget.NewEntry()
+	sharedKeyLabel := widget.NewLabel(lang.L("SharedKey"))
+	sharedKeyEntry, sharedKeyFormat := common.GetBytesEntry(common.FormatHex)
 	sharedKeyCopyButton := widget.NewButton(lang.L("Copy"), func() {
 		if publicKeyEntry.Text != "" {
 			fyne.CurrentApp().Clipboard().SetContent(sharedKeyEntry.Text)
 		}
 	})
-	outputLabel, outputEntry, copyButton := common.GetOutput()
-	outputLabel.SetText(outputLabel.Text + "(base64)")
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+	inputFormat.SetFormat(common.FormatBase64)
+
+	// Keys of the selected parameter set, parsed from the entries. The
+	// entries accept base64, hex or PEM, so keys can be pasted as well as
+	// generated or loaded from a file.
+	var decapsulationKey, encapsulationKey []byte
+
+	parsePublicKey := func(s string) ([]byte, error) {
+		key, err := common.DecodeKeyText(s)
+		if err != nil {
+			return nil, err
+		}
+		return key, core.CheckMLKEMPublicKey(currentKeySize, key)
+	}
+	parsePrivateKey := func(s string) (privateKey, publicKey []byte, err error) {
+		key, err := common.DecodeKeyText(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		publicKey, err = core.MLKEMPublicKey(currentKeySize, key)
+		return key, publicKey, err
+	}
 
-	// Main action states
-	var decapsulationKey *mlkem.DecapsulationKey768
-	var decapsulationKey1024 *mlkem.DecapsulationKey1024
-	var encapsulationKey *mlkem.EncapsulationKey768
-	var encapsulationKey1024 *mlkem.EncapsulationKey1024
+	// setKey shows a key read from a file or pasted text. PEM carries the
+	// parameter set, raw keys are taken as the selected one.
+	setKey := func(data []byte, private bool) error {
+		entry := publicKeyEntry
+		if private {
+			entry = privateKeyEntry
+		}
+		if !common.IsPEM(string(data)) {
+			entry.SetText(base64.StdEncoding.EncodeToString(data))
+			return entry.Validate()
+		}
+
+		params, key, isPrivate, err := core.DecodeMLKEMPEM(data)
+		if err != nil {
+			return err
+		}
+		if isPrivate != private {
+			return fmt.Errorf("%w: expected a %s", core.ErrMLKEMKey, keyKind(private))
+		}
+		// Replace the PEM text first, the select parses the entries again
+		entry.SetText(base64.StdEncoding.EncodeToString(key))
+		keySizeSelect.SetSelected(params.String())
+		return nil
+	}
+
+	publicKeyEntry.Validator = func(s string) error {
+		if s == "" || common.IsPEM(s) {
+			return nil
+		}
+		_, err := parsePublicKey(s)
+		return err
+	}
+	publicKeyEntry.OnChanged = func(s string) {
+		encapsulationKey = nil
+		if common.IsPEM(s) {
+			if err := setKey([]byte(s), false); err != nil {
+				publicKeyEntry.SetValidationError(err)
+			}
+			return
+		}
+		if key, err := parsePublicKey(s); err == nil {
+			encapsulationKey = key
+		}
+	}
+
+	privateKeyEntry.Validator = func(s string) error {
+		if s == "" || common.IsPEM(s) {
+			return nil
+		}
+		_, _, err := parsePrivateKey(s)
+		return err
+	}
+	privateKeyEntry.OnChanged = func(s string) {
+		decapsulationKey = nil
+		if common.IsPEM(s) {
+			if err := setKey([]byte(s), true); err != nil {
+				privateKeyEntry.SetValidationError(err)
+			}
+			return
+		}
+		privateKey, publicKey, err := parsePrivateKey(s)
+		if err != nil {
+			return
+		}
+		decapsulationKey = privateKey
+		// Show the public key to hand out for a pasted private key
+		if publicKeyText := base64.StdEncoding.EncodeToString(publicKey); publicKeyEntry.Text != publicKeyText {
+			publicKeyEntry.SetText(publicKeyText)
+		}
+	}
+
+	keySizeSelect.OnChanged = func(selected string) {
+		if params, err := core.ParseMLKEMParameterSet(selected); err == nil {
+			currentKeySize = params
+			keySizeDescription.SetText(mlkemDescriptions[params])
+		}
+
+		// The seed form fits both ML-KEM sets, the public key does not
+		privateKeyEntry.OnChanged(privateKeyEntry.Text)
+		privateKeyEntry.Validate()
+		publicKeyEntry.OnChanged(publicKeyEntry.Text)
+		publicKeyEntry.Validate()
+	}
 
 	// Generate keys button action
 	generateKeyButton.OnTapped = func() {
-		var err error
-		var publicKeyText, privateKeyText string
+		privateKey, _, err := core.GenerateMLKEMKey(currentKeySize)
+		if err != nil {
+			log.Printf("Error generating %v key: %v", currentKeySize, err)
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
 
-		switch currentKeySize {
-		case mode768:
-			decapsulationKey, err = mlkem.GenerateKey768()
-			if err != nil {
-				log.Println("Error generating 768 key:", err)
+		// Also fills in the public key
+		privateKeyEntry.SetText(base64.StdEncoding.EncodeToString(privateKey))
+	}
+
+	saveKey := func(private bool) {
+		key, name := encapsulationKey, strings.ToLower(currentKeySize.String())+".pub"
+		if private {
+			key, name = decapsulationKey, strings.ToLower(currentKeySize.String())
+		}
+		if key == nil {
+			outputEntry.SetText("Error: You need to generate or insert a " + keyKind(private) + " first")
+			return
+		}
+
+		data := key
+		if keyFileSelect.Selected == keyFilePEM {
+			var err error
+			if data, err = core.EncodeMLKEMPEM(currentKeySize, key, private); err != nil {
 				outputEntry.SetText("Error: " + err.Error())
 				return
 			}
-			encapsulationKey = decapsulationKey.EncapsulationKey()
-			publicKeyText = base64.StdEncoding.EncodeToString(encapsulationKey.Bytes())
-			privateKeyText = base64.StdEncoding.EncodeToString(decapsulationKey.Bytes())
-		case mode1024:
-			decapsulationKey1024, err = mlkem.GenerateKey1024()
-			if err != nil {
-				log.Println("Error generating 1024 key:", err)
-				outputEntry.SetText("Error: " + err.Error())
+			name += ".pem"
+		} else {
+			name += ".key"
+		}
+
+		saveDialog := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil || w == nil {
+				return
+			}
+			defer w.Close()
+			if _, err := w.Write(data); err != nil {
+				log.Println("Key save error:", err)
+				dialog.ShowError(err, common.GetWindow())
+			}
+		}, common.GetWindow())
+		saveDialog.SetFileName(name)
+		saveDialog.Show()
+	}
+
+	loadKey := func(private bool) {
+		dialog.ShowFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil || rc == nil {
 				return
 			}
-			encapsulationKey1024 = decapsulationKey1024.EncapsulationKey()
-			publicKeyText = base64.StdEncoding.EncodeToString(encapsulationKey1024.Bytes())
-			privateKeyText = base64.StdEncoding.EncodeToString(decapsulationKey1024.Bytes())
+			defer rc.Close()
+			data, err := io.ReadAll(rc)
+			if err == nil {
+				err = setKey(data, private)
+			}
+			if err != nil {
+				log.Println("Key load error:", err)
+				dialog.ShowError(err, common.GetWindow())
+			}
+		}, common.GetWindow())
+	}
+
+	savePublicKeyButton := widget.NewButton(lang.L("SavePublicKey"), func() { saveKey(false) })
+	loadPublicKeyButton := widget.NewButton(lang.L("LoadPublicKey"), func() { loadKey(false) })
+	savePrivateKeyButton := widget.NewButton(lang.L("SavePrivateKey"), func() { saveKey(true) })
+	loadPrivateKeyButton := widget.NewButton(lang.L("LoadPrivateKey"), func() { loadKey(true) })
+
+	// In KEM-DEM mode the input is the message, sealed to the public key
+	// or opened with the private key. The shared key is never shown.
+	inputRows := container.NewVBox(
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+	)
+	sharedKeyRows := container.NewVBox(
+		container.NewHBox(sharedKeyLabel, sharedKeyFormat),
+		container.NewBorder(nil, nil, nil, sharedKeyCopyButton, sharedKeyEntry),
+	)
+	sealing := false
+
+	updateFields := func() {
+		switch {
+		case sealing && modeToggle.Checked:
+			actionButton.SetText(lang.L("Decrypt"))
+		case sealing:
+			actionButton.SetText(lang.L("Encrypt"))
+		case modeToggle.Checked:
+			actionButton.SetText(lang.L("Decapsulate"))
+		default:
+			actionButton.SetText(lang.L("Encapsulate"))
 		}
+		setVisible(inputRows, sealing || modeToggle.Checked)
+		setVisible(sharedKeyRows, !sealing)
+	}
 
-		// Display keys
-		publicKeyEntry.SetText(publicKeyText)
-		privateKeyEntry.SetText(privateKeyText)
+	modeToggle.OnChanged = func(bool) {
+		if sealing {
+			common.SwapFormats(inputFormat, outputFormat)
+		}
+		updateFields()
 	}
 
+	modeSelect.OnChanged = func(selected string) {
+		sealing = selected == mlkemModeKEMDEM
+		modeDescription.SetText(mlkemModeDescriptions[selected])
+		modeToggle.Text = lang.L("Decapsulate")
+		if sealing {
+			modeToggle.Text = lang.L("Decrypt")
+		}
+		modeToggle.Refresh()
+
+		switch {
+		case sealing && !modeToggle.Checked:
+			inputFormat.SetFormat(common.FormatText)
+			outputFormat.SetFormat(common.FormatBase64)
+		case sealing:
+			inputFormat.SetFormat(common.FormatBase64)
+			outputFormat.SetFormat(common.FormatText)
+		default:
+			inputFormat.SetFormat(common.FormatBase64)
+			outputFormat.SetFormat(common.FormatBase64)
+		}
+		updateFields()
+	}
+	updateFields()
+
 	// Set up validators and actions based on encryption/decryption mode
 	actionButton.OnTapped = func() {
+		if sealing {
+			key := encapsulationKey
+			if modeToggle.Checked {
+				key = decapsulationKey
+			}
+			if key == nil {
+				outputEntry.SetText("Error: You need to generate or insert a " + keyKind(modeToggle.Checked) + " first")
+				return
+			}
+			if inputEntry.Text == "" {
+				return
+			}
+
+			go func() {
+				fyne.Do(func() {
+					actionButton.Disable()
+					defer actionButton.Enable()
+
+					data, err := inputFormat.Bytes()
+					if err != nil {
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+
+					var result []byte
+					if modeToggle.Checked {
+						result, err = core.OpenMLKEM(key, data)
+					} else {
+						result, err = core.SealMLKEM(currentKeySize, key, data)
+					}
+					if err != nil {
+						log.Println("KEM-DEM error:", err)
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+
+					outputFormat.SetBytes(result)
+				})
+			}()
+			return
+		}
+
 		if modeToggle.Checked {
-			if decapsulationKey == nil && decapsulationKey1024 == nil {
+			if decapsulationKey == nil {
 				outputEntry.SetText("Error: You need to generate or insert a private key first")
 				return
 			}
@@ -153,10 +398,10 @@ func (m *MLKEM) BuildForm() *fyne.Container {
 				if len(s) == 0 {
 					return errors.New(lang.L("Required"))
 				}
-				_, err := base64.StdEncoding.DecodeString(s)
+				_, err := inputFormat.Bytes()
 				if err != nil {
-					log.Println("Invalid base64 input for ciphertext:", err)
-					return errors.New(lang.L("InvalidBase64"))
+					log.Println("Invalid input for ciphertext:", err)
+					return err
 				}
 				return nil
 			}
@@ -171,41 +416,26 @@ func (m *MLKEM) BuildForm() *fyne.Container {
 					actionButton.Disable()
 					defer actionButton.Enable()
 
-					ciphertext, err := base64.StdEncoding.DecodeString(inputEntry.Text)
+					ciphertext, err := inputFormat.Bytes()
 					if err != nil {
-						log.Println("Base64 decode error:", err)
-						outputEntry.SetText("Error: Invalid Base64 input")
+						log.Println("Input decode error:", err)
+						outputEntry.SetText("Error: " + err.Error())
 						return
 					}
 
-					var sharedKey []byte
-					switch currentKeySize {
-					case mode768:
-						if decapsulationKey == nil {
-							outputEntry.SetText("Error: No ML-KEM-768 private key available")
-							return
-						}
-						sharedKey, err = decapsulationKey.Decapsulate(ciphertext)
-					case mode1024:
-						if decapsulationKey1024 == nil {
-							outputEntry.SetText("Error: No ML-KEM-1024 private key available")
-							return
-						}
-						sharedKey, err = decapsulationKey1024.Decapsulate(ciphertext)
-					}
-
+					sharedKey, err := core.MLKEMDecapsulate(currentKeySize, decapsulationKey, ciphertext)
 					if err != nil {
 						log.Println("Decapsulation error:", err)
 						outputEntry.SetText("Error: " + err.Error())
 						return
 					}
 
-					sharedKeyEntry.SetText(hex.EncodeToString(sharedKey))
+					sharedKeyFormat.SetBytes(sharedKey)
 				})
 			}()
 		} else {
 			// Encapsulation mode - uses public key to generate shared key and ciphertext
-			if encapsulationKey == nil && encapsulationKey1024 == nil {
+			if encapsulationKey == nil {
 				outputEntry.SetText("Error: You need to generate or insert a public key first")
 				return
 			}
@@ -215,24 +445,15 @@ func (m *MLKEM) BuildForm() *fyne.Container {
 					actionButton.Disable()
 					defer actionButton.Enable()
 
-					var sharedKey, ciphertext []byte
-					switch currentKeySize {
-					case mode768:
-						if encapsulationKey == nil {
-							outputEntry.SetText("Error: No ML-KEM-768 public key available")
-							return
-						}
-						sharedKey, ciphertext = encapsulationKey.Encapsulate()
-					case mode1024:
-						if encapsulationKey1024 == nil {
-							outputEntry.SetText("Error: No ML-KEM-1024 public key available")
-							return
-						}
-						sharedKey, ciphertext = encapsulationKey1024.Encapsulate()
+					sharedKey, ciphertext, err := core.MLKEMEncapsulate(currentKeySize, encapsulationKey)
+					if err != nil {
+						log.Println("Encapsulation error:", err)
+						outputEntry.SetText("Error: " + err.Error())
+						return
 					}
 
-					sharedKeyEntry.SetText(hex.EncodeToString(sharedKey))
-					outputEntry.SetText(base64.StdEncoding.EncodeToString(ciphertext))
+					sharedKeyFormat.SetBytes(sharedKey)
+					outputFormat.SetBytes(ciphertext)
 				})
 			}()
 		}
@@ -240,19 +461,29 @@ func (m *MLKEM) BuildForm() *fyne.Container {
 
 	return container.NewVBox(
 		header,
+		container.NewHBox(modeLabel, modeSelect),
+		modeDescription,
 		container.NewHBox(keySizeLabel, keySizeSelect),
 		keySizeDescription,
 		generateKeyButton,
 		publicKeyLabel,
 		container.NewBorder(nil, nil, nil, publicKeyCopyButton, publicKeyEntry),
+		container.NewHBox(loadPublicKeyButton, savePublicKeyButton),
 		privateKeyLabel,
 		container.NewBorder(nil, nil, nil, privateKeyCopyButton, privateKeyEntry),
-		inputLabel,
-		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewHBox(loadPrivateKeyButton, savePrivateKeyButton),
+		container.NewHBox(keyFileLabel, keyFileSelect),
+		inputRows,
 		container.NewVBox(modeToggle, actionButton),
-		sharedKeyLabel,
-		container.NewBorder(nil, nil, nil, sharedKeyCopyButton, sharedKeyEntry),
-		outputLabel,
+		sharedKeyRows,
+		container.NewHBox(outputLabel, outputFormat),
 		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
 	)
 }
+
+func keyKind(private bool) string {
+	if private {
+		return "private key"
+	}
+	return "public key"
+}
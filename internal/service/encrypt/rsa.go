@@ -0,0 +1,337 @@
+package encrypt
+
+import (
+	"crypto/rsa"
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_encrypt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+var rsaSchemeDescriptions = map[core.RSAScheme]string{
+	core.RSASchemeOAEP:            "OAEP - encryption with a digest and optional label, the choice for new designs",
+	core.RSASchemePKCS1v15Encrypt: "PKCS#1 v1.5 - legacy encryption, only for systems that need it",
+	core.RSASchemePSS:             "PSS - randomized signature, salt as long as the digest",
+	core.RSASchemePKCS1v15Sign:    "PKCS#1 v1.5 signature - deterministic, as used by JWT RS256 and most certificates",
+}
+
+// RSA encrypts to and signs with RSA keys. Keys are shown as PEM and may be
+// pasted as PEM or base64 DER; the public key follows the private key.
+type RSA struct {
+	Name string
+}
+
+func NewRSA() *RSA {
+	return &RSA{Name: "RSA"}
+}
+
+func (r *RSA) BuildForm() *fyne.Container {
+	header := common.GetHeader(r.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	modeToggle, actionButton := common_encrypt.GetActionButton()
+
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelect := widget.NewSelect(core.RSASchemes(), nil)
+	modeSelect.SetSelected(core.RSASchemeOAEP.String())
+	var currentScheme = core.RSASchemeOAEP
+
+	modeDescription := widget.NewLabel(rsaSchemeDescriptions[currentScheme])
+	modeDescription.TextStyle.Italic = true
+
+	var keySizes []string
+	for _, bits := range core.RSAKeySizes {
+		keySizes = append(keySizes, strconv.Itoa(bits))
+	}
+	keySizeSelect := widget.NewSelect(keySizes, nil)
+	keySizeSelect.SetSelected("2048")
+	generateKeyButton := widget.NewButton(lang.L("GenerateKeys"), nil)
+
+	privateFormatSelect := widget.NewSelect(core.RSAPrivateKeyFormats, nil)
+	privateFormatSelect.SetSelected(core.RSAPrivateKeyFormats[0])
+	privateKeyEntry := widget.NewMultiLineEntry()
+	privateKeyEntry.Wrapping = fyne.TextWrapBreak
+	privateKeyEntry.SetMinRowsVisible(4)
+	loadPrivateKeyButton, savePrivateKeyButton := common.GetKeyFileButtons(privateKeyEntry, lang.L("LoadPrivateKey"), lang.L("SavePrivateKey"), "rsa.pem")
+
+	publicFormatSelect := widget.NewSelect(core.RSAPublicKeyFormats, nil)
+	publicFormatSelect.SetSelected(core.RSAPublicKeyFormats[0])
+	publicKeyEntry := widget.NewMultiLineEntry()
+	publicKeyEntry.Wrapping = fyne.TextWrapBreak
+	publicKeyEntry.SetMinRowsVisible(4)
+	publicKeyCopyButton := widget.NewButton(lang.L("Copy"), func() {
+		if publicKeyEntry.Text != "" {
+			fyne.CurrentApp().Clipboard().SetContent(publicKeyEntry.Text)
+		}
+	})
+	loadPublicKeyButton, savePublicKeyButton := common.GetKeyFileButtons(publicKeyEntry, lang.L("LoadPublicKey"), lang.L("SavePublicKey"), "rsa.pub.pem")
+
+	digestSelect := widget.NewSelect(core.ShaAlgorithms, nil)
+	digestSelect.SetSelected("sha256")
+	labelEntry, labelFormat := common.GetBytesEntry(common.FormatText)
+	signatureEntry, signatureFormat := common.GetBytesEntry(common.FormatBase64)
+	signatureEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := signatureFormat.Bytes()
+		return err
+	}
+	verifyResult := widget.NewLabel("")
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+
+	// Keys parsed from the entries
+	var privateKey *rsa.PrivateKey
+	var publicKey *rsa.PublicKey
+
+	showPublicKey := func(key *rsa.PublicKey) {
+		pemKey, err := core.EncodeRSAPublicKey(key, publicFormatSelect.Selected)
+		if err != nil {
+			log.Println("RSA public key error:", err)
+			return
+		}
+		publicKeyEntry.SetText(string(pemKey))
+	}
+
+	privateKeyEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := parseRSAPrivateKey(s)
+		return err
+	}
+	privateKeyEntry.OnChanged = func(s string) {
+		privateKey, _ = parseRSAPrivateKey(s)
+		// the public key always follows the private key
+		if privateKey != nil && (publicKey == nil || !publicKey.Equal(&privateKey.PublicKey)) {
+			showPublicKey(&privateKey.PublicKey)
+		}
+	}
+	publicKeyEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := parseRSAPublicKey(s)
+		return err
+	}
+	publicKeyEntry.OnChanged = func(s string) {
+		publicKey, _ = parseRSAPublicKey(s)
+	}
+
+	privateFormatSelect.OnChanged = func(format string) {
+		if privateKey == nil {
+			return
+		}
+		if pemKey, err := core.EncodeRSAPrivateKey(privateKey, format); err == nil {
+			privateKeyEntry.SetText(string(pemKey))
+		}
+	}
+	publicFormatSelect.OnChanged = func(string) {
+		if publicKey != nil {
+			showPublicKey(publicKey)
+		}
+	}
+
+	generateKeyButton.OnTapped = func() {
+		bits, _ := strconv.Atoi(keySizeSelect.Selected)
+		generateKeyButton.Disable()
+		go func() {
+			key, err := core.GenerateRSAKey(bits)
+			fyne.Do(func() {
+				generateKeyButton.Enable()
+				if err == nil {
+					var pemKey []byte
+					if pemKey, err = core.EncodeRSAPrivateKey(key, privateFormatSelect.Selected); err == nil {
+						privateKeyEntry.SetText(string(pemKey))
+					}
+				}
+				if err != nil {
+					log.Println("Error generating RSA key:", err)
+					outputEntry.SetText("Error: " + err.Error())
+				}
+			})
+		}()
+	}
+
+	digestRows := container.NewHBox(widget.NewLabel(lang.L("Digest")), digestSelect)
+	labelRows := container.NewVBox(container.NewHBox(widget.NewLabel(lang.L("Label")), labelFormat), labelEntry)
+	signatureRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("Signature")), signatureFormat),
+		signatureEntry,
+		verifyResult,
+	)
+
+	// OAEP takes a digest and a label, PKCS#1 v1.5 encryption neither;
+	// verifying reads the signature instead of writing output.
+	updateFields := func() {
+		signing := currentScheme.Signature()
+		setVisible(digestRows, currentScheme != core.RSASchemePKCS1v15Encrypt)
+		setVisible(labelRows, currentScheme == core.RSASchemeOAEP)
+		setVisible(signatureRows, signing && modeToggle.Checked)
+
+		switch {
+		case signing && modeToggle.Checked:
+			actionButton.SetText(lang.L("Verify"))
+		case signing:
+			actionButton.SetText(lang.L("Sign"))
+		case modeToggle.Checked:
+			actionButton.SetText(lang.L("Decrypt"))
+		default:
+			actionButton.SetText(lang.L("Encrypt"))
+		}
+		if signing {
+			modeToggle.Text = lang.L("Verify")
+		} else {
+			modeToggle.Text = lang.L("Decrypt")
+		}
+		modeToggle.Refresh()
+	}
+
+	modeToggle.OnChanged = func(bool) {
+		if !currentScheme.Signature() {
+			common.SwapFormats(inputFormat, outputFormat)
+		}
+		verifyResult.SetText("")
+		updateFields()
+	}
+	modeSelect.OnChanged = func(selected string) {
+		scheme, err := core.ParseRSAScheme(selected)
+		if err != nil {
+			return
+		}
+		// the input is plaintext except when decrypting
+		if scheme.Signature() != currentScheme.Signature() && modeToggle.Checked {
+			common.SwapFormats(inputFormat, outputFormat)
+		}
+		currentScheme = scheme
+		modeDescription.SetText(rsaSchemeDescriptions[scheme])
+		verifyResult.SetText("")
+		updateFields()
+	}
+	updateFields()
+
+	actionButton.OnTapped = func() {
+		// the private key decrypts and signs, the public key encrypts and verifies
+		keyEntry := publicKeyEntry
+		if currentScheme.Signature() != modeToggle.Checked {
+			keyEntry = privateKeyEntry
+		}
+		validated := []*widget.Entry{keyEntry}
+		if currentScheme.Signature() && modeToggle.Checked {
+			validated = append(validated, signatureEntry)
+		}
+		for _, entry := range validated {
+			if err := entry.Validate(); err != nil {
+				entry.SetValidationError(err)
+				return
+			}
+		}
+
+		if inputEntry.Text == "" {
+			return
+		}
+
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					log.Println("Input decode error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				label, err := labelFormat.Bytes()
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				digest := digestSelect.Selected
+
+				if currentScheme.Signature() && modeToggle.Checked {
+					signature, _ := signatureFormat.Bytes()
+					valid, err := core.VerifyRSA(publicKey, currentScheme, digest, data, signature)
+					switch {
+					case err != nil:
+						verifyResult.SetText("Error: " + err.Error())
+					case valid:
+						verifyResult.SetText(lang.L("SignatureValid"))
+					default:
+						verifyResult.SetText(lang.L("SignatureInvalid"))
+					}
+					return
+				}
+
+				var result []byte
+				switch {
+				case currentScheme.Signature():
+					result, err = core.SignRSA(privateKey, currentScheme, digest, data)
+				case modeToggle.Checked:
+					result, err = core.DecryptRSA(privateKey, currentScheme, digest, label, data)
+				default:
+					result, err = core.EncryptRSA(publicKey, currentScheme, digest, label, data)
+				}
+				if err != nil {
+					log.Println("RSA error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				outputFormat.SetBytes(result)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(modeLabel, modeSelect),
+		modeDescription,
+		container.NewHBox(widget.NewLabel(lang.L("KeySize")), keySizeSelect, generateKeyButton),
+		container.NewHBox(widget.NewLabel(lang.L("PrivateKey")), privateFormatSelect),
+		privateKeyEntry,
+		container.NewHBox(loadPrivateKeyButton, savePrivateKeyButton),
+		container.NewHBox(widget.NewLabel(lang.L("PublicKey")), publicFormatSelect),
+		container.NewBorder(nil, nil, nil, publicKeyCopyButton, publicKeyEntry),
+		container.NewHBox(loadPublicKeyButton, savePublicKeyButton),
+		digestRows,
+		labelRows,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		signatureRows,
+		container.NewVBox(modeToggle, actionButton),
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
+
+// parseRSAPrivateKey and parseRSAPublicKey read PEM, or DER pasted as base64 or hex.
+func parseRSAPrivateKey(s string) (*rsa.PrivateKey, error) {
+	if common.IsPEM(s) {
+		return core.ParseRSAPrivateKey([]byte(s))
+	}
+	der, err := common.DecodeKeyText(s)
+	if err != nil {
+		return nil, err
+	}
+	return core.ParseRSAPrivateKey(der)
+}
+
+func parseRSAPublicKey(s string) (*rsa.PublicKey, error) {
+	if common.IsPEM(s) {
+		return core.ParseRSAPublicKey([]byte(s))
+	}
+	der, err := common.DecodeKeyText(s)
+	if err != nil {
+		return nil, err
+	}
+	return core.ParseRSAPublicKey(der)
+}
@@ -0,0 +1,204 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_encrypt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+var salsaModeDescriptions = map[core.SalsaMode]string{
+	core.SalsaModeSalsa20:   "Salsa20 - stream cipher without authentication, 8 byte nonce",
+	core.SalsaModeXSalsa20:  "XSalsa20 - stream cipher without authentication, 24 byte nonce",
+	core.SalsaModeSecretbox: "secretbox - NaCl / libsodium XSalsa20-Poly1305, authenticated, MAC | ciphertext",
+}
+
+type Salsa20 struct {
+	Name string
+}
+
+func NewSalsa20() *Salsa20 {
+	return &Salsa20{Name: "Salsa20"}
+}
+
+func (s *Salsa20) BuildForm() *fyne.Container {
+	header := common.GetHeader(s.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	modeToggle, actionButton := common_encrypt.GetActionButton()
+
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelect := widget.NewSelect(core.SalsaModes(), nil)
+	modeSelect.SetSelected(core.SalsaModeSecretbox.String())
+	var currentMode = core.SalsaModeSecretbox
+
+	modeDescription := widget.NewLabel(salsaModeDescriptions[currentMode])
+	modeDescription.TextStyle.Italic = true
+
+	keyLabel := widget.NewLabel(lang.L("Key"))
+	keyEntry, keyFormat := common.GetBytesEntry(common.FormatText)
+	keyEntry.PlaceHolder = lang.L("KeyMustBe32Bytes")
+
+	nonceLabel := widget.NewLabel(lang.L("Nonce"))
+	nonceEntry, nonceFormat := common.GetBytesEntry(common.FormatHex)
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Decrypt"))
+		} else {
+			actionButton.SetText(lang.L("Encrypt"))
+		}
+		common.SwapFormats(inputFormat, outputFormat)
+	}
+
+	generateKeyButton := widget.NewButton(lang.L("Generate"), func() {
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		if err != nil {
+			log.Println("Error generating random key:", err)
+			outputEntry.SetText("Error generating random key: " + err.Error())
+			return
+		}
+
+		keyFormat.SetFormat(common.FormatHex)
+		keyFormat.SetBytes(key)
+	})
+
+	generateNonceButton := widget.NewButton(lang.L("Generate"), func() {
+		nonce := make([]byte, currentMode.NonceSize())
+		_, err := rand.Read(nonce)
+		if err != nil {
+			log.Println("Error generating random nonce:", err)
+			outputEntry.SetText("Error generating random nonce: " + err.Error())
+			return
+		}
+
+		nonceFormat.SetFormat(common.FormatHex)
+		nonceFormat.SetBytes(nonce)
+	})
+
+	keyEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+
+		key, err := keyFormat.Bytes()
+		if err != nil {
+			return err
+		}
+
+		if len(key) != 32 {
+			return errors.New(lang.L("KeyMustBe32Bytes"))
+		}
+
+		return nil
+	}
+
+	// secretbox picks a random nonce and prepends it when none is given
+	nonceEntry.Validator = func(s string) error {
+		if s == "" && currentMode == core.SalsaModeSecretbox {
+			return nil
+		}
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+
+		nonce, err := nonceFormat.Bytes()
+		if err != nil {
+			return err
+		}
+
+		if len(nonce) != currentMode.NonceSize() {
+			if currentMode == core.SalsaModeSalsa20 {
+				return errors.New(lang.L("NonceMustBe8Bytes"))
+			}
+			return errors.New(lang.L("NonceMustBe24Bytes"))
+		}
+
+		return nil
+	}
+
+	modeSelect.OnChanged = func(selected string) {
+		mode, err := core.ParseSalsaMode(selected)
+		if err != nil {
+			return
+		}
+		currentMode = mode
+		modeDescription.SetText(salsaModeDescriptions[mode])
+		if mode == core.SalsaModeSecretbox {
+			nonceEntry.SetPlaceHolder(lang.L("RandomNonce"))
+		} else {
+			nonceEntry.SetPlaceHolder("")
+		}
+	}
+	modeSelect.OnChanged(modeSelect.Selected)
+
+	actionButton.OnTapped = func() {
+		for _, entry := range []*widget.Entry{keyEntry, nonceEntry} {
+			if err := entry.Validate(); err != nil {
+				entry.SetValidationError(err)
+				return
+			}
+		}
+
+		if inputEntry.Text == "" {
+			return
+		}
+
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				key, _ := keyFormat.Bytes()
+				nonce, _ := nonceFormat.Bytes()
+
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				var result []byte
+				switch {
+				case currentMode != core.SalsaModeSecretbox:
+					result, err = core.XORSalsa20(key, nonce, data)
+				case modeToggle.Checked:
+					result, err = core.OpenSecretbox(key, nonce, data)
+				default:
+					result, err = core.SealSecretbox(key, nonce, data)
+				}
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				outputFormat.SetBytes(result)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(modeLabel, modeSelect),
+		modeDescription,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewHBox(keyLabel, keyFormat),
+		container.NewBorder(nil, nil, nil, generateKeyButton, keyEntry),
+		container.NewHBox(nonceLabel, nonceFormat),
+		container.NewBorder(nil, nil, nil, generateNonceButton, nonceEntry),
+		container.NewVBox(modeToggle, actionButton),
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
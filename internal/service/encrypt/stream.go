@@ -0,0 +1,167 @@
+package encrypt
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"os"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_encrypt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+type Stream struct {
+	Name string
+}
+
+func NewStream() *Stream {
+	return &Stream{Name: "File encryption"}
+}
+
+func (s *Stream) BuildForm() *fyne.Container {
+	header := common.GetHeader(s.Name)
+	modeToggle, actionButton := common_encrypt.GetActionButton()
+
+	algorithmLabel := widget.NewLabel(lang.L("Algorithm"))
+	algorithmSelect := widget.NewSelect(core.StreamAlgorithms(), nil)
+	algorithmSelect.SetSelected("AES-GCM")
+	var currentAlgorithm = core.StreamAESGCM
+
+	algorithmDescription := widget.NewLabel("AES-GCM - 16, 24 or 32 byte key")
+	algorithmDescription.TextStyle.Italic = true
+
+	algorithmSelect.OnChanged = func(selected string) {
+		switch selected {
+		case "AES-GCM":
+			currentAlgorithm = core.StreamAESGCM
+			algorithmDescription.SetText("AES-GCM - 16, 24 or 32 byte key")
+		case "ChaCha20-Poly1305":
+			currentAlgorithm = core.StreamChaCha20Poly1305
+			algorithmDescription.SetText("ChaCha20-Poly1305 - 32 byte key")
+		}
+	}
+
+	streamDescription := widget.NewLabel(lang.L("StreamDescription"))
+	streamDescription.Wrapping = fyne.TextWrapWord
+
+	keyLabel := widget.NewLabel(lang.L("Key"))
+	keyEntry, keyFormat := common.GetBytesEntry(common.FormatHex)
+	keyEntry.Validator = func(string) error {
+		key, err := keyFormat.Bytes()
+		if err != nil {
+			return err
+		}
+		if len(key) == 0 {
+			return errors.New(lang.L("Required"))
+		}
+		return nil
+	}
+
+	generateKeyButton := widget.NewButton(lang.L("Generate"), func() {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			log.Println("Error generating random key:", err)
+			return
+		}
+
+		keyFormat.SetFormat(common.FormatHex)
+		keyFormat.SetBytes(key)
+	})
+
+	inputFileLabel := widget.NewLabel(lang.L("InputFile"))
+	inputFileEntry, inputFileButton := common.GetFilePicker(false)
+	outputFileLabel := widget.NewLabel(lang.L("OutputFile"))
+	outputFileEntry, outputFileButton := common.GetFilePicker(true)
+
+	task := common.GetTask()
+
+	actionButton.OnTapped = func() {
+		if err := keyEntry.Validate(); err != nil {
+			keyEntry.SetValidationError(err)
+			return
+		}
+		if inputFileEntry.Text == "" || outputFileEntry.Text == "" || task.Running() {
+			return
+		}
+		if inputFileEntry.Text == outputFileEntry.Text {
+			task.Status.SetText("Error: " + lang.L("SameFile"))
+			return
+		}
+
+		key, _ := keyFormat.Bytes()
+		inPath, outPath := inputFileEntry.Text, outputFileEntry.Text
+		decrypt := modeToggle.Checked
+		alg := currentAlgorithm
+
+		info, err := os.Stat(inPath)
+		if err != nil {
+			task.Status.SetText("Error: " + err.Error())
+			return
+		}
+
+		actionButton.Disable()
+		task.Start(info.Size(), func(ctx context.Context, progress func(int64)) error {
+			return cryptFile(ctx, decrypt, alg, key, inPath, outPath, progress)
+		}, func(error) {
+			actionButton.Enable()
+		})
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(algorithmLabel, algorithmSelect),
+		algorithmDescription,
+		streamDescription,
+		container.NewHBox(keyLabel, keyFormat),
+		container.NewBorder(nil, nil, nil, generateKeyButton, keyEntry),
+		inputFileLabel,
+		container.NewBorder(nil, nil, nil, inputFileButton, inputFileEntry),
+		outputFileLabel,
+		container.NewBorder(nil, nil, nil, outputFileButton, outputFileEntry),
+		container.NewVBox(modeToggle, actionButton),
+		task.Container(),
+	)
+}
+
+// cryptFile streams inPath into outPath and removes the output if anything
+// fails, so a cancelled or forged decryption never leaves partial plaintext.
+func cryptFile(ctx context.Context, decrypt bool, alg core.StreamAlgorithm, key []byte, inPath, outPath string, progress func(int64)) (err error) {
+	in, err := os.Open(inPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(outPath)
+		}
+	}()
+
+	w := bufio.NewWriter(out)
+	r := bufio.NewReader(in)
+	if decrypt {
+		err = core.DecryptStream(ctx, key, r, w, progress)
+	} else {
+		err = core.EncryptStream(ctx, alg, key, r, w, progress)
+	}
+	if err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
@@ -1,8 +1,6 @@
 package hash
 
 import (
-	"crypto/md5"
-	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"pararti/chify/internal/common"
@@ -19,11 +17,13 @@ func NewMd5() *Md5 {
 
 func (m *Md5) BuildForm() *fyne.Container {
 	header := common.GetHeader(m.Name)
-	inputLabel, inputEntry, resetButton := common.GetInput()
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
 	actionButton := common_hash.GetActionButton()
 
-	outputLabel, outputEntry, copyButton := common.GetOutput()
-	outputLabel.SetText(outputLabel.Text)
+	hmacFields := common_hash.GetHMACFields(inputFormat, func() string { return "md5" })
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatHex)
 
 	actionButton.OnTapped = func() {
 		if inputEntry.Text == "" {
@@ -34,19 +34,31 @@ func (m *Md5) BuildForm() *fyne.Container {
 				actionButton.Disable()
 				defer actionButton.Enable()
 
-				h := md5.Sum([]byte(inputEntry.Text))
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				h, err := hmacFields.Sum("md5", data)
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
 
-				outputEntry.SetText(fmt.Sprintf("%x", h))
+				outputFormat.SetBytes(h)
 			})
 		}()
 	}
 
 	return container.NewVBox(
 		header,
-		inputLabel,
+		container.NewHBox(inputLabel, inputFormat),
 		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		hmacFields.Container(),
 		actionButton,
-		outputLabel,
+		container.NewHBox(outputLabel, outputFormat),
 		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+		common_hash.GetFileHasher([]string{"md5"}, []string{"md5"}),
 	)
 }
@@ -0,0 +1,117 @@
+package kdf
+
+import (
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_kdf"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+type Argon2 struct {
+	Name string
+}
+
+func NewArgon2() *Argon2 {
+	return &Argon2{Name: "Argon2"}
+}
+
+func (a *Argon2) BuildForm() *fyne.Container {
+	header := common.GetHeader(a.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	actionButton := common_kdf.GetActionButton()
+	saltLabel, saltEntry, saltFormat, generateSaltButton := common_kdf.GetSaltEntry()
+
+	variantLabel := widget.NewLabel(lang.L("Mode"))
+	variantSelect := widget.NewSelect([]string{core.KDFArgon2id.String(), core.KDFArgon2i.String()}, nil)
+	variantSelect.SetSelected(core.KDFArgon2id.String())
+
+	variantDescription := widget.NewLabel("Argon2id - resists side channel and GPU attacks (recommended)")
+	variantDescription.TextStyle.Italic = true
+	variantSelect.OnChanged = func(selected string) {
+		switch selected {
+		case core.KDFArgon2id.String():
+			variantDescription.SetText("Argon2id - resists side channel and GPU attacks (recommended)")
+		case core.KDFArgon2i.String():
+			variantDescription.SetText("Argon2i - data independent memory access")
+		}
+	}
+
+	defaults := core.DefaultKDFParams(core.KDFArgon2id)
+	passesLabel := widget.NewLabel(lang.L("Passes"))
+	passesEntry := common_kdf.GetNumberEntry(int(defaults.Iterations))
+	memoryLabel := widget.NewLabel(lang.L("MemoryKiB"))
+	memoryEntry := common_kdf.GetNumberEntry(int(defaults.Memory))
+	threadsLabel := widget.NewLabel(lang.L("Parallelism"))
+	threadsEntry := common_kdf.GetNumberEntry(int(defaults.Threads))
+	lengthLabel := widget.NewLabel(lang.L("OutputLength"))
+	lengthEntry := common_kdf.GetNumberEntry(32)
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatHex)
+
+	params := func() (core.KDFParams, error) {
+		salt, err := saltFormat.Bytes()
+		if err != nil {
+			return core.KDFParams{}, err
+		}
+		kdf, err := core.ParseKDF(variantSelect.Selected)
+		if err != nil {
+			return core.KDFParams{}, err
+		}
+		return core.KDFParams{
+			KDF:        kdf,
+			Iterations: uint32(common_kdf.Number(passesEntry)),
+			Memory:     uint32(common_kdf.Number(memoryEntry)),
+			Threads:    uint8(min(common_kdf.Number(threadsEntry), 255)),
+			Salt:       salt,
+		}, nil
+	}
+
+	calibrator := common_kdf.GetCalibrator(params, func(p core.KDFParams) {
+		passesEntry.SetText(strconv.Itoa(int(p.Iterations)))
+	})
+
+	actionButton.OnTapped = func() {
+		if !common_kdf.ValidateAll(passesEntry, memoryEntry, threadsEntry, lengthEntry) {
+			return
+		}
+		password, err := inputFormat.Bytes()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		kdfParams, err := params()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		length := common_kdf.Number(lengthEntry)
+
+		common_kdf.Derive(actionButton, outputEntry, outputFormat, func() ([]byte, error) {
+			return kdfParams.DeriveKey(password, length)
+		})
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(variantLabel, variantSelect),
+		variantDescription,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewHBox(saltLabel, saltFormat),
+		container.NewBorder(nil, nil, nil, generateSaltButton, saltEntry),
+		container.NewBorder(nil, nil, passesLabel, nil, passesEntry),
+		container.NewBorder(nil, nil, memoryLabel, nil, memoryEntry),
+		container.NewBorder(nil, nil, threadsLabel, nil, threadsEntry),
+		container.NewBorder(nil, nil, lengthLabel, nil, lengthEntry),
+		calibrator,
+		actionButton,
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
@@ -0,0 +1,120 @@
+package kdf
+
+import (
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_kdf"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+type HKDF struct {
+	Name string
+}
+
+func NewHKDF() *HKDF {
+	return &HKDF{Name: "HKDF"}
+}
+
+// BuildForm has no calibration: HKDF is a single HMAC pass per block and
+// expects a secret with full entropy, not a password to stretch.
+func (h *HKDF) BuildForm() *fyne.Container {
+	header := common.GetHeader(h.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	inputFormat.SetFormat(common.FormatHex)
+	actionButton := common_kdf.GetActionButton()
+	saltLabel, saltEntry, saltFormat, generateSaltButton := common_kdf.GetSaltEntry()
+
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelect := widget.NewSelect(core.HKDFModes, nil)
+	modeSelect.SetSelected("extract-expand")
+
+	modeDescription := widget.NewLabel("extract-expand - input keying material to output key")
+	modeDescription.TextStyle.Italic = true
+
+	infoLabel := widget.NewLabel(lang.L("Info"))
+	infoEntry, infoFormat := common.GetBytesEntry(common.FormatText)
+	lengthLabel := widget.NewLabel(lang.L("OutputLength"))
+	lengthEntry := common_kdf.GetNumberEntry(32)
+
+	// extract ignores info and length, expand takes a PRK and ignores the salt
+	modeSelect.OnChanged = func(selected string) {
+		switch selected {
+		case "extract-expand":
+			modeDescription.SetText("extract-expand - input keying material to output key")
+			saltEntry.Enable()
+			infoEntry.Enable()
+			lengthEntry.Enable()
+		case "extract":
+			modeDescription.SetText("extract - input keying material to pseudorandom key (PRK)")
+			saltEntry.Enable()
+			infoEntry.Disable()
+			lengthEntry.Disable()
+		case "expand":
+			modeDescription.SetText("expand - pseudorandom key (PRK) to output key")
+			saltEntry.Disable()
+			infoEntry.Enable()
+			lengthEntry.Enable()
+		}
+	}
+
+	hashLabel := widget.NewLabel(lang.L("HashName"))
+	hashSelect := widget.NewSelect(core.HashAlgorithms, nil)
+	hashSelect.SetSelected("sha256")
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatHex)
+
+	actionButton.OnTapped = func() {
+		if !common_kdf.ValidateAll(lengthEntry) {
+			return
+		}
+		secret, err := inputFormat.Bytes()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		salt, err := saltFormat.Bytes()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		info, err := infoFormat.Bytes()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		mode, hash, length := modeSelect.Selected, hashSelect.Selected, common_kdf.Number(lengthEntry)
+
+		common_kdf.Derive(actionButton, outputEntry, outputFormat, func() ([]byte, error) {
+			switch mode {
+			case "extract":
+				return core.HKDFExtract(hash, secret, salt)
+			case "expand":
+				return core.HKDFExpand(hash, secret, info, length)
+			default:
+				return core.HKDF(hash, secret, salt, info, length)
+			}
+		})
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(modeLabel, modeSelect),
+		modeDescription,
+		container.NewHBox(hashLabel, hashSelect),
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewHBox(saltLabel, saltFormat),
+		container.NewBorder(nil, nil, nil, generateSaltButton, saltEntry),
+		container.NewHBox(infoLabel, infoFormat),
+		infoEntry,
+		container.NewBorder(nil, nil, lengthLabel, nil, lengthEntry),
+		actionButton,
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
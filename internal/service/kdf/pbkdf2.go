@@ -0,0 +1,94 @@
+package kdf
+
+import (
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_kdf"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+type PBKDF2 struct {
+	Name string
+}
+
+func NewPBKDF2() *PBKDF2 {
+	return &PBKDF2{Name: "PBKDF2"}
+}
+
+func (p *PBKDF2) BuildForm() *fyne.Container {
+	header := common.GetHeader(p.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	actionButton := common_kdf.GetActionButton()
+	saltLabel, saltEntry, saltFormat, generateSaltButton := common_kdf.GetSaltEntry()
+
+	defaults := core.DefaultKDFParams(core.KDFPBKDF2)
+	hashLabel := widget.NewLabel(lang.L("HashName"))
+	hashSelect := widget.NewSelect(core.HashAlgorithms, nil)
+	hashSelect.SetSelected("sha256")
+
+	iterationsLabel := widget.NewLabel(lang.L("Iterations"))
+	iterationsEntry := common_kdf.GetNumberEntry(int(defaults.Iterations))
+	lengthLabel := widget.NewLabel(lang.L("OutputLength"))
+	lengthEntry := common_kdf.GetNumberEntry(32)
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatHex)
+
+	params := func() (core.KDFParams, error) {
+		salt, err := saltFormat.Bytes()
+		if err != nil {
+			return core.KDFParams{}, err
+		}
+		return core.KDFParams{
+			KDF:        core.KDFPBKDF2,
+			Hash:       hashSelect.Selected,
+			Iterations: uint32(common_kdf.Number(iterationsEntry)),
+			Salt:       salt,
+		}, nil
+	}
+
+	calibrator := common_kdf.GetCalibrator(params, func(p core.KDFParams) {
+		iterationsEntry.SetText(strconv.Itoa(int(p.Iterations)))
+	})
+
+	actionButton.OnTapped = func() {
+		if !common_kdf.ValidateAll(iterationsEntry, lengthEntry) {
+			return
+		}
+		password, err := inputFormat.Bytes()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		kdfParams, err := params()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		length := common_kdf.Number(lengthEntry)
+
+		common_kdf.Derive(actionButton, outputEntry, outputFormat, func() ([]byte, error) {
+			return kdfParams.DeriveKey(password, length)
+		})
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewHBox(saltLabel, saltFormat),
+		container.NewBorder(nil, nil, nil, generateSaltButton, saltEntry),
+		container.NewHBox(hashLabel, hashSelect),
+		container.NewBorder(nil, nil, iterationsLabel, nil, iterationsEntry),
+		container.NewBorder(nil, nil, lengthLabel, nil, lengthEntry),
+		calibrator,
+		actionButton,
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
@@ -0,0 +1,96 @@
+package kdf
+
+import (
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_kdf"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+type Scrypt struct {
+	Name string
+}
+
+func NewScrypt() *Scrypt {
+	return &Scrypt{Name: "scrypt"}
+}
+
+func (s *Scrypt) BuildForm() *fyne.Container {
+	header := common.GetHeader(s.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	actionButton := common_kdf.GetActionButton()
+	saltLabel, saltEntry, saltFormat, generateSaltButton := common_kdf.GetSaltEntry()
+
+	defaults := core.DefaultKDFParams(core.KDFScrypt)
+	logNLabel := widget.NewLabel(lang.L("ScryptCost"))
+	logNEntry := common_kdf.GetNumberEntry(int(defaults.LogN))
+	rLabel := widget.NewLabel(lang.L("BlockSize"))
+	rEntry := common_kdf.GetNumberEntry(int(defaults.R))
+	pLabel := widget.NewLabel(lang.L("Parallelism"))
+	pEntry := common_kdf.GetNumberEntry(int(defaults.P))
+	lengthLabel := widget.NewLabel(lang.L("OutputLength"))
+	lengthEntry := common_kdf.GetNumberEntry(32)
+
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatHex)
+
+	params := func() (core.KDFParams, error) {
+		salt, err := saltFormat.Bytes()
+		if err != nil {
+			return core.KDFParams{}, err
+		}
+		return core.KDFParams{
+			KDF:  core.KDFScrypt,
+			LogN: uint8(min(common_kdf.Number(logNEntry), 255)),
+			R:    uint32(common_kdf.Number(rEntry)),
+			P:    uint32(common_kdf.Number(pEntry)),
+			Salt: salt,
+		}, nil
+	}
+
+	calibrator := common_kdf.GetCalibrator(params, func(p core.KDFParams) {
+		logNEntry.SetText(strconv.Itoa(int(p.LogN)))
+	})
+
+	actionButton.OnTapped = func() {
+		if !common_kdf.ValidateAll(logNEntry, rEntry, pEntry, lengthEntry) {
+			return
+		}
+		password, err := inputFormat.Bytes()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		kdfParams, err := params()
+		if err != nil {
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		length := common_kdf.Number(lengthEntry)
+
+		common_kdf.Derive(actionButton, outputEntry, outputFormat, func() ([]byte, error) {
+			return kdfParams.DeriveKey(password, length)
+		})
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		container.NewHBox(saltLabel, saltFormat),
+		container.NewBorder(nil, nil, nil, generateSaltButton, saltEntry),
+		container.NewBorder(nil, nil, logNLabel, nil, logNEntry),
+		container.NewBorder(nil, nil, rLabel, nil, rEntry),
+		container.NewBorder(nil, nil, pLabel, nil, pEntry),
+		container.NewBorder(nil, nil, lengthLabel, nil, lengthEntry),
+		calibrator,
+		actionButton,
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
@@ -0,0 +1,248 @@
+package recipe
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type Recipe struct {
+	Name       string
+	operations func() []*core.Operation
+	lookup     func(category, name string) (*core.Operation, bool)
+}
+
+// NewRecipe builds the pipeline form. operations lists what can be added as
+// a step and lookup resolves the steps of a loaded recipe file.
+func NewRecipe(operations func() []*core.Operation, lookup func(category, name string) (*core.Operation, bool)) *Recipe {
+	return &Recipe{Name: "Recipe", operations: operations, lookup: lookup}
+}
+
+func stepTitle(op *core.Operation) string {
+	return op.Category + "/" + op.Name
+}
+
+func (r *Recipe) BuildForm() *fyne.Container {
+	header := common.GetHeader(r.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	outputLabel, outputEntry, copyButton, outputFormat := common.GetOutput()
+
+	recipe := &core.Recipe{}
+
+	ops := r.operations()
+	titles := make([]string, 0, len(ops))
+	for _, op := range ops {
+		titles = append(titles, stepTitle(op))
+	}
+
+	stepsBox := container.NewVBox()
+	var refreshSteps func()
+	refreshSteps = func() {
+		stepsBox.RemoveAll()
+		for i, step := range recipe.Steps {
+			stepsBox.Add(stepView(recipe, i, step, refreshSteps))
+		}
+		stepsBox.Refresh()
+	}
+
+	operationSelect := widget.NewSelect(titles, nil)
+	operationSelect.PlaceHolder = lang.L("Operation")
+	addButton := widget.NewButton(lang.L("Add"), func() {
+		i := operationSelect.SelectedIndex()
+		if i < 0 {
+			return
+		}
+		recipe.Steps = append(recipe.Steps, &core.Step{Op: ops[i], Options: core.Options{}})
+		refreshSteps()
+	})
+
+	saveButton := widget.NewButton(lang.L("Save"), func() {
+		data, err := json.MarshalIndent(recipe, "", "  ")
+		if err != nil {
+			dialog.ShowError(err, common.GetWindow())
+			return
+		}
+		saveDialog := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil || w == nil {
+				return
+			}
+			defer w.Close()
+			if _, err := w.Write(data); err != nil {
+				log.Println("Recipe save error:", err)
+				dialog.ShowError(err, common.GetWindow())
+			}
+		}, common.GetWindow())
+		saveDialog.SetFileName("recipe.json")
+		saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		saveDialog.Show()
+	})
+
+	loadButton := widget.NewButton(lang.L("Load"), func() {
+		loadDialog := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil || rc == nil {
+				return
+			}
+			defer rc.Close()
+			data, err := io.ReadAll(rc)
+			if err != nil {
+				dialog.ShowError(err, common.GetWindow())
+				return
+			}
+			loaded, err := core.ParseRecipe(data, r.lookup)
+			if err != nil {
+				log.Println("Recipe load error:", err)
+				dialog.ShowError(err, common.GetWindow())
+				return
+			}
+			recipe.Steps = loaded.Steps
+			refreshSteps()
+		}, common.GetWindow())
+		loadDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		loadDialog.Show()
+	})
+
+	runButton := widget.NewButton(lang.L("Run"), nil)
+	runButton.OnTapped = func() {
+		go func() {
+			fyne.Do(func() {
+				runButton.Disable()
+				defer runButton.Enable()
+
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				result, err := recipe.Run(data)
+				if err != nil {
+					log.Println("Recipe error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				outputFormat.SetBytes(result)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewBorder(nil, nil, nil, container.NewHBox(addButton, saveButton, loadButton), operationSelect),
+		stepsBox,
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		runButton,
+		container.NewHBox(outputLabel, outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+}
+
+func stepView(recipe *core.Recipe, i int, step *core.Step, refresh func()) fyne.CanvasObject {
+	title := widget.NewLabel(strconv.Itoa(i+1) + ". " + stepTitle(step.Op))
+	title.TextStyle.Bold = true
+
+	enabledCheck := widget.NewCheck(lang.L("Enabled"), func(checked bool) {
+		step.Disabled = !checked
+	})
+	enabledCheck.SetChecked(!step.Disabled)
+
+	upButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+		recipe.Move(i, -1)
+		refresh()
+	})
+	downButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+		recipe.Move(i, 1)
+		refresh()
+	})
+	removeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		recipe.Remove(i)
+		refresh()
+	})
+
+	form := widget.NewForm()
+	for _, p := range step.Op.Params {
+		form.Append(p.Name, paramEditor(p, step.Options))
+	}
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, enabledCheck, container.NewHBox(upButton, downButton, removeButton), title),
+		form,
+		widget.NewSeparator(),
+	)
+}
+
+// paramEditor returns a widget that writes the value of p into opts as it changes.
+func paramEditor(p core.Param, opts core.Options) fyne.CanvasObject {
+	if _, ok := opts[p.Name]; !ok && p.Default != nil {
+		opts[p.Name] = p.Default
+	}
+
+	switch p.Kind {
+	case core.KindBytes:
+		entry, format := common.GetBytesEntry(common.FormatText)
+		entry.PlaceHolder = p.Usage
+		format.SetBytes(opts.Bytes(p.Name))
+
+		entry.OnChanged = func(string) {
+			value, err := format.Bytes()
+			if err != nil {
+				entry.SetValidationError(err)
+				delete(opts, p.Name)
+				return
+			}
+			entry.SetValidationError(nil)
+			opts[p.Name] = value
+		}
+
+		return container.NewBorder(nil, nil, nil, format, entry)
+	case core.KindString:
+		if len(p.Choices) > 0 {
+			choiceSelect := widget.NewSelect(p.Choices, func(selected string) {
+				opts[p.Name] = selected
+			})
+			choiceSelect.SetSelected(opts.String(p.Name))
+			return choiceSelect
+		}
+		entry := widget.NewEntry()
+		entry.PlaceHolder = p.Usage
+		entry.SetText(opts.String(p.Name))
+		entry.OnChanged = func(s string) {
+			opts[p.Name] = s
+		}
+		return entry
+	case core.KindInt:
+		entry := widget.NewEntry()
+		entry.PlaceHolder = p.Usage
+		entry.SetText(strconv.Itoa(opts.Int(p.Name)))
+		entry.Validator = func(s string) error {
+			_, err := strconv.Atoi(s)
+			return err
+		}
+		entry.OnChanged = func(s string) {
+			if n, err := strconv.Atoi(s); err == nil {
+				opts[p.Name] = n
+			}
+		}
+		return entry
+	case core.KindBool:
+		check := widget.NewCheck(p.Usage, func(checked bool) {
+			opts[p.Name] = checked
+		})
+		check.SetChecked(opts.Bool(p.Name))
+		return check
+	}
+
+	return widget.NewLabel(p.Usage)
+}
@@ -0,0 +1,265 @@
+package sign
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_sign"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+var signatureEncodingDescriptions = map[string]string{
+	"DER": "DER - ASN.1 sequence of r and s, as in X.509 and OpenSSL",
+	"raw": "raw - r ‖ s at the curve size, as in JWS (ES256) and WebCrypto",
+}
+
+// ECDSA signs and verifies detached signatures over the NIST curves. Raw
+// keys are read on the selected curve; a PEM key selects its own curve.
+type ECDSA struct {
+	Name string
+}
+
+func NewECDSA() *ECDSA {
+	return &ECDSA{Name: "ECDSA"}
+}
+
+func (e *ECDSA) BuildForm() *fyne.Container {
+	header := common.GetHeader(e.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	modeToggle, actionButton := common_sign.GetActionButton()
+
+	curveSelect := widget.NewSelect(core.ECDSACurves, nil)
+	curveSelect.SetSelected(core.ECDSACurves[0])
+	currentCurve := curveSelect.Selected
+	hashSelect := widget.NewSelect(core.ECDSAHashes, nil)
+	hashSelect.SetSelected(core.ECDSADefaultHash(currentCurve))
+
+	encodingSelect := widget.NewSelect(core.SignatureEncodings, nil)
+	encodingSelect.SetSelected("DER")
+	encodingDescription := widget.NewLabel(signatureEncodingDescriptions["DER"])
+	encodingDescription.TextStyle.Italic = true
+	encodingSelect.OnChanged = func(selected string) {
+		encodingDescription.SetText(signatureEncodingDescriptions[selected])
+	}
+
+	keyFormatSelect := widget.NewSelect(core.SignKeyFormats, nil)
+	keyFormatSelect.SetSelected("PEM")
+	privateKeyEntry := newKeyEntry()
+	loadPrivateKeyButton, savePrivateKeyButton := common.GetKeyFileButtons(privateKeyEntry, lang.L("LoadPrivateKey"), lang.L("SavePrivateKey"), "ecdsa.pem")
+	publicKeyEntry := newKeyEntry()
+	publicKeyCopyButton := widget.NewButton(lang.L("Copy"), func() {
+		if publicKeyEntry.Text != "" {
+			fyne.CurrentApp().Clipboard().SetContent(publicKeyEntry.Text)
+		}
+	})
+	loadPublicKeyButton, savePublicKeyButton := common.GetKeyFileButtons(publicKeyEntry, lang.L("LoadPublicKey"), lang.L("SavePublicKey"), "ecdsa.pub.pem")
+
+	signatureEntry, signatureFormat := common.GetBytesEntry(common.FormatBase64)
+	signatureEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := signatureFormat.Bytes()
+		return err
+	}
+	_, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+	resultLabel := common_sign.GetResultLabel()
+
+	// Keys parsed from the entries
+	var privateKey *ecdsa.PrivateKey
+	var publicKey *ecdsa.PublicKey
+
+	showPrivateKey := func(key *ecdsa.PrivateKey) {
+		encoded, err := core.EncodeECDSAPrivateKey(key, keyFormatSelect.Selected)
+		if err != nil {
+			log.Println("ECDSA key error:", err)
+			return
+		}
+		privateKeyEntry.SetText(keyText(encoded, keyFormatSelect.Selected))
+	}
+	showPublicKey := func(key *ecdsa.PublicKey) {
+		encoded, err := core.EncodeECDSAPublicKey(key, keyFormatSelect.Selected)
+		if err != nil {
+			log.Println("ECDSA key error:", err)
+			return
+		}
+		publicKeyEntry.SetText(keyText(encoded, keyFormatSelect.Selected))
+	}
+	// a PEM key of another curve switches the curve select
+	followCurve := func(key *ecdsa.PublicKey) {
+		if name := core.ECDSACurveName(key); name != currentCurve {
+			currentCurve = name
+			curveSelect.SetSelected(name)
+		}
+	}
+
+	privateKeyEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := parseECDSAPrivateKey(currentCurve, s)
+		return err
+	}
+	privateKeyEntry.OnChanged = func(s string) {
+		privateKey, _ = parseECDSAPrivateKey(currentCurve, s)
+		if privateKey == nil {
+			return
+		}
+		followCurve(&privateKey.PublicKey)
+		// the public key always follows the private key
+		if publicKey == nil || !publicKey.Equal(&privateKey.PublicKey) {
+			showPublicKey(&privateKey.PublicKey)
+		}
+	}
+	publicKeyEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := parseECDSAPublicKey(currentCurve, s)
+		return err
+	}
+	publicKeyEntry.OnChanged = func(s string) {
+		publicKey, _ = parseECDSAPublicKey(currentCurve, s)
+		if publicKey != nil {
+			followCurve(publicKey)
+		}
+	}
+	keyFormatSelect.OnChanged = func(string) {
+		if privateKey != nil {
+			showPrivateKey(privateKey)
+		}
+		if publicKey != nil {
+			showPublicKey(publicKey)
+		}
+	}
+	curveSelect.OnChanged = func(selected string) {
+		hashSelect.SetSelected(core.ECDSADefaultHash(selected))
+		if selected == currentCurve {
+			return
+		}
+		// keys of the old curve do not carry over
+		currentCurve = selected
+		privateKeyEntry.SetText("")
+		publicKeyEntry.SetText("")
+	}
+
+	generateKeyButton := widget.NewButton(lang.L("GenerateKeys"), func() {
+		key, err := core.GenerateECDSAKey(currentCurve)
+		if err != nil {
+			log.Println("Error generating ECDSA key:", err)
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		showPrivateKey(key)
+	})
+
+	signatureRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("Signature")), signatureFormat),
+		signatureEntry,
+	)
+	outputRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("Signature")), outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Verify"))
+		} else {
+			actionButton.SetText(lang.L("Sign"))
+		}
+		setVisible(signatureRows, checked)
+		setVisible(outputRows, !checked)
+		resultLabel.SetText("")
+	}
+	modeToggle.OnChanged(false)
+
+	actionButton.OnTapped = func() {
+		validated := []*widget.Entry{privateKeyEntry}
+		if modeToggle.Checked {
+			validated = []*widget.Entry{publicKeyEntry, signatureEntry}
+		}
+		for _, entry := range validated {
+			if err := entry.Validate(); err != nil {
+				entry.SetValidationError(err)
+				return
+			}
+		}
+
+		if inputEntry.Text == "" {
+			return
+		}
+
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					log.Println("Input decode error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+
+				if modeToggle.Checked {
+					signature, _ := signatureFormat.Bytes()
+					valid, err := core.VerifyECDSA(publicKey, hashSelect.Selected, data, signature, encodingSelect.Selected)
+					common_sign.SetResult(resultLabel, valid, err)
+					return
+				}
+
+				signature, err := core.SignECDSA(privateKey, hashSelect.Selected, data, encodingSelect.Selected)
+				if err != nil {
+					log.Println("ECDSA error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				outputFormat.SetBytes(signature)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(widget.NewLabel(lang.L("Curve")), curveSelect, widget.NewLabel(lang.L("HashName")), hashSelect),
+		container.NewHBox(widget.NewLabel(lang.L("SignatureEncoding")), encodingSelect),
+		encodingDescription,
+		container.NewHBox(widget.NewLabel(lang.L("KeyFormat")), keyFormatSelect, generateKeyButton),
+		widget.NewLabel(lang.L("PrivateKey")),
+		privateKeyEntry,
+		container.NewHBox(loadPrivateKeyButton, savePrivateKeyButton),
+		widget.NewLabel(lang.L("PublicKey")),
+		container.NewBorder(nil, nil, nil, publicKeyCopyButton, publicKeyEntry),
+		container.NewHBox(loadPublicKeyButton, savePublicKeyButton),
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		signatureRows,
+		container.NewVBox(modeToggle, actionButton),
+		resultLabel,
+		outputRows,
+	)
+}
+
+func parseECDSAPrivateKey(curve, s string) (*ecdsa.PrivateKey, error) {
+	data, err := keyBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	return core.ParseECDSAPrivateKey(curve, data)
+}
+
+func parseECDSAPublicKey(curve, s string) (*ecdsa.PublicKey, error) {
+	data, err := keyBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	return core.ParseECDSAPublicKey(curve, data)
+}
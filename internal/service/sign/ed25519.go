@@ -0,0 +1,284 @@
+package sign
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"log"
+	"pararti/chify/core"
+	"pararti/chify/internal/common"
+	"pararti/chify/internal/common/common_sign"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+var ed25519Descriptions = map[core.Ed25519Variant]string{
+	core.Ed25519Pure: "Ed25519 - signs the message itself, as in SSH, TLS and JWT (EdDSA)",
+	core.Ed25519ph:   "Ed25519ph - signs the SHA-512 digest of the message, with an optional context",
+	core.Ed25519ctx:  "Ed25519ctx - binds the signature to a context, which must not be empty",
+}
+
+// Ed25519 signs and verifies detached RFC 8032 signatures. Keys are shown as
+// PEM or raw hex and may be pasted in either form.
+type Ed25519 struct {
+	Name string
+}
+
+func NewEd25519() *Ed25519 {
+	return &Ed25519{Name: "Ed25519"}
+}
+
+func (e *Ed25519) BuildForm() *fyne.Container {
+	header := common.GetHeader(e.Name)
+	inputLabel, inputEntry, resetButton, inputFormat := common.GetInput()
+	modeToggle, actionButton := common_sign.GetActionButton()
+
+	modeLabel := widget.NewLabel(lang.L("Mode"))
+	modeSelect := widget.NewSelect(core.Ed25519Variants(), nil)
+	modeSelect.SetSelected(core.Ed25519Pure.String())
+	var currentVariant = core.Ed25519Pure
+
+	modeDescription := widget.NewLabel(ed25519Descriptions[currentVariant])
+	modeDescription.TextStyle.Italic = true
+
+	contextEntry, contextFormat := common.GetBytesEntry(common.FormatText)
+
+	keyFormatSelect := widget.NewSelect(core.SignKeyFormats, nil)
+	keyFormatSelect.SetSelected("PEM")
+	privateKeyEntry := newKeyEntry()
+	loadPrivateKeyButton, savePrivateKeyButton := common.GetKeyFileButtons(privateKeyEntry, lang.L("LoadPrivateKey"), lang.L("SavePrivateKey"), "ed25519.pem")
+	publicKeyEntry := newKeyEntry()
+	publicKeyCopyButton := widget.NewButton(lang.L("Copy"), func() {
+		if publicKeyEntry.Text != "" {
+			fyne.CurrentApp().Clipboard().SetContent(publicKeyEntry.Text)
+		}
+	})
+	loadPublicKeyButton, savePublicKeyButton := common.GetKeyFileButtons(publicKeyEntry, lang.L("LoadPublicKey"), lang.L("SavePublicKey"), "ed25519.pub.pem")
+
+	signatureEntry, signatureFormat := common.GetBytesEntry(common.FormatBase64)
+	signatureEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := signatureFormat.Bytes()
+		return err
+	}
+	_, outputEntry, copyButton, outputFormat := common.GetOutput()
+	outputFormat.SetFormat(common.FormatBase64)
+	resultLabel := common_sign.GetResultLabel()
+
+	// Keys parsed from the entries: the seed and the public key
+	var privateKey, publicKey []byte
+
+	showKey := func(entry *widget.Entry, key []byte, private bool) {
+		encoded, err := core.EncodeEd25519Key(key, private, keyFormatSelect.Selected)
+		if err != nil {
+			log.Println("Ed25519 key error:", err)
+			return
+		}
+		entry.SetText(keyText(encoded, keyFormatSelect.Selected))
+	}
+
+	privateKeyEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := parseEd25519Key(s, true)
+		return err
+	}
+	privateKeyEntry.OnChanged = func(s string) {
+		privateKey, _ = parseEd25519Key(s, true)
+		// the public key always follows the private key
+		if privateKey != nil {
+			if public, err := core.Ed25519PublicKey(privateKey); err == nil && !bytes.Equal(public, publicKey) {
+				showKey(publicKeyEntry, public, false)
+			}
+		}
+	}
+	publicKeyEntry.Validator = func(s string) error {
+		if s == "" {
+			return errors.New(lang.L("Required"))
+		}
+		_, err := parseEd25519Key(s, false)
+		return err
+	}
+	publicKeyEntry.OnChanged = func(s string) {
+		publicKey, _ = parseEd25519Key(s, false)
+	}
+	keyFormatSelect.OnChanged = func(string) {
+		if privateKey != nil {
+			showKey(privateKeyEntry, privateKey, true)
+		}
+		if publicKey != nil {
+			showKey(publicKeyEntry, publicKey, false)
+		}
+	}
+
+	generateKeyButton := widget.NewButton(lang.L("GenerateKeys"), func() {
+		private, _, err := core.GenerateEd25519Key()
+		if err != nil {
+			log.Println("Error generating Ed25519 key:", err)
+			outputEntry.SetText("Error: " + err.Error())
+			return
+		}
+		showKey(privateKeyEntry, private, true)
+	})
+
+	contextRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("Context")), contextFormat),
+		contextEntry,
+	)
+	signatureRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("Signature")), signatureFormat),
+		signatureEntry,
+	)
+	outputRows := container.NewVBox(
+		container.NewHBox(widget.NewLabel(lang.L("Signature")), outputFormat),
+		container.NewBorder(nil, nil, nil, copyButton, outputEntry),
+	)
+
+	// Verifying reads a signature, signing writes one; plain Ed25519 has no context.
+	updateFields := func() {
+		setVisible(contextRows, currentVariant != core.Ed25519Pure)
+		setVisible(signatureRows, modeToggle.Checked)
+		setVisible(outputRows, !modeToggle.Checked)
+		resultLabel.SetText("")
+	}
+
+	modeToggle.OnChanged = func(checked bool) {
+		if checked {
+			actionButton.SetText(lang.L("Verify"))
+		} else {
+			actionButton.SetText(lang.L("Sign"))
+		}
+		updateFields()
+	}
+	modeSelect.OnChanged = func(selected string) {
+		variant, err := core.ParseEd25519Variant(selected)
+		if err != nil {
+			return
+		}
+		currentVariant = variant
+		modeDescription.SetText(ed25519Descriptions[variant])
+		updateFields()
+	}
+	updateFields()
+
+	actionButton.OnTapped = func() {
+		validated := []*widget.Entry{privateKeyEntry}
+		if modeToggle.Checked {
+			validated = []*widget.Entry{publicKeyEntry, signatureEntry}
+		}
+		for _, entry := range validated {
+			if err := entry.Validate(); err != nil {
+				entry.SetValidationError(err)
+				return
+			}
+		}
+
+		if inputEntry.Text == "" {
+			return
+		}
+
+		go func() {
+			fyne.Do(func() {
+				actionButton.Disable()
+				defer actionButton.Enable()
+
+				data, err := inputFormat.Bytes()
+				if err != nil {
+					log.Println("Input decode error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				var context []byte
+				if currentVariant != core.Ed25519Pure {
+					if context, err = contextFormat.Bytes(); err != nil {
+						outputEntry.SetText("Error: " + err.Error())
+						return
+					}
+				}
+
+				if modeToggle.Checked {
+					signature, _ := signatureFormat.Bytes()
+					valid, err := core.VerifyEd25519(currentVariant, publicKey, context, data, signature)
+					common_sign.SetResult(resultLabel, valid, err)
+					return
+				}
+
+				signature, err := core.SignEd25519(currentVariant, privateKey, context, data)
+				if err != nil {
+					log.Println("Ed25519 error:", err)
+					outputEntry.SetText("Error: " + err.Error())
+					return
+				}
+				outputFormat.SetBytes(signature)
+			})
+		}()
+	}
+
+	return container.NewVBox(
+		header,
+		container.NewHBox(modeLabel, modeSelect),
+		modeDescription,
+		contextRows,
+		container.NewHBox(widget.NewLabel(lang.L("KeyFormat")), keyFormatSelect, generateKeyButton),
+		widget.NewLabel(lang.L("PrivateKey")),
+		privateKeyEntry,
+		container.NewHBox(loadPrivateKeyButton, savePrivateKeyButton),
+		widget.NewLabel(lang.L("PublicKey")),
+		container.NewBorder(nil, nil, nil, publicKeyCopyButton, publicKeyEntry),
+		container.NewHBox(loadPublicKeyButton, savePublicKeyButton),
+		container.NewHBox(inputLabel, inputFormat),
+		container.NewBorder(nil, nil, nil, resetButton, inputEntry),
+		signatureRows,
+		container.NewVBox(modeToggle, actionButton),
+		resultLabel,
+		outputRows,
+	)
+}
+
+func parseEd25519Key(s string, private bool) ([]byte, error) {
+	data, err := keyBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	if private {
+		return core.ParseEd25519PrivateKey(data)
+	}
+	return core.ParseEd25519PublicKey(data)
+}
+
+func newKeyEntry() *widget.Entry {
+	entry := widget.NewMultiLineEntry()
+	entry.Wrapping = fyne.TextWrapBreak
+	entry.SetMinRowsVisible(3)
+	return entry
+}
+
+// keyText shows a PEM key as it is and a raw one as hex.
+func keyText(key []byte, format string) string {
+	if format == "raw" {
+		return hex.EncodeToString(key)
+	}
+	return string(key)
+}
+
+// keyBytes reads a key entry: PEM text, or raw or DER bytes in hex or base64.
+func keyBytes(s string) ([]byte, error) {
+	if common.IsPEM(s) {
+		return []byte(s), nil
+	}
+	return common.DecodeKeyText(s)
+}
+
+func setVisible(obj fyne.CanvasObject, visible bool) {
+	if visible {
+		obj.Show()
+	} else {
+		obj.Hide()
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"embed"
 	"log"
+	"os"
+	"pararti/chify/internal/cli"
 	"pararti/chify/internal/registry"
 
 	"fyne.io/fyne/v2"
@@ -19,6 +21,11 @@ var translations embed.FS
 var icon []byte
 
 func main() {
+	// Any argument switches to the non-interactive command line mode
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	a := app.New()
 	resourceIcon := fyne.NewStaticResource("chify.png", icon)
 	a.SetIcon(resourceIcon)